@@ -75,6 +75,7 @@ Example:
 
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(upgradeCmd)
+	rootCmd.AddCommand(newAPIKeyCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
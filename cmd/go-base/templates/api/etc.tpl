@@ -105,12 +105,159 @@ Auth:
   # Token expiration time in seconds
   AccessExpire: 3600
 
+# ==================== Identity signature (go-base extension) ====================
+# Verify the gateway-signed x-jwt-* identity headers (optional; must match the gateway Auth.IdentitySign)
+# IdentitySign:
+#   Enabled: true
+#   Algorithm: hmac  # hmac or ed25519 (use PublicKey for ed25519)
+#   Secret: change-me
+#   MaxAge: 5m
+# Without IdentitySign, x-jwt-* headers are ignored unless trusted (only if the service is reachable through the gateway only)
+# TrustIdentityHeaders: true
+
+# Role/scope authorization policy file (optional, see README)
+# AuthPolicy: etc/policy.yaml
+
 # ==================== Signature configuration (SignatureConf) ====================
-# Signature verification config (optional)
+# go-zero content security signature config (optional; RSA-based, see go-zero docs)
 # Signature:
 #   Strict: false  # Strict mode
 #   Expiry: 3600   # Signature expiry (seconds)
 
+# HMAC request signature verification for partner requests (optional, go-base extension)
+# Signed over method, path, sorted query, body hash, timestamp and nonce (see pkg/signature)
+# RequestSignature:
+#   Enabled: true
+#   Keys:                # Several key ids may be active during rotation
+#     - ID: partner-2024
+#       Secret: change-me
+#   Paths:               # Path patterns requiring a signature; empty means all
+#     - /callbacks/**
+#   Window: 5m           # Accepted clock skew / replay window
+#   Store: memory        # Nonce store: memory (single instance) or redis (cluster)
+#   # Redis:
+#   #   Host: localhost:6379
+
+# Response compression negotiated by Accept-Encoding (optional)
+# Compress:
+#   Enabled: true
+#   Encodings: [br, zstd, gzip, deflate]  # Preference order for equal q-values
+#   Level: default          # fastest, default or best
+#   MinSize: 1024           # Smaller responses are sent uncompressed
+#   ContentTypes: [application/json, text/*]  # Default: JSON, JavaScript, XML, SVG, HTML, CSS, plain text, CSV
+
+# Security headers: HSTS, CSP, X-Content-Type-Options, X-Frame-Options, Referrer-Policy, Permissions-Policy
+# (applied by default when App.Env is prod; set Enabled to apply in other environments)
+# SecurityHeaders:
+#   Disabled: false         # true opts out in prod
+#   HSTS: "max-age=31536000; includeSubDomains"
+#   CSP: "default-src 'none'; frame-ancestors 'none'"
+#   CSPReportOnly: false    # Send Content-Security-Policy-Report-Only instead
+#   CSPReportURI: /csp-report
+#   CSPRoutes:
+#     - Path: /docs/**
+#       Policy: "default-src 'self'; style-src 'self' 'unsafe-inline'"
+#       ReportOnly: true
+#   FrameOptions: DENY      # "-" omits a header
+
+# Client IP: the forwarding header set by these proxies is only trusted from them
+# ClientIP:
+#   TrustedProxies: [10.0.0.0/8, 172.16.0.0/12]  # Load balancers / ingress; empty uses the peer address
+#   Header: X-Forwarded-For     # The one header the proxies set: Forwarded, X-Forwarded-For or X-Real-Ip
+
+# Client IP allow/deny lists per route; the first matching rule applies (optional)
+# IPFilter:
+#   Enabled: true
+#   Rules:
+#     - Path: /admin/**
+#       Allow: [203.0.113.0/24]   # Office CIDRs
+#     - Path: /**
+#       Deny: [198.51.100.7]
+
+# Structured access log: route, status, business code, latency, user, request id (optional)
+# AccessLog:
+#   Enabled: true
+#   SkipPaths: [/health]
+#   Headers: [User-Agent, Authorization]  # Request headers to log; sensitive ones are logged as ***
+#   BodySampleRate: 0.01    # Fraction of requests logged with (redacted) bodies; 0 disables
+#   MaxBodySize: 4096
+#   RedactFields: [password, token, accessToken, refreshToken]  # JSON fields logged as *** (defaults shown in README)
+#   MaskFields: [phone, mobile]            # JSON fields partially masked, e.g. 138****5678
+#   SlowThreshold: 1000     # Milliseconds
+
+# Audit trail of state-changing requests: actor, action, resource ids, outcome, client IP (optional)
+# Audit:
+#   Enabled: true
+#   Rules:                  # Empty audits every POST, PUT, PATCH and DELETE
+#     - Path: /api/v1/orders/:id/cancel
+#       Action: order.cancel
+#       Resource: order
+#   Sinks:                  # Empty writes to the log
+#     - Type: file          # log, file or webhook
+#       Path: logs/audit.log
+#     # - Type: webhook
+#     #   URL: https://audit.example.com/events
+#     #   Secret: change-me  # Signs the body in X-Audit-Signature
+#   BufferSize: 1024
+#   OnFull: block           # block (up to BlockTimeout) or drop when the buffer is full
+
+# Multi-tenancy: tenant from the gateway-forwarded identity, the token claim, the header or the subdomain (optional)
+# Tenant:
+#   Enabled: true
+#   Claim: tid
+#   Header: X-Tenant-Id
+#   RateLimit:
+#     Enabled: true
+#     Rate: 100             # Requests per second per tenant
+#     Burst: 200
+
+# Idempotency-Key support for unsafe methods (optional, go-base extension)
+# The first response for a key (scoped to the caller user id) is stored and replayed on retries
+# Idempotency:
+#   Enabled: true
+#   Header: Idempotency-Key
+#   Methods: [POST, PATCH]  # Default POST and PATCH
+#   Paths:                  # Path patterns honoring the key; empty means all
+#     - /orders/**
+#   TTL: 24h                # How long completed responses are kept
+#   LockTTL: 30s            # How long an in-flight request holds the key
+#   Store: memory           # memory (single instance) or redis (cluster)
+#   # Redis:
+#   #   Host: localhost:6379
+
+# Response cache for GET routes: only 200 responses with code 0 are cached (optional, go-base extension)
+# Cache:
+#   Enabled: true
+#   TTL: 1m                 # Default time responses are fresh
+#   StaleTTL: 0s            # Default stale-while-revalidate window
+#   Rules:                  # The first matching rule applies
+#     - Path: /products/**
+#       TTL: 5m
+#       StaleTTL: 1m
+#       Query: [page, size] # Query parameters in the key; empty means the whole query
+#       Headers: [Accept-Language]
+#       Scope: public       # user (default), tenant or public
+#   MaxEntries: 10000       # Memory store capacity (LRU)
+#   MaxBodySize: 1048576
+#   Store: memory           # memory (single instance) or redis (cluster)
+#   # Redis:
+#   #   Host: localhost:6379
+
+# Cookie session authentication for browser clients (optional, go-base extension)
+# Session:
+#   Enabled: true
+#   Secret: change-me          # Encrypts cookie sessions and signs CSRF cookies
+#   Store: cookie              # cookie (encrypted, stateless), memory or redis
+#   MaxAge: 24h
+#   Secure: true               # HTTPS only
+#   SameSite: lax              # lax | strict | none
+#   CSRF:
+#     Enabled: true
+#     Mode: synchronizer       # synchronizer or double-submit
+#     Header: X-CSRF-Token
+#     SkipPaths:
+#       - /webhooks/**
+
 # ==================== Prometheus configuration ====================
 # Prometheus monitoring config (deprecated since v1.4.3+; prefer MetricsUrl)
 # Prometheus:
@@ -6,6 +6,7 @@ import (
 	"github.com/zeromicro/go-zero/rest/httpx"
 
 	"github.com/addls/go-base/pkg/response"
+	"github.com/addls/go-base/pkg/validation"
 
 	{{.ImportPackages}}
 )
@@ -17,6 +18,10 @@ func {{.HandlerName}}(svcCtx *svc.ServiceContext) http.HandlerFunc {
 			response.ErrorInvalidParam(w, err)
 			return
 		}
+		if err := validation.ValidateRequest(r, &req); err != nil {
+			response.ErrorValidation(w, err)
+			return
+		}
 
 		{{end}}l := {{.LogicName}}.New{{.LogicType}}(r.Context(), svcCtx)
 		{{if .HasResp}}resp, {{end}}err := l.{{.Call}}({{if .HasRequest}}&req{{end}})
@@ -79,9 +79,197 @@ Middlewares:
 # Auth:
 #   AccessSecret: your-jwt-secret  # JWT signing secret (required)
 #   AccessExpire: 3600             # Token expiration time in seconds (optional)
-#   SkipPaths:                      # Paths that skip JWT verification (optional)
+#   SkipPaths:                      # Path patterns that skip JWT verification (optional)
 #     - /ping
 #     - /health
+#     - /public/*                   # Trailing * matches everything below /public/
+#   Rules:                          # Per-route rules (optional; first match wins, checked before SkipPaths)
+#     - Path: /users/:id/avatar     # Path parameters (:id or {id}) match one segment
+#       Methods: [GET]              # Only GET is affected; empty means all methods
+#       Mode: skip                  # skip | optional | required
+#     - Path: /articles/**          # ** matches zero or more segments
+#       Mode: optional              # Token not required, but decoded and passed through when present
+#   Revocation:                     # Token revocation denylist (optional)
+#     Enabled: true
+#     Store: memory                 # memory (single instance) or redis (cluster)
+#     # Redis:
+#     #   Host: localhost:6379
+#     AdminPath: /admin/auth/revoke # Admin endpoint: POST {"jti": "...", "userId": "..."}
+#     AdminKey: change-me           # Required X-Admin-Key header value for the admin endpoint
+#     FailClosed: false             # Reject requests while the store is unavailable (default: let them through)
+#   IdentitySign:                   # Sign the forwarded x-jwt-* identity (optional; backends verify it)
+#     Enabled: true
+#     Algorithm: hmac               # hmac (shared Secret) or ed25519 (PrivateKey here, PublicKey in backends)
+#     Secret: change-me
+#     MaxAge: 5m                    # Maximum age of a signed identity
+#   Policy: etc/policy.yaml         # Role/scope authorization policy (optional, see README)
+#   APIKey:                         # API key authentication for machine clients (optional)
+#     Enabled: true
+#     Header: X-Api-Key
+#     Store: config                 # config (Keys below) or redis
+#     Keys:                         # Generate entries with: go-base apikey gen --id <id>
+#       - ID: partner-a
+#         Hash: <sha256 of the key>
+#         Owner: partner-a          # Forwarded as x-jwt-user-id
+#         Scopes: [orders:read]
+#         Tenant: acme              # Optional tenant of the key (see Tenant)
+#         ExpiresAt: 2026-12-31T00:00:00Z  # Optional RFC 3339 expiry
+#   OIDC:                           # OpenID Connect login (optional; requires AccessSecret and AccessExpire)
+#     Enabled: true
+#     Issuer: https://accounts.example.com   # Discovery: Issuer + /.well-known/openid-configuration
+#     ClientID: go-base-gateway
+#     ClientSecret: change-me       # Empty for public clients (PKCE only)
+#     RedirectURL: https://api.example.com/auth/oidc/callback
+#     Scopes: [openid, profile, email]
+#     LoginPath: /auth/oidc/login   # GET, optional ?redirect=/app
+#     CallbackPath: /auth/oidc/callback
+#     UserClaim: sub                # ID token claim used as x-jwt-user-id
+#     Store: memory                 # Login state store: memory or redis
+#   Issuers:                        # Named token issuers (optional; AccessSecret, if set, is accepted as "default")
+#     - Name: customer              # Forwarded to backends as x-jwt-issuer
+#       Secret: customer-secret     # HMAC secret, or PublicKey: PEM encoded RSA/EC public key
+#       Issuer: https://id.example.com    # Expected iss claim (optional)
+#       Audience: [shop]            # Accepted aud values (optional)
+#     - Name: staff
+#       Secret: staff-secret
+#   Upstreams:                      # Accepted issuers for all mapped routes of an upstream (optional)
+#     - Name: admin                 # Upstreams[].Name
+#       Issuers: [staff]
+#   # Rules[].Issuers selects issuers per path as well and takes precedence over Upstreams.
+# Note: client-supplied x-jwt-* / Grpc-Metadata-x-jwt-* headers are always stripped by the gateway.
+
+# ==================== Request signature (go-base extension) ====================
+# HMAC request signature verification for partner requests (optional, see pkg/signature)
+# RequestSignature:
+#   Enabled: true
+#   Keys:
+#     - ID: partner-2024
+#       Secret: change-me
+#   Paths:
+#     - /partner/**
+#   Window: 5m
+#   Store: memory        # memory or redis
+
+# ==================== Compression (go-base extension) ====================
+# Response compression negotiated by Accept-Encoding (optional)
+# Compress:
+#   Enabled: true
+#   Encodings: [br, zstd, gzip, deflate]  # Preference order for equal q-values
+#   Level: default          # fastest, default or best
+#   MinSize: 1024           # Smaller responses are sent uncompressed
+#   ContentTypes: [application/json, text/*]  # Default: JSON, JavaScript, XML, SVG, HTML, CSS, plain text, CSV
+
+# ==================== Security headers (go-base extension) ====================
+# Security headers: HSTS, CSP, X-Content-Type-Options, X-Frame-Options, Referrer-Policy, Permissions-Policy
+# (applied by default when App.Env is prod; set Enabled to apply in other environments)
+# SecurityHeaders:
+#   Disabled: false         # true opts out in prod
+#   HSTS: "max-age=31536000; includeSubDomains"
+#   CSP: "default-src 'none'; frame-ancestors 'none'"
+#   CSPReportOnly: false    # Send Content-Security-Policy-Report-Only instead
+#   CSPReportURI: /csp-report
+#   CSPRoutes:
+#     - Path: /docs/**
+#       Policy: "default-src 'self'; style-src 'self' 'unsafe-inline'"
+#       ReportOnly: true
+#   FrameOptions: DENY      # "-" omits a header
+
+# ==================== Client IP (go-base extension) ====================
+# Client IP: the forwarding header set by these proxies is only trusted from them
+# ClientIP:
+#   TrustedProxies: [10.0.0.0/8, 172.16.0.0/12]  # Load balancers / ingress; empty uses the peer address
+#   Header: X-Forwarded-For     # The one header the proxies set: Forwarded, X-Forwarded-For or X-Real-Ip
+
+# Client IP allow/deny lists per route; the first matching rule applies (optional)
+# IPFilter:
+#   Enabled: true
+#   Rules:
+#     - Path: /admin/**
+#       Allow: [203.0.113.0/24]   # Office CIDRs
+#     - Path: /**
+#       Deny: [198.51.100.7]
+
+# ==================== Access log (go-base extension) ====================
+# Structured access log: route, status, business code, latency, user, request id (optional)
+# AccessLog:
+#   Enabled: true
+#   SkipPaths: [/health]
+#   Headers: [User-Agent, Authorization]  # Request headers to log; sensitive ones are logged as ***
+#   BodySampleRate: 0.01    # Fraction of requests logged with (redacted) bodies; 0 disables
+#   MaxBodySize: 4096
+#   RedactFields: [password, token, accessToken, refreshToken]  # JSON fields logged as *** (defaults shown in README)
+#   MaskFields: [phone, mobile]            # JSON fields partially masked, e.g. 138****5678
+#   SlowThreshold: 1000     # Milliseconds
+
+# ==================== Audit (go-base extension) ====================
+# Audit trail of state-changing requests: actor, action, resource ids, outcome, client IP (optional)
+# Audit:
+#   Enabled: true
+#   Rules:                  # Empty audits every POST, PUT, PATCH and DELETE
+#     - Path: /api/v1/orders/:id/cancel
+#       Action: order.cancel
+#       Resource: order
+#   Sinks:                  # Empty writes to the log
+#     - Type: file          # log, file or webhook
+#       Path: logs/audit.log
+#     # - Type: webhook
+#     #   URL: https://audit.example.com/events
+#     #   Secret: change-me  # Signs the body in X-Audit-Signature
+#   BufferSize: 1024
+#   OnFull: block           # block (up to BlockTimeout) or drop when the buffer is full
+
+# ==================== Tenant (go-base extension) ====================
+# Multi-tenancy (optional, requires Auth): the credential tenant (token claim or API key) is forwarded to
+# backends as x-jwt-tenant-id (tenant.FromContext); without one, the header or subdomain tenant is forwarded
+# as x-jwt-requested-tenant-id (tenant.RequestedFromContext)
+# Tenant:
+#   Enabled: true
+#   Claim: tid              # JWT claim carrying the tenant id (API keys use their Tenant field)
+#   Header: X-Tenant-Id     # Must match the token tenant if both are present
+#   Domain: example.com     # acme.example.com -> acme (optional)
+#   Required: false         # Reject authenticated requests whose credential carries no tenant
+#   RateLimit:
+#     Enabled: true
+#     Rate: 100             # Requests per second per tenant
+#     Burst: 200
+#     Overrides:
+#       - Tenant: acme
+#         Rate: 500
+#     Store: memory         # memory (single instance) or redis (cluster)
+
+# ==================== Idempotency (go-base extension) ====================
+# Idempotency-Key support for unsafe methods (optional, go-base extension)
+# The first response for a key (scoped to the caller user id) is stored and replayed on retries
+# Idempotency:
+#   Enabled: true
+#   Header: Idempotency-Key
+#   Methods: [POST, PATCH]  # Default POST and PATCH
+#   Paths:                  # Path patterns honoring the key; empty means all
+#     - /orders/**
+#   TTL: 24h                # How long completed responses are kept
+#   LockTTL: 30s            # How long an in-flight request holds the key
+#   Store: memory           # memory (single instance) or redis (cluster)
+#   # Redis:
+#   #   Host: localhost:6379
+
+# ==================== Response cache (go-base extension) ====================
+# Response cache for GET routes: only 200 responses with code 0 are cached (optional, go-base extension)
+# Cache:
+#   Enabled: true
+#   TTL: 1m                 # Default time responses are fresh
+#   StaleTTL: 0s            # Default stale-while-revalidate window
+#   Rules:                  # The first matching rule applies
+#     - Path: /products/**
+#       TTL: 5m
+#       StaleTTL: 1m
+#       Query: [page, size] # Query parameters in the key; empty means the whole query
+#       Headers: [Accept-Language]
+#       Scope: public       # user (default), tenant or public
+#   MaxEntries: 10000       # Memory store capacity (LRU)
+#   MaxBodySize: 1048576
+#   Store: memory           # memory (single instance) or redis (cluster)
+#   # Redis:
+#   #   Host: localhost:6379
 
 # ==================== Application configuration (go-base extension) ====================
 # Application configuration
@@ -109,7 +297,7 @@ Upstreams:
         RpcPath: ping.Ping/Ping
         # JWT config (optional; if global JWT is enabled, this documents how to skip per route)
         # Note: go-zero RouteMapping does not support custom fields; this is documentation only.
-        # Actual control is via the global Auth.SkipPaths config.
+        # Actual control is via the global Auth.SkipPaths / Auth.Rules config.
   
   # HTTP-to-HTTP Gateway example
   # - Name: userapi
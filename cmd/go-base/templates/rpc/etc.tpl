@@ -38,6 +38,53 @@ ListenOn: 0.0.0.0:50001
 #   # Addrs:
 #   #   - localhost:26379
 
+# Token revocation denylist (optional; checks x-jwt-* identities forwarded by the gateway)
+# Use the same store as the gateway (redis) so revocations take effect at every hop.
+# Revocation:
+#   Enabled: true
+#   Store: redis  # memory or redis
+#   Redis:
+#     Host: localhost:6379
+#   FailClosed: false  # Reject calls while the store is unavailable (default: let them through)
+
+# Verify the gateway-signed x-jwt-* identity (optional; must match the gateway Auth.IdentitySign)
+# IdentitySign:
+#   Enabled: true
+#   Algorithm: hmac  # hmac or ed25519 (use PublicKey for ed25519)
+#   Secret: change-me
+#   MaxAge: 5m
+
+# Role/scope authorization policy file (optional, see README)
+# AuthPolicy: etc/policy.yaml
+
+# Structured access log: method, gRPC code, business code, latency, user, request id (optional)
+# AccessLog:
+#   Enabled: true
+#   SkipPaths: [/grpc.health.v1.Health/*]
+#   BodySampleRate: 0.01    # Fraction of calls logged with (redacted) request/response messages
+#   MaskFields: [phone, mobile]
+
+# Audit trail of the calls matching the rules: actor, action, resource ids, outcome, client IP (optional)
+# Audit:
+#   Enabled: true
+#   Rules:
+#     - Rpc: /order.OrderService/Cancel*
+#       Action: order.cancel
+#       Resource: order
+#       IDFields: [id]      # Request fields recorded as resource ids
+#   Sinks:
+#     - Type: file
+#       Path: logs/audit.log
+
+# Per-tenant rate limit of the calls carrying a tenant forwarded by the gateway (optional)
+# TenantRateLimit:
+#   Enabled: true
+#   Rate: 100               # Calls per second per tenant
+#   Burst: 200
+
+# Requests are validated by the rules declared in the proto (protoc-gen-validate); set to disable
+# SkipValidation: false
+
 # Enable strict control (optional; default false)
 # StrictControl: false
 
@@ -140,6 +187,13 @@ App:
 #   PoolSize: 10
 #   MinIdleConns: 5
 
+# Downstream gRPC client example (bootstrap.RpcClientConfig, created with bootstrap.MustNewRpcClient)
+# The caller identity (x-jwt-*) is propagated automatically.
+# UserRpc:
+#   Target: localhost:50002
+#   Propagate:  # Extra incoming metadata keys to propagate (optional)
+#     - x-request-id
+
 # Other business configuration...
 # Custom:
 #   Key: value
@@ -0,0 +1,183 @@
+// Package accesslog provides structured access logs with sampled body capture and redaction,
+// used by middleware.AccessLog (HTTP, gateway) and the interceptor.AccessLog interceptors (gRPC).
+package accesslog
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/timex"
+	"google.golang.org/grpc/codes"
+
+	"github.com/addls/go-base/pkg/pathmatch"
+)
+
+// RequestIdHeader is the request id header (or metadata key) logged as request_id.
+const RequestIdHeader = "X-Request-Id"
+
+// Default redaction lists, used when the corresponding Conf list is empty.
+var (
+	DefaultRedactHeaders = []string{
+		"Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-Csrf-Token", "X-Signature", "X-Admin-Key",
+	}
+	DefaultRedactFields = []string{
+		"password", "oldPassword", "newPassword", "secret", "clientSecret", "token", "accessToken",
+		"refreshToken", "idToken", "apiKey", "captcha",
+	}
+	DefaultMaskFields = []string{"phone", "mobile", "email", "idCard"}
+)
+
+// Conf access log configuration.
+type Conf struct {
+	Enabled        bool     `json:",optional"`
+	SkipPaths      []string `json:",optional"`                // Path patterns (or gRPC methods) not logged, e.g. /health
+	Headers        []string `json:",optional"`                // Request headers (gRPC metadata keys) to log
+	RedactHeaders  []string `json:",optional"`                // Headers logged as "***" (default DefaultRedactHeaders)
+	RedactFields   []string `json:",optional"`                // JSON fields logged as "***", case-insensitive (default DefaultRedactFields)
+	MaskFields     []string `json:",optional"`                // JSON fields partially masked, e.g. 138****5678 (default DefaultMaskFields)
+	BodySampleRate float64  `json:",default=0,range=[0:1]"`   // Fraction of requests logged with bodies (0 disables body capture)
+	MaxBodySize    int      `json:",default=4096,range=[0:]"` // Maximum logged body size in bytes (longer bodies are truncated)
+	SlowThreshold  int64    `json:",default=1000,range=[0:]"` // Requests slower than this (ms) are logged as slow
+}
+
+// Logger writes access log entries.
+type Logger struct {
+	conf          Conf
+	skip          []pathmatch.Pattern
+	headers       []string
+	redactHeaders map[string]bool
+	redactFields  map[string]bool
+	maskFields    map[string]bool
+}
+
+// New creates a Logger from config.
+func New(c Conf) *Logger {
+	l := &Logger{
+		conf:          c,
+		redactHeaders: lowerSet(c.RedactHeaders, DefaultRedactHeaders),
+		redactFields:  lowerSet(c.RedactFields, DefaultRedactFields),
+		maskFields:    lowerSet(c.MaskFields, DefaultMaskFields),
+	}
+	for _, p := range c.SkipPaths {
+		l.skip = append(l.skip, pathmatch.Compile(p))
+	}
+	for _, h := range c.Headers {
+		l.headers = append(l.headers, strings.ToLower(h))
+	}
+	return l
+}
+
+func lowerSet(values, defaults []string) map[string]bool {
+	if len(values) == 0 {
+		values = defaults
+	}
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[strings.ToLower(v)] = true
+	}
+	return set
+}
+
+// Skip reports whether the path (or gRPC full method) is not logged.
+func (l *Logger) Skip(path string) bool {
+	for _, p := range l.skip {
+		if p.Match(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// SampleBody reports whether the bodies of this request are logged.
+func (l *Logger) SampleBody() bool {
+	rate := l.conf.BodySampleRate
+	return rate > 0 && (rate >= 1 || rand.Float64() < rate)
+}
+
+// Headers returns the configured headers (lowercase) to log.
+func (l *Logger) Headers() []string {
+	return l.headers
+}
+
+// Entry is an access log entry.
+type Entry struct {
+	Protocol  string // ProtocolHTTP or ProtocolGRPC
+	Method    string // HTTP method (empty for gRPC)
+	Route     string // Route pattern or gRPC full method
+	Path      string // Request path
+	Status    int    // HTTP status or gRPC code
+	Code      *int   // Business code, if known
+	Duration  time.Duration
+	UserID    string
+	RequestID string
+	ClientIP  string
+	Headers   map[string]string
+	ReqBody   string
+	RespBody  string
+	Err       error
+}
+
+// Protocols logged in Entry.Protocol.
+const (
+	ProtocolHTTP = "http"
+	ProtocolGRPC = "grpc"
+)
+
+// Log writes the entry: server errors (HTTP 5xx, gRPC Unknown, DeadlineExceeded, Internal, Unavailable
+// or DataLoss) at error level, slow requests at slow level, others at info level.
+func (l *Logger) Log(ctx context.Context, e Entry) {
+	fields := []logx.LogField{
+		logx.Field("protocol", e.Protocol),
+		logx.Field("route", e.Route),
+		logx.Field("status", e.Status),
+		logx.Field("duration", timex.ReprOfDuration(e.Duration)),
+	}
+	add := func(key string, v string) {
+		if v != "" {
+			fields = append(fields, logx.Field(key, v))
+		}
+	}
+	add("method", e.Method)
+	if e.Path != e.Route {
+		add("path", e.Path)
+	}
+	if e.Code != nil {
+		fields = append(fields, logx.Field("code", *e.Code))
+	}
+	add("user", e.UserID)
+	add("request_id", e.RequestID)
+	add("ip", e.ClientIP)
+	if len(e.Headers) > 0 {
+		fields = append(fields, logx.Field("headers", e.Headers))
+	}
+	add("req_body", e.ReqBody)
+	add("resp_body", e.RespBody)
+	if e.Err != nil {
+		fields = append(fields, logx.Field("error", e.Err.Error()))
+	}
+
+	logger := logx.WithContext(ctx)
+	switch {
+	case e.serverError():
+		logger.Errorw("access", fields...)
+	case l.conf.SlowThreshold > 0 && e.Duration > time.Duration(l.conf.SlowThreshold)*time.Millisecond:
+		logger.Sloww("access", fields...)
+	default:
+		logger.Infow("access", fields...)
+	}
+}
+
+func (e Entry) serverError() bool {
+	if e.Protocol == ProtocolGRPC {
+		switch codes.Code(e.Status) {
+		case codes.Unknown, codes.DeadlineExceeded, codes.Internal, codes.Unavailable, codes.DataLoss:
+			return true
+		}
+		return false
+	}
+	return e.Status >= http.StatusInternalServerError
+}
@@ -0,0 +1,96 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const redacted = "***"
+
+// RedactHeader returns the header value to log ("***" for redacted headers).
+func (l *Logger) RedactHeader(name, value string) string {
+	if l.redactHeaders[strings.ToLower(name)] {
+		return redacted
+	}
+	return value
+}
+
+// RedactBody returns the body to log: JSON bodies with redacted and masked fields, truncated to
+// MaxBodySize. Non-JSON bodies are not logged, only their size, as they cannot be redacted.
+func (l *Logger) RedactBody(body []byte) string {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return ""
+	}
+
+	var v interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return fmt.Sprintf("[%d bytes]", len(body))
+	}
+	out, err := json.Marshal(l.redact(v))
+	if err != nil {
+		return fmt.Sprintf("[%d bytes]", len(body))
+	}
+	return truncate(string(out), l.conf.MaxBodySize)
+}
+
+func (l *Logger) redact(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, fv := range t {
+			key := strings.ToLower(k)
+			switch {
+			case l.redactFields[key]:
+				t[k] = redacted
+			case l.maskFields[key]:
+				t[k] = mask(fv)
+			default:
+				t[k] = l.redact(fv)
+			}
+		}
+	case []interface{}:
+		for i, item := range t {
+			t[i] = l.redact(item)
+		}
+	}
+	return v
+}
+
+// mask keeps the first 3 and last 4 characters of long values (e.g. 138****5678)
+// and the first character of short ones.
+func mask(v interface{}) interface{} {
+	s, ok := v.(string)
+	if !ok {
+		if n, ok := v.(json.Number); ok {
+			s = n.String()
+		} else {
+			return redacted
+		}
+	}
+	runes := []rune(s)
+	switch {
+	case len(runes) == 0:
+		return s
+	case len(runes) > 7:
+		return string(runes[:3]) + "****" + string(runes[len(runes)-4:])
+	default:
+		return string(runes[:1]) + redacted
+	}
+}
+
+// truncate cuts s to at most max bytes (on a rune boundary), marking the cut.
+func truncate(s string, max int) string {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "...(truncated)"
+}
@@ -0,0 +1,330 @@
+// Package audit records an audit trail of state-changing operations: who (actor) did what (action)
+// to which resource, with which outcome. Events are emitted by middleware.Audit (HTTP, gateway),
+// the interceptor.Audit interceptors (gRPC) and audit.Record (logic), buffered and written
+// asynchronously to the configured sinks.
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/metric"
+
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/tenant"
+)
+
+// Backpressure policies applied when the buffer is full.
+const (
+	OnFullBlock = "block" // Wait up to BlockTimeout for room, then drop
+	OnFullDrop  = "drop"  // Drop immediately
+)
+
+var metricEvents = metric.NewCounterVec(&metric.CounterVecOpts{
+	Namespace: "gobase",
+	Subsystem: "audit",
+	Name:      "events_total",
+	Help:      "Audit events by result.",
+	Labels:    []string{"result"}, // written, dropped, failed
+})
+
+// Conf audit configuration.
+type Conf struct {
+	Enabled       bool          `json:",optional"`
+	Rules         []Rule        `json:",optional"`                         // Audited operations; empty audits every POST, PUT, PATCH and DELETE (HTTP only)
+	Sinks         []SinkConf    `json:",optional"`                         // Event sinks; empty writes to the log
+	BufferSize    int           `json:",default=1024,range=[1:]"`          // Events buffered before backpressure applies
+	BatchSize     int           `json:",default=100,range=[1:]"`           // Maximum events written per batch
+	FlushInterval time.Duration `json:",default=1s"`                       // Maximum delay before buffered events are written
+	OnFull        string        `json:",default=block,options=block|drop"` // Backpressure policy when the buffer is full
+	BlockTimeout  time.Duration `json:",default=100ms"`                    // Maximum wait for room with OnFull block
+}
+
+// Actor is the caller that performed the operation.
+type Actor struct {
+	UserID   string   `json:"userId,omitempty"`
+	UserName string   `json:"userName,omitempty"`
+	AuthType string   `json:"authType,omitempty"` // auth.AuthTypeJwt, AuthTypeAPIKey or AuthTypeSession
+	KeyID    string   `json:"keyId,omitempty"`    // API key id
+	Roles    []string `json:"roles,omitempty"`
+	Tenant   string   `json:"tenant,omitempty"`
+}
+
+// Resource is the object the operation applies to.
+type Resource struct {
+	Type string            `json:"type,omitempty"` // e.g. order
+	IDs  map[string]string `json:"ids,omitempty"`  // e.g. {"id": "42"} from route parameters or request fields
+}
+
+// Outcome is the result of the operation.
+type Outcome struct {
+	Success bool   `json:"success"`
+	Code    int    `json:"code"`             // Business code (0 on success)
+	Status  int    `json:"status,omitempty"` // HTTP status or gRPC code
+	Message string `json:"message,omitempty"`
+}
+
+// Change is the old and new value of a changed field (see Diff).
+type Change struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// Event is an audit event.
+type Event struct {
+	ID        string                 `json:"id"`
+	Time      time.Time              `json:"time"`
+	Actor     Actor                  `json:"actor"`
+	Action    string                 `json:"action"` // e.g. order.cancel, or "POST /orders/:id/cancel"
+	Resource  Resource               `json:"resource"`
+	Outcome   Outcome                `json:"outcome"`
+	ClientIP  string                 `json:"clientIp,omitempty"`
+	RequestID string                 `json:"requestId,omitempty"`
+	Source    string                 `json:"source,omitempty"`  // http, grpc or logic
+	Changes   map[string]Change      `json:"changes,omitempty"` // Field changes (see Diff)
+	Extra     map[string]interface{} `json:"extra,omitempty"`
+}
+
+// Auditor buffers events and writes them to the sinks asynchronously.
+type Auditor struct {
+	conf   Conf
+	rules  []*compiledRule
+	sinks  []Sink
+	events chan Event
+	done   chan struct{}
+	mu     sync.RWMutex // Guards closed: Emit holds it for reading while sending
+	closed bool
+}
+
+// NewAuditor creates an Auditor writing to the given sinks and starts its writer.
+func NewAuditor(c Conf, sinks ...Sink) *Auditor {
+	if c.BufferSize <= 0 {
+		c.BufferSize = 1024
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 100
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = time.Second
+	}
+
+	a := &Auditor{
+		conf:   c,
+		rules:  compileRules(c.Rules),
+		sinks:  sinks,
+		events: make(chan Event, c.BufferSize),
+		done:   make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+// MustNewAuditor creates an Auditor with the sinks from config, panics on error.
+func MustNewAuditor(c Conf) *Auditor {
+	sinks, err := NewSinks(c.Sinks)
+	logx.Must(err)
+	return NewAuditor(c, sinks...)
+}
+
+// Emit queues an event, filling in its id and time if empty. When the buffer is full the event
+// waits for room (OnFull block, up to BlockTimeout) or is dropped.
+func (a *Auditor) Emit(e Event) {
+	if e.ID == "" {
+		e.ID = newEventID()
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		metricEvents.Inc("dropped")
+		logx.Errorf("auditor is closed, event dropped: %s %s", e.Action, e.Actor.UserID)
+		return
+	}
+
+	select {
+	case a.events <- e:
+		return
+	default:
+	}
+
+	if a.conf.OnFull == OnFullBlock && a.conf.BlockTimeout > 0 {
+		timer := time.NewTimer(a.conf.BlockTimeout)
+		defer timer.Stop()
+		select {
+		case a.events <- e:
+			return
+		case <-timer.C:
+		}
+	}
+	metricEvents.Inc("dropped")
+	logx.Errorf("audit buffer is full, event dropped: %s %s", e.Action, e.Actor.UserID)
+}
+
+// Close writes the buffered events and closes the sinks. Events emitted after Close are dropped.
+func (a *Auditor) Close() {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return
+	}
+	a.closed = true
+	close(a.events)
+	a.mu.Unlock()
+
+	<-a.done
+	for _, s := range a.sinks {
+		if err := s.Close(); err != nil {
+			logx.Errorf("close audit sink failed: %v", err)
+		}
+	}
+}
+
+func (a *Auditor) run() {
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.conf.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, a.conf.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		a.write(batch)
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case e, ok := <-a.events:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, e)
+			if len(batch) >= a.conf.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (a *Auditor) write(batch []Event) {
+	for _, s := range a.sinks {
+		if err := s.Write(context.Background(), batch); err != nil {
+			metricEvents.Add(float64(len(batch)), "failed")
+			logx.Errorf("write %d audit events failed: %v", len(batch), err)
+			continue
+		}
+		metricEvents.Add(float64(len(batch)), "written")
+	}
+}
+
+// ActorFromContext returns the caller identity from context (see auth.GetClaims).
+func ActorFromContext(ctx context.Context) Actor {
+	return Actor{
+		UserID:   auth.GetUserID(ctx),
+		UserName: auth.GetUserName(ctx),
+		AuthType: auth.GetAuthType(ctx),
+		KeyID:    auth.GetAPIKeyID(ctx),
+		Roles:    auth.GetRoles(ctx),
+		Tenant:   tenant.FromContext(ctx),
+	}
+}
+
+func newEventID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+var (
+	defaultMu      sync.RWMutex
+	defaultAuditor *Auditor
+)
+
+// SetAuditor sets the default Auditor used by Record (bootstrap sets it when Audit is enabled).
+func SetAuditor(a *Auditor) {
+	defaultMu.Lock()
+	defaultAuditor = a
+	defaultMu.Unlock()
+}
+
+// GetAuditor returns the default Auditor (nil if not set).
+func GetAuditor() *Auditor {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultAuditor
+}
+
+// Record emits a logic-level event with the default Auditor (a no-op if auditing is not enabled).
+// The actor, client IP and request id are filled from context when empty, and the outcome defaults
+// to success.
+//
+//	audit.Record(ctx, audit.Event{
+//		Action:   "order.cancel",
+//		Resource: audit.Resource{Type: "order", IDs: map[string]string{"id": id}},
+//		Changes:  audit.Diff(before, after),
+//	})
+func Record(ctx context.Context, e Event) {
+	a := GetAuditor()
+	if a == nil {
+		return
+	}
+	if e.Actor.UserID == "" {
+		e.Actor = ActorFromContext(ctx)
+	}
+	if info, ok := RequestInfoFromContext(ctx); ok {
+		if e.ClientIP == "" {
+			e.ClientIP = info.ClientIP
+		}
+		if e.RequestID == "" {
+			e.RequestID = info.RequestID
+		}
+	}
+	if e.Source == "" {
+		e.Source = SourceLogic
+	}
+	if e.Outcome == (Outcome{}) {
+		e.Outcome.Success = true
+	}
+	a.Emit(e)
+}
+
+// Event sources.
+const (
+	SourceHTTP  = "http"
+	SourceGRPC  = "grpc"
+	SourceLogic = "logic"
+)
+
+// RequestInfo is the request metadata made available to Record by the audit middleware and interceptors.
+type RequestInfo struct {
+	ClientIP  string
+	RequestID string
+}
+
+type requestInfoKey struct{}
+
+// WithRequestInfo returns a copy of ctx carrying the request metadata.
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// RequestInfoFromContext returns the request metadata set by WithRequestInfo.
+func RequestInfoFromContext(ctx context.Context) (RequestInfo, bool) {
+	info, ok := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info, ok
+}
@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Diff returns the fields that differ between before and after, keyed by their JSON path
+// (nested objects use dotted paths, e.g. address.city; arrays are compared as a whole).
+// Either side may be nil (creation or deletion). Values are compared by their JSON encoding,
+// so fields tagged json:"-" (e.g. password hashes) are never recorded.
+func Diff(before, after interface{}) map[string]Change {
+	changes := make(map[string]Change)
+	diffValues("", toJSONValue(before), toJSONValue(after), changes)
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+func toJSONValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var out interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil
+	}
+	return out
+}
+
+func diffValues(prefix string, before, after interface{}, changes map[string]Change) {
+	bm, bok := before.(map[string]interface{})
+	am, aok := after.(map[string]interface{})
+	if (bok || before == nil) && (aok || after == nil) && (bok || aok) {
+		for k, bv := range bm {
+			diffValues(joinPath(prefix, k), bv, am[k], changes)
+		}
+		for k, av := range am {
+			if _, ok := bm[k]; !ok {
+				diffValues(joinPath(prefix, k), nil, av, changes)
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		changes[prefix] = Change{Old: before, New: after}
+	}
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/addls/go-base/pkg/pathmatch"
+)
+
+// DefaultMethods are the HTTP methods audited when a rule (or the whole config) lists none.
+var DefaultMethods = []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// Rule selects the audited operations and describes them.
+type Rule struct {
+	Path     string   `json:",optional"` // HTTP path pattern (see pathmatch.Pattern), e.g. /api/v1/orders/**
+	Methods  []string `json:",optional"` // HTTP methods; empty means DefaultMethods
+	Rpc      string   `json:",optional"` // gRPC full method pattern, e.g. /order.OrderService/Cancel*
+	Action   string   `json:",optional"` // Event action, e.g. order.cancel (default "POST /orders/:id" or the gRPC full method)
+	Resource string   `json:",optional"` // Resource type, e.g. order
+	IDFields []string `json:",optional"` // gRPC request fields recorded as resource ids (HTTP records the route parameters)
+}
+
+type compiledRule struct {
+	Rule
+	path    *pathmatch.Pattern
+	rpc     *pathmatch.Pattern
+	methods map[string]bool
+}
+
+func compileRules(rules []Rule) []*compiledRule {
+	if len(rules) == 0 {
+		// Without rules every state-changing HTTP request is audited.
+		rules = []Rule{{Path: "/**"}}
+	}
+
+	compiled := make([]*compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		cr := &compiledRule{Rule: rule}
+		if rule.Path != "" {
+			pattern := pathmatch.Compile(rule.Path)
+			cr.path = &pattern
+		}
+		if rule.Rpc != "" {
+			pattern := pathmatch.Compile(rule.Rpc)
+			cr.rpc = &pattern
+		}
+		methods := rule.Methods
+		if len(methods) == 0 {
+			methods = DefaultMethods
+		}
+		cr.methods = make(map[string]bool, len(methods))
+		for _, m := range methods {
+			cr.methods[strings.ToUpper(m)] = true
+		}
+		compiled = append(compiled, cr)
+	}
+	return compiled
+}
+
+// MatchHTTP returns the first rule auditing the HTTP request, if any.
+func (a *Auditor) MatchHTTP(method, path string) (Rule, bool) {
+	for _, r := range a.rules {
+		if r.path != nil && r.methods[method] && r.path.Match(path) {
+			return r.Rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// MatchRPC returns the first rule auditing the gRPC call (/package.Service/Method), if any.
+func (a *Auditor) MatchRPC(fullMethod string) (Rule, bool) {
+	for _, r := range a.rules {
+		if r.rpc != nil && r.rpc.Match(fullMethod) {
+			return r.Rule, true
+		}
+	}
+	return Rule{}, false
+}
@@ -0,0 +1,213 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// Sink types.
+const (
+	SinkLog     = "log"
+	SinkFile    = "file"
+	SinkWebhook = "webhook"
+)
+
+// WebhookSignatureHeader carries the hex HMAC-SHA256 of the webhook body when a Secret is configured.
+const WebhookSignatureHeader = "X-Audit-Signature"
+
+// SinkConf configures an event sink.
+type SinkConf struct {
+	Type    string            `json:",default=log,options=log|file|webhook"`
+	Path    string            `json:",optional"`             // file: JSON lines file events are appended to
+	URL     string            `json:",optional"`             // webhook: endpoint receiving each batch as a JSON array
+	Headers map[string]string `json:",optional"`             // webhook: extra request headers, e.g. Authorization
+	Secret  string            `json:",optional"`             // webhook: signs the body in WebhookSignatureHeader
+	Timeout time.Duration     `json:",default=5s"`           // webhook: request timeout
+	Retries int               `json:",default=2,range=[0:]"` // webhook: retries of a failed batch
+}
+
+// Sink writes batches of audit events.
+type Sink interface {
+	Write(ctx context.Context, events []Event) error
+	Close() error
+}
+
+// NewSinks creates the configured sinks (a LogSink when none is configured).
+func NewSinks(confs []SinkConf) ([]Sink, error) {
+	if len(confs) == 0 {
+		return []Sink{NewLogSink()}, nil
+	}
+
+	sinks := make([]Sink, 0, len(confs))
+	for _, c := range confs {
+		var (
+			s   Sink
+			err error
+		)
+		switch c.Type {
+		case SinkLog, "":
+			s = NewLogSink()
+		case SinkFile:
+			s, err = NewFileSink(c.Path)
+		case SinkWebhook:
+			s, err = NewWebhookSink(c)
+		default:
+			err = fmt.Errorf("audit: unknown sink type %s", c.Type)
+		}
+		if err != nil {
+			for _, created := range sinks {
+				created.Close()
+			}
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+	return sinks, nil
+}
+
+// LogSink writes events to the log.
+type LogSink struct{}
+
+// NewLogSink creates a LogSink.
+func NewLogSink() *LogSink {
+	return &LogSink{}
+}
+
+// Write logs each event.
+func (s *LogSink) Write(ctx context.Context, events []Event) error {
+	for _, e := range events {
+		logx.WithContext(ctx).Infow("audit", logx.Field("event", e))
+	}
+	return nil
+}
+
+// Close is a no-op.
+func (s *LogSink) Close() error {
+	return nil
+}
+
+// FileSink appends events to a file as JSON lines.
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink opens (or creates) the file events are appended to.
+func NewFileSink(path string) (*FileSink, error) {
+	if path == "" {
+		return nil, errors.New("audit: file sink requires Path")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{file: f}, nil
+}
+
+// Write appends the events and syncs the file.
+func (s *FileSink) Write(_ context.Context, events []Event) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, e := range events {
+		if err := encoder.Encode(e); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// WebhookSink posts each batch of events as a JSON array.
+type WebhookSink struct {
+	conf   SinkConf
+	client *http.Client
+}
+
+// NewWebhookSink creates a WebhookSink.
+func NewWebhookSink(c SinkConf) (*WebhookSink, error) {
+	if c.URL == "" {
+		return nil, errors.New("audit: webhook sink requires URL")
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 5 * time.Second
+	}
+	return &WebhookSink{
+		conf:   c,
+		client: &http.Client{Timeout: c.Timeout},
+	}, nil
+}
+
+// Write posts the events, retrying failed requests with a linear backoff.
+func (s *WebhookSink) Write(ctx context.Context, events []Event) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = s.post(ctx, body)
+		if err == nil || attempt >= s.conf.Retries {
+			return err
+		}
+		time.Sleep(time.Duration(attempt+1) * 200 * time.Millisecond)
+	}
+}
+
+func (s *WebhookSink) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.conf.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.conf.Headers {
+		req.Header.Set(k, v)
+	}
+	if s.conf.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(s.conf.Secret))
+		mac.Write(body)
+		req.Header.Set(WebhookSignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("audit: webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close is a no-op.
+func (s *WebhookSink) Close() error {
+	return nil
+}
@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/metric"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+
+	"github.com/addls/go-base/pkg/errcode"
+)
+
+// JwtKeyIdHeader id of the API key the caller authenticated with, forwarded next to the owner identity.
+const JwtKeyIdHeader = "x-jwt-key-id"
+
+// APIKeyPrefix prefixes generated API keys so leaked keys are easy to recognize.
+const APIKeyPrefix = "gbk_"
+
+// APIKeyStoreConfig keeps the keys in the configuration file.
+const APIKeyStoreConfig = "config"
+
+var metricAPIKeyRequests = metric.NewCounterVec(&metric.CounterVecOpts{
+	Namespace: "gobase",
+	Subsystem: "auth",
+	Name:      "apikey_requests_total",
+	Help:      "API key authentications by key id and result.",
+	Labels:    []string{"key", "result"},
+})
+
+// APIKeyConf API key authentication configuration.
+type APIKeyConf struct {
+	Enabled   bool            `json:",optional"`
+	Header    string          `json:",default=X-Api-Key"`                   // Request header carrying the key
+	Store     string          `json:",default=config,options=config|redis"` // config (Keys below) or redis
+	Keys      []APIKeyEntry   `json:",optional"`                            // Hashed keys when Store is config
+	Redis     redis.RedisConf `json:",optional"`                            // Required when Store is redis
+	KeyPrefix string          `json:",default=gobase:apikey:"`              // Redis key prefix
+}
+
+// APIKeyEntry is a stored API key. Only the SHA-256 hash of the key is stored (see HashAPIKey).
+type APIKeyEntry struct {
+	ID        string   // Key id (used in logs and metrics)
+	Hash      string   // Hex SHA-256 of the key
+	Owner     string   // Owner identity, forwarded as the user id
+	Name      string   `json:",optional"` // Owner display name, forwarded as the user name
+	Roles     []string `json:",optional"` // Roles granted to the key
+	Scopes    []string `json:",optional"` // Scopes granted to the key
+	Tenant    string   `json:",optional"` // Tenant the key belongs to, forwarded like a token tenant claim
+	ExpiresAt string   `json:",optional"` // RFC 3339 expiry; empty means no expiry
+}
+
+// APIKey is an authenticated API key.
+type APIKey struct {
+	ID        string
+	Owner     string
+	Name      string
+	Roles     []string
+	Scopes    []string
+	Tenant    string
+	ExpiresAt time.Time // Zero means no expiry
+}
+
+// APIKeyStore looks up API keys by hash. Implement it to keep keys in a database.
+type APIKeyStore interface {
+	// Lookup returns the key with the given hash, or nil if there is none.
+	Lookup(ctx context.Context, hash string) (*APIKey, error)
+}
+
+// GenerateAPIKey generates a new random API key and returns it with its hash.
+// Only the hash should be stored; the key is shown to its owner once.
+func GenerateAPIKey() (key, hash string) {
+	key = APIKeyPrefix + randomToken()
+	return key, HashAPIKey(key)
+}
+
+// HashAPIKey returns the hex SHA-256 hash of an API key.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyAuthenticator authenticates API keys against a store and reports per-key usage metrics.
+type APIKeyAuthenticator struct {
+	header string
+	store  APIKeyStore
+}
+
+// NewAPIKeyAuthenticator creates an APIKeyAuthenticator reading keys from the given header.
+func NewAPIKeyAuthenticator(header string, store APIKeyStore) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{
+		header: header,
+		store:  store,
+	}
+}
+
+// MustNewAPIKeyAuthenticator creates an APIKeyAuthenticator with the store from config, panics on error.
+func MustNewAPIKeyAuthenticator(c APIKeyConf) *APIKeyAuthenticator {
+	return NewAPIKeyAuthenticator(c.Header, MustNewAPIKeyStore(c))
+}
+
+// Header returns the request header carrying the key.
+func (a *APIKeyAuthenticator) Header() string {
+	return a.header
+}
+
+// Authenticate verifies an API key.
+// It returns errcode.ErrAPIKeyInvalid for unknown keys and errcode.ErrAPIKeyExpired for expired keys.
+func (a *APIKeyAuthenticator) Authenticate(ctx context.Context, key string) (*APIKey, error) {
+	k, err := a.store.Lookup(ctx, HashAPIKey(key))
+	if err != nil {
+		metricAPIKeyRequests.Inc("unknown", "error")
+		return nil, err
+	}
+	if k == nil {
+		metricAPIKeyRequests.Inc("unknown", "invalid")
+		return nil, errcode.ErrAPIKeyInvalid
+	}
+	if !k.ExpiresAt.IsZero() && time.Now().After(k.ExpiresAt) {
+		metricAPIKeyRequests.Inc(k.ID, "expired")
+		return nil, errcode.ErrAPIKeyExpired
+	}
+	metricAPIKeyRequests.Inc(k.ID, "ok")
+	return k, nil
+}
+
+// MustNewAPIKeyStore creates an API key store from config, panics on error.
+func MustNewAPIKeyStore(c APIKeyConf) APIKeyStore {
+	if c.Store == StoreRedis {
+		return NewRedisAPIKeyStore(redis.MustNewRedis(c.Redis), c.KeyPrefix)
+	}
+	store, err := NewConfigAPIKeyStore(c.Keys)
+	logx.Must(err)
+	return store
+}
+
+func (e APIKeyEntry) toKey() (*APIKey, error) {
+	k := &APIKey{
+		ID:     e.ID,
+		Owner:  e.Owner,
+		Name:   e.Name,
+		Roles:  e.Roles,
+		Scopes: e.Scopes,
+		Tenant: e.Tenant,
+	}
+	if e.ExpiresAt != "" {
+		t, err := time.Parse(time.RFC3339, e.ExpiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("auth: api key %s: invalid ExpiresAt: %w", e.ID, err)
+		}
+		k.ExpiresAt = t
+	}
+	return k, nil
+}
+
+// ----- Config store -----
+
+// ConfigAPIKeyStore is an immutable API key store built from configuration.
+type ConfigAPIKeyStore struct {
+	keys map[string]*APIKey
+}
+
+// NewConfigAPIKeyStore creates an API key store from configured entries.
+func NewConfigAPIKeyStore(entries []APIKeyEntry) (*ConfigAPIKeyStore, error) {
+	s := &ConfigAPIKeyStore{keys: make(map[string]*APIKey, len(entries))}
+	for _, e := range entries {
+		if e.ID == "" || e.Hash == "" || e.Owner == "" {
+			return nil, fmt.Errorf("auth: api key entries require id, hash and owner")
+		}
+		k, err := e.toKey()
+		if err != nil {
+			return nil, err
+		}
+		s.keys[e.Hash] = k
+	}
+	return s, nil
+}
+
+// Lookup implements APIKeyStore.
+func (s *ConfigAPIKeyStore) Lookup(_ context.Context, hash string) (*APIKey, error) {
+	return s.keys[hash], nil
+}
+
+// ----- Redis store -----
+
+// RedisAPIKeyStore is a Redis-backed API key store: each key is a JSON APIKeyEntry stored at prefix+hash.
+type RedisAPIKeyStore struct {
+	rds    *redis.Redis
+	prefix string
+}
+
+// NewRedisAPIKeyStore creates a Redis-backed API key store.
+func NewRedisAPIKeyStore(rds *redis.Redis, keyPrefix string) *RedisAPIKeyStore {
+	return &RedisAPIKeyStore{
+		rds:    rds,
+		prefix: keyPrefix,
+	}
+}
+
+// Save stores an API key entry (e.g. from an admin tool).
+func (s *RedisAPIKeyStore) Save(ctx context.Context, e APIKeyEntry) error {
+	if _, err := e.toKey(); err != nil {
+		return err
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return s.rds.SetCtx(ctx, s.prefix+e.Hash, string(b))
+}
+
+// Delete removes an API key by hash, revoking it.
+func (s *RedisAPIKeyStore) Delete(ctx context.Context, hash string) error {
+	_, err := s.rds.DelCtx(ctx, s.prefix+hash)
+	return err
+}
+
+// Lookup implements APIKeyStore.
+func (s *RedisAPIKeyStore) Lookup(ctx context.Context, hash string) (*APIKey, error) {
+	v, err := s.rds.GetCtx(ctx, s.prefix+hash)
+	if err != nil || v == "" {
+		return nil, err
+	}
+	var e APIKeyEntry
+	if err := json.Unmarshal([]byte(v), &e); err != nil {
+		return nil, err
+	}
+	return e.toKey()
+}
+
+// GetAPIKeyID extracts the id of the API key the caller authenticated with (empty for JWT callers).
+func GetAPIKeyID(ctx context.Context) string {
+	return getIdentity(ctx, JwtKeyIdHeader)
+}
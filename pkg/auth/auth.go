@@ -2,6 +2,9 @@ package auth
 
 import (
 	"context"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"google.golang.org/grpc/metadata"
@@ -13,12 +16,56 @@ const (
 	JwtUserIdHeader = "x-jwt-user-id"
 	// JwtUserNameHeader HTTP header name used to pass through the JWT user name.
 	JwtUserNameHeader = "x-jwt-user-name"
+	// JwtTokenIdHeader HTTP header name used to pass through the JWT id (jti), used for revocation checks.
+	JwtTokenIdHeader = "x-jwt-token-id"
+	// JwtIssuedAtHeader HTTP header name used to pass through the JWT issued-at time (iat, unix seconds).
+	JwtIssuedAtHeader = "x-jwt-issued-at"
+	// JwtAuthTypeHeader HTTP header name used to pass through how the caller authenticated.
+	JwtAuthTypeHeader = "x-jwt-auth-type"
 )
 
+// Authentication types passed through in JwtAuthTypeHeader.
+const (
+	AuthTypeJwt     = "jwt"     // Bearer JWT
+	AuthTypeAPIKey  = "apikey"  // API key (machine clients)
+	AuthTypeSession = "session" // Session cookie (web console)
+)
+
+// claimsKey is the context key of claims set by WithClaims.
+type claimsKey struct{}
+
+// WithClaims returns a copy of ctx carrying the given claims.
+// HTTP services have no incoming gRPC metadata, so the go-base HTTP middlewares store the
+// caller identity here (keyed like the metadata: x-jwt-user-id, x-jwt-user-name, ...).
+func WithClaims(ctx context.Context, claims jwt.MapClaims) context.Context {
+	if tracked, ok := ctx.Value(trackKey{}).(*jwt.MapClaims); ok {
+		*tracked = claims
+	}
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// trackKey is the context key of the claims recorded for TrackIdentity.
+type trackKey struct{}
+
+// TrackIdentity returns a copy of ctx in which the claims later set by WithClaims (on derived contexts,
+// further down the handler chain) are recorded, and a function returning the last recorded claims.
+// Outer middlewares such as the access log use it to see the authenticated caller.
+func TrackIdentity(ctx context.Context) (context.Context, func() jwt.MapClaims) {
+	tracked := new(jwt.MapClaims)
+	return context.WithValue(ctx, trackKey{}, tracked), func() jwt.MapClaims {
+		return *tracked
+	}
+}
+
 // GetClaims extracts JWT claims from context (unified API, works for HTTP or gRPC).
 // It returns the full claims map, from which any field can be extracted.
-// Claims are built by copying all fields from incoming gRPC metadata.
+// In HTTP services claims come from the context set by WithClaims;
+// in gRPC services they are built by copying all fields from incoming gRPC metadata.
 func GetClaims(ctx context.Context) jwt.MapClaims {
+	if claims, ok := ctx.Value(claimsKey{}).(jwt.MapClaims); ok && len(claims) > 0 {
+		return claims
+	}
+
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
 		return nil
@@ -33,7 +80,7 @@ func GetClaims(ctx context.Context) jwt.MapClaims {
 			claims[key] = values[0]
 		}
 	}
-	
+
 	// If there are no claims, return nil.
 	if len(claims) == 0 {
 		return nil
@@ -44,13 +91,53 @@ func GetClaims(ctx context.Context) jwt.MapClaims {
 // GetUserID extracts UserId from context (unified API, works for HTTP or gRPC).
 // Convenience helper: returns UserId directly.
 func GetUserID(ctx context.Context) string {
-	return getFromGrpcMetadata(ctx, JwtUserIdHeader)
+	return getIdentity(ctx, JwtUserIdHeader)
 }
 
 // GetUserName extracts UserName from context (unified API, works for HTTP or gRPC).
 // Convenience helper: returns UserName directly.
 func GetUserName(ctx context.Context) string {
-	return getFromGrpcMetadata(ctx, JwtUserNameHeader)
+	return getIdentity(ctx, JwtUserNameHeader)
+}
+
+// GetTokenID extracts the JWT id (jti) from context.
+func GetTokenID(ctx context.Context) string {
+	return getIdentity(ctx, JwtTokenIdHeader)
+}
+
+// GetIssuedAt extracts the JWT issued-at time from context (zero if absent).
+func GetIssuedAt(ctx context.Context) time.Time {
+	iat, err := strconv.ParseInt(getIdentity(ctx, JwtIssuedAtHeader), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(iat, 0)
+}
+
+// GetAuthType extracts how the caller authenticated (AuthTypeJwt, AuthTypeAPIKey or AuthTypeSession) from context.
+func GetAuthType(ctx context.Context) string {
+	return getIdentity(ctx, JwtAuthTypeHeader)
+}
+
+// GetIssuerName extracts the name of the issuer that authenticated the caller from context
+// (only set when the gateway is configured with named issuers, see JwtIssuerConf).
+func GetIssuerName(ctx context.Context) string {
+	return getIdentity(ctx, JwtIssuerHeader)
+}
+
+// GetValue extracts a single identity or metadata value from context (unified API, works for HTTP or gRPC).
+func GetValue(ctx context.Context, key string) string {
+	return getIdentity(ctx, strings.ToLower(key))
+}
+
+// getIdentity reads an identity field from context claims (HTTP) or incoming gRPC metadata.
+func getIdentity(ctx context.Context, key string) string {
+	if claims, ok := ctx.Value(claimsKey{}).(jwt.MapClaims); ok {
+		if v, ok := claims[key].(string); ok && v != "" {
+			return v
+		}
+	}
+	return getFromGrpcMetadata(ctx, key)
 }
 
 func getFromGrpcMetadata(ctx context.Context, key string) string {
@@ -61,7 +148,7 @@ func getFromGrpcMetadata(ctx context.Context, key string) string {
 
 	// Compatibility: some hops add the "gateway-" prefix.
 	keys := []string{
-		key,            // x-jwt-...
+		key,              // x-jwt-...
 		"gateway-" + key, // gateway-x-jwt-...
 	}
 	for _, k := range keys {
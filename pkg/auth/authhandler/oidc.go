@@ -0,0 +1,140 @@
+package authhandler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/rest"
+
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/errcode"
+	"github.com/addls/go-base/pkg/oidc"
+	"github.com/addls/go-base/pkg/response"
+)
+
+// OIDCLoginResp is returned by the OIDC callback when the login has no redirect.
+type OIDCLoginResp struct {
+	AccessToken  string `json:"accessToken"`
+	AccessExpire int64  `json:"accessExpire"` // Unix seconds
+}
+
+// OIDCRoutes returns the OIDC login and callback routes (GET LoginPath and CallbackPath).
+// Mount them without JWT verification.
+func OIDCRoutes(c oidc.Conf, rp *oidc.RelyingParty, issuer *auth.Issuer) []rest.Route {
+	return []rest.Route{
+		{Method: http.MethodGet, Path: c.LoginPath, Handler: OIDCLoginHandler(rp)},
+		{Method: http.MethodGet, Path: c.CallbackPath, Handler: OIDCCallbackHandler(rp, issuer)},
+	}
+}
+
+// OIDCLoginHandler redirects to the provider's authorization endpoint, binding the login to the
+// browser with a short-lived HttpOnly state cookie checked by the callback (prevents login CSRF).
+// An optional ?redirect=/path (same-site relative path) is where the callback sends the user afterwards.
+func OIDCLoginHandler(rp *oidc.RelyingParty) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		redirect := r.URL.Query().Get("redirect")
+		if redirect != "" && !isLocalRedirect(redirect) {
+			response.Error(w, errcode.ErrInvalidParam.WithMsg("redirect must be a relative path"))
+			return
+		}
+
+		binding := oidc.NewBinding()
+		u, err := rp.AuthCodeURL(r.Context(), redirect, binding)
+		if err != nil {
+			logx.WithContext(r.Context()).Errorf("OIDC login failed: %v", err)
+			response.Error(w, errcode.ErrServiceUnavailable)
+			return
+		}
+		http.SetCookie(w, stateCookie(rp.Conf(), binding))
+		http.Redirect(w, r, u, http.StatusFound)
+	}
+}
+
+// OIDCCallbackHandler handles the provider callback: it validates the ID token and issues a
+// go-base access token for the identity. Without a login redirect it responds with OIDCLoginResp;
+// otherwise it redirects with the token in the URL fragment (#access_token=...&expires_in=...).
+func OIDCCallbackHandler(rp *oidc.RelyingParty, issuer *auth.Issuer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if e := q.Get("error"); e != "" {
+			logx.WithContext(r.Context()).Errorf("OIDC provider returned error: %s %s", e, q.Get("error_description"))
+			response.Error(w, errcode.ErrOIDCLoginFailed)
+			return
+		}
+		state, code := q.Get("state"), q.Get("code")
+		if state == "" || code == "" {
+			response.Error(w, errcode.ErrOIDCStateInvalid)
+			return
+		}
+
+		var binding string
+		if c, err := r.Cookie(rp.Conf().StateCookie); err == nil {
+			binding = c.Value
+		}
+		http.SetCookie(w, stateCookie(rp.Conf(), ""))
+
+		id, _, redirect, err := rp.Exchange(r.Context(), state, code, binding)
+		if err != nil {
+			logx.WithContext(r.Context()).Errorf("OIDC callback failed: %v", err)
+			if errors.Is(err, oidc.ErrStateInvalid) {
+				response.Error(w, errcode.ErrOIDCStateInvalid)
+			} else {
+				response.Error(w, errcode.ErrOIDCLoginFailed)
+			}
+			return
+		}
+
+		token, claims, err := issuer.IssueAccessToken(id)
+		if err != nil {
+			logx.WithContext(r.Context()).Errorf("OIDC issue access token failed: %v", err)
+			response.Error(w, errcode.ErrInternal)
+			return
+		}
+		exp := claims["exp"].(int64)
+
+		if redirect == "" {
+			response.OkWithData(w, OIDCLoginResp{AccessToken: token, AccessExpire: exp})
+			return
+		}
+		fragment := url.Values{
+			"access_token": {token},
+			"token_type":   {"Bearer"},
+			"expires_in":   {strconv.FormatInt(exp-claims["iat"].(int64), 10)},
+		}
+		http.Redirect(w, r, redirect+"#"+fragment.Encode(), http.StatusFound)
+	}
+}
+
+// stateCookie returns the state cookie carrying the browser binding of a login (an expired cookie
+// if binding is empty). It is scoped to the callback path and sent on the top-level redirect back
+// from the provider (SameSite=Lax).
+func stateCookie(c oidc.Conf, binding string) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     c.StateCookie,
+		Value:    binding,
+		Path:     c.CallbackPath,
+		MaxAge:   int(c.StateTTL / time.Second),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if u, err := url.Parse(c.RedirectURL); err == nil {
+		if u.Path != "" {
+			cookie.Path = u.Path
+		}
+		cookie.Secure = u.Scheme == "https"
+	}
+	if binding == "" {
+		cookie.MaxAge = -1
+	}
+	return cookie
+}
+
+// isLocalRedirect reports whether a redirect target is a same-site relative path (no open redirects).
+func isLocalRedirect(s string) bool {
+	return strings.HasPrefix(s, "/") && !strings.HasPrefix(s, "//") && !strings.HasPrefix(s, "/\\")
+}
@@ -0,0 +1,268 @@
+package authhandler
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/errcode"
+	"github.com/addls/go-base/pkg/middleware"
+	"github.com/addls/go-base/pkg/oidc"
+)
+
+const testAccessSecret = "test-access-secret-at-least-256-bits"
+
+// mockProvider is a minimal OpenID provider: discovery, JWKS and a token endpoint that checks
+// the PKCE verifier and returns an ID token with the claims built by claims.
+type mockProvider struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu        sync.Mutex
+	nonce     string
+	challenge string
+	claims    func(p *mockProvider) jwt.MapClaims
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &mockProvider{key: key}
+	p.claims = func(p *mockProvider) jwt.MapClaims {
+		now := time.Now()
+		return jwt.MapClaims{
+			"iss":   p.URL,
+			"aud":   "client",
+			"sub":   "alice",
+			"name":  "Alice",
+			"nonce": p.nonce,
+			"iat":   now.Unix(),
+			"exp":   now.Add(time.Minute).Unix(),
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(oidc.Discovery{
+			Issuer:                p.URL,
+			AuthorizationEndpoint: p.URL + "/authorize",
+			TokenEndpoint:         p.URL + "/token",
+			JwksURI:               p.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "k1",
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		if r.PostFormValue("code") != "code" || base64.RawURLEncoding.EncodeToString(sum[:]) != p.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		tok := jwt.NewWithClaims(jwt.SigningMethodRS256, p.claims(p))
+		tok.Header["kid"] = "k1"
+		signed, err := tok.SignedString(p.key)
+		if err != nil {
+			t.Error(err)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": signed})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+// authorize records the nonce and PKCE challenge of an authorization URL, as the provider would.
+func (p *mockProvider) authorize(t *testing.T, location string) (state string) {
+	t.Helper()
+	u, err := url.Parse(location)
+	if err != nil || !strings.HasPrefix(location, p.URL+"/authorize?") {
+		t.Fatalf("login redirected to %q, want the provider authorization endpoint", location)
+	}
+	q := u.Query()
+	p.mu.Lock()
+	p.nonce, p.challenge = q.Get("nonce"), q.Get("code_challenge")
+	p.mu.Unlock()
+	return q.Get("state")
+}
+
+// newOIDCGateway returns the OIDC routes behind the gateway's unified response middleware.
+func newOIDCGateway(t *testing.T, p *mockProvider) http.Handler {
+	t.Helper()
+	c := oidc.Conf{
+		Issuer:       p.URL,
+		ClientID:     "client",
+		RedirectURL:  "https://gw.example/auth/oidc/callback",
+		LoginPath:    "/auth/oidc/login",
+		CallbackPath: "/auth/oidc/callback",
+		UserClaim:    "sub",
+		NameClaim:    "name",
+		RolesClaim:   "roles",
+		StateTTL:     time.Minute,
+		Timeout:      5 * time.Second,
+	}
+	rp, err := oidc.NewRelyingParty(c, oidc.NewMemoryStateStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := auth.NewIssuer(auth.IssuerConf{AccessSecret: testAccessSecret, AccessExpire: 3600}, nil)
+
+	mux := http.NewServeMux()
+	for _, route := range OIDCRoutes(c, rp, issuer) {
+		mux.Handle(route.Path, middleware.ResponseMiddleware()(route.Handler))
+	}
+	return mux
+}
+
+// login starts a login and returns the state and the state cookie.
+func login(t *testing.T, gw http.Handler, p *mockProvider) (string, *http.Cookie) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	gw.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/login?redirect=/app", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("login status = %d, body %s; want 302", w.Code, w.Body)
+	}
+	state := p.authorize(t, w.Header().Get("Location"))
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Value == "" || !cookies[0].HttpOnly || !cookies[0].Secure {
+		t.Fatalf("login cookies = %v, want one HttpOnly Secure state cookie", cookies)
+	}
+	return state, cookies[0]
+}
+
+func callback(gw http.Handler, state string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/auth/oidc/callback?"+url.Values{
+		"state": {state},
+		"code":  {"code"},
+	}.Encode(), nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	gw.ServeHTTP(w, r)
+	return w
+}
+
+func TestOIDCLoginFlow(t *testing.T) {
+	p := newMockProvider(t)
+	gw := newOIDCGateway(t, p)
+
+	state, cookie := login(t, gw, p)
+	w := callback(gw, state, cookie)
+	if w.Code != http.StatusFound {
+		t.Fatalf("callback status = %d, body %s; want 302", w.Code, w.Body)
+	}
+	location := w.Header().Get("Location")
+	path, fragment, _ := strings.Cut(location, "#")
+	if path != "/app" {
+		t.Fatalf("callback redirected to %q, want /app", location)
+	}
+	values, err := url.ParseQuery(fragment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(values.Get("access_token"), claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(testAccessSecret), nil
+	}); err != nil {
+		t.Fatalf("access token: %v", err)
+	}
+	if claims[auth.ClaimUserID] != "alice" || claims[auth.ClaimUserName] != "Alice" {
+		t.Errorf("access token claims = %v, want uid alice and name Alice", claims)
+	}
+
+	// The state is single-use.
+	if w := callback(gw, state, cookie); !strings.Contains(w.Body.String(), `"code":21015`) {
+		t.Errorf("replayed callback body = %s, want code 21015", w.Body)
+	}
+}
+
+func TestOIDCCallbackRejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		cookie func(c *http.Cookie) *http.Cookie
+		claims func(c jwt.MapClaims)
+		code   int
+	}{
+		{
+			name:   "missing state cookie",
+			cookie: func(*http.Cookie) *http.Cookie { return nil },
+			code:   errcode.ErrOIDCStateInvalid.Code,
+		},
+		{
+			name: "cookie of another browser",
+			cookie: func(c *http.Cookie) *http.Cookie {
+				return &http.Cookie{Name: c.Name, Value: oidc.NewBinding()}
+			},
+			code: errcode.ErrOIDCStateInvalid.Code,
+		},
+		{
+			name:   "id token without exp",
+			claims: func(c jwt.MapClaims) { delete(c, "exp") },
+			code:   errcode.ErrOIDCLoginFailed.Code,
+		},
+		{
+			name:   "id token without iat",
+			claims: func(c jwt.MapClaims) { delete(c, "iat") },
+			code:   errcode.ErrOIDCLoginFailed.Code,
+		},
+		{
+			name:   "id token for another client",
+			claims: func(c jwt.MapClaims) { c["aud"] = "other" },
+			code:   errcode.ErrOIDCLoginFailed.Code,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newMockProvider(t)
+			if tt.claims != nil {
+				base := p.claims
+				p.claims = func(p *mockProvider) jwt.MapClaims {
+					c := base(p)
+					tt.claims(c)
+					return c
+				}
+			}
+			gw := newOIDCGateway(t, p)
+
+			state, cookie := login(t, gw, p)
+			if tt.cookie != nil {
+				cookie = tt.cookie(cookie)
+			}
+			w := callback(gw, state, cookie)
+			var body struct {
+				Code int `json:"code"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != tt.code {
+				t.Errorf("callback status %d body %s, want code %d", w.Code, w.Body, tt.code)
+			}
+		})
+	}
+}
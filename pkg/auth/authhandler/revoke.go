@@ -0,0 +1,76 @@
+// Package authhandler provides ready-made HTTP handlers for authentication endpoints.
+package authhandler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/rest/httpx"
+
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/errcode"
+	"github.com/addls/go-base/pkg/response"
+)
+
+// AdminKeyHeader HTTP header carrying the admin key for admin endpoints.
+const AdminKeyHeader = "X-Admin-Key"
+
+// RevokeReq admin revoke request. At least one of TokenID or UserID is required.
+type RevokeReq struct {
+	TokenID   string `json:"jti,optional"`       // Revoke a single token by jti
+	ExpiresAt int64  `json:"expiresAt,optional"` // Token expiration (unix seconds); defaults to now + ttl
+	UserID    string `json:"userId,optional"`    // Revoke all tokens of the user issued until now
+}
+
+// RevokeHandler returns an admin endpoint that revokes a token by jti and/or all tokens of a user.
+// Requests must carry adminKey in the X-Admin-Key header.
+// ttl should be the access token lifetime: it bounds how long revocation entries are kept
+// (auth.DefaultRevokeTTL if zero).
+func RevokeHandler(store auth.RevocationStore, adminKey string, ttl time.Duration) http.HandlerFunc {
+	if ttl <= 0 {
+		ttl = auth.DefaultRevokeTTL
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(AdminKeyHeader)
+		if adminKey == "" || subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) != 1 {
+			response.Error(w, errcode.ErrForbidden)
+			return
+		}
+
+		var req RevokeReq
+		if err := httpx.Parse(r, &req); err != nil {
+			response.ErrorInvalidParam(w, err)
+			return
+		}
+		if req.TokenID == "" && req.UserID == "" {
+			response.ErrorWithMsg(w, errcode.ErrInvalidParam, "jti or userId is required")
+			return
+		}
+
+		ctx := r.Context()
+		if req.TokenID != "" {
+			expiresAt := time.Now().Add(ttl)
+			if req.ExpiresAt > 0 {
+				expiresAt = time.Unix(req.ExpiresAt, 0)
+			}
+			if err := store.RevokeToken(ctx, req.TokenID, expiresAt); err != nil {
+				logx.WithContext(ctx).Errorf("revoke token %s failed: %v", req.TokenID, err)
+				response.Error(w, errcode.ErrInternal)
+				return
+			}
+			logx.WithContext(ctx).Infof("token revoked: jti=%s", req.TokenID)
+		}
+		if req.UserID != "" {
+			if err := store.RevokeUser(ctx, req.UserID, time.Now(), ttl); err != nil {
+				logx.WithContext(ctx).Errorf("revoke user %s tokens failed: %v", req.UserID, err)
+				response.Error(w, errcode.ErrInternal)
+				return
+			}
+			logx.WithContext(ctx).Infof("user tokens revoked: uid=%s", req.UserID)
+		}
+
+		response.Ok(w)
+	}
+}
@@ -0,0 +1,47 @@
+package authhandler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/addls/go-base/pkg/auth"
+)
+
+func TestRevokeHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		adminKey string
+		body     string
+		jti      string
+		uid      string
+		revoked  bool
+	}{
+		{name: "token without expiresAt", adminKey: "admin", body: `{"jti":"t1"}`, jti: "t1", revoked: true},
+		{name: "user", adminKey: "admin", body: `{"userId":"alice"}`, uid: "alice", revoked: true},
+		{name: "wrong admin key", adminKey: "guess", body: `{"userId":"alice"}`, uid: "alice"},
+		{name: "no admin key", body: `{"userId":"alice"}`, uid: "alice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := auth.NewMemoryRevocationStore()
+			// A zero TTL (AccessExpire not configured) must still keep the entries.
+			h := RevokeHandler(store, "admin", 0)
+
+			r := httptest.NewRequest(http.MethodPost, "/admin/auth/revoke", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			if tt.adminKey != "" {
+				r.Header.Set(AdminKeyHeader, tt.adminKey)
+			}
+			h(httptest.NewRecorder(), r)
+
+			revoked, err := store.IsRevoked(context.Background(), tt.jti, tt.uid, time.Now().Add(-time.Minute))
+			if err != nil || revoked != tt.revoked {
+				t.Errorf("IsRevoked = %v, %v; want %v", revoked, err, tt.revoked)
+			}
+		})
+	}
+}
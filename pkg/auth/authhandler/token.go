@@ -0,0 +1,123 @@
+package authhandler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4/request"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/rest"
+	"github.com/zeromicro/go-zero/rest/httpx"
+
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/response"
+)
+
+// LoginReq login request.
+type LoginReq struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// RefreshReq refresh / logout request.
+type RefreshReq struct {
+	RefreshToken string `json:"refreshToken,optional"`
+}
+
+// AuthenticateFunc verifies login credentials and returns the identity to issue tokens for.
+// Return an *errcode.Error (e.g. errcode.ErrUserPasswordWrong) to reject the login.
+type AuthenticateFunc func(ctx context.Context, req *LoginReq) (*auth.Identity, error)
+
+// Routes returns the login, refresh and logout routes (POST /login, /refresh, /logout).
+// Mount them without rest.WithJwt, e.g. bootstrap.RegisterRoutesWithPrefix(server, "/auth", authhandler.Routes(...)).
+func Routes(issuer *auth.Issuer, authenticate AuthenticateFunc) []rest.Route {
+	return []rest.Route{
+		{Method: http.MethodPost, Path: "/login", Handler: LoginHandler(issuer, authenticate)},
+		{Method: http.MethodPost, Path: "/refresh", Handler: RefreshHandler(issuer)},
+		{Method: http.MethodPost, Path: "/logout", Handler: LogoutHandler(issuer)},
+	}
+}
+
+// LoginHandler verifies credentials with authenticate and responds with a new auth.TokenPair.
+func LoginHandler(issuer *auth.Issuer, authenticate AuthenticateFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req LoginReq
+		if err := httpx.Parse(r, &req); err != nil {
+			response.ErrorInvalidParam(w, err)
+			return
+		}
+
+		id, err := authenticate(r.Context(), &req)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		pair, err := issuer.Issue(r.Context(), *id)
+		response.HandleResult(w, pair, err)
+	}
+}
+
+// RefreshHandler rotates a refresh token and responds with a new auth.TokenPair.
+func RefreshHandler(issuer *auth.Issuer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req RefreshReq
+		if err := httpx.Parse(r, &req); err != nil {
+			response.ErrorInvalidParam(w, err)
+			return
+		}
+
+		pair, err := issuer.Refresh(r.Context(), req.RefreshToken)
+		response.HandleResult(w, pair, err)
+	}
+}
+
+// LogoutHandler revokes the refresh token family, and the bearer access token if revocation is enabled.
+func LogoutHandler(issuer *auth.Issuer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req RefreshReq
+		if err := httpx.Parse(r, &req); err != nil {
+			response.ErrorInvalidParam(w, err)
+			return
+		}
+
+		ctx := r.Context()
+		if req.RefreshToken != "" {
+			if err := issuer.RevokeRefreshToken(ctx, req.RefreshToken); err != nil {
+				response.Error(w, err)
+				return
+			}
+		}
+
+		// Revoke the current access token so it cannot be used until it expires.
+		if auth.GetRevocationStore() != nil {
+			revokeBearer(r, issuer)
+		}
+
+		response.Ok(w)
+	}
+}
+
+// revokeBearer revokes the access token carried in the Authorization header, if it is valid.
+func revokeBearer(r *http.Request, issuer *auth.Issuer) {
+	tok, err := request.AuthorizationHeaderExtractor.ExtractToken(r)
+	if err != nil {
+		return
+	}
+	claims, err := issuer.ParseAccessToken(tok)
+	if err != nil {
+		return
+	}
+
+	jti, _ := claims["jti"].(string)
+	exp, _ := claims["exp"].(json.Number)
+	expiresAt, err := exp.Int64()
+	if jti == "" || err != nil {
+		return
+	}
+	if err := auth.Revoke(r.Context(), jti, time.Unix(expiresAt, 0)); err != nil {
+		logx.WithContext(r.Context()).Errorf("revoke access token %s failed: %v", jti, err)
+	}
+}
@@ -0,0 +1,239 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+
+	"github.com/addls/go-base/pkg/errcode"
+)
+
+// Claim names read by the gateway and forwarded to backends.
+const (
+	ClaimUserID   = "uid"
+	ClaimUserName = "name"
+)
+
+// Standard JWT claims and go-base identity claims that custom claims must not override.
+var reservedClaims = map[string]bool{
+	"aud": true, "exp": true, "jti": true, "iat": true, "iss": true, "nbf": true, "sub": true,
+	ClaimUserID: true, ClaimUserName: true, ClaimRoles: true, ClaimScope: true, ClaimScopes: true,
+}
+
+// IssuerConf token issuance configuration.
+type IssuerConf struct {
+	AccessSecret  string          // JWT signing secret (same as the gateway Auth.AccessSecret)
+	AccessExpire  int64           // Access token lifetime in seconds
+	RefreshExpire int64           `json:",default=604800"`                      // Refresh token lifetime in seconds (default 7 days)
+	Issuer        string          `json:",optional"`                            // Optional iss claim
+	Store         string          `json:",default=memory,options=memory|redis"` // Refresh token store: memory or redis
+	Redis         redis.RedisConf `json:",optional"`                            // Required when Store is redis
+	KeyPrefix     string          `json:",default=gobase:refresh:"`             // Redis key prefix
+}
+
+// Identity is the authenticated subject a token is issued for.
+type Identity struct {
+	UserID   string                 `json:"uid"`
+	UserName string                 `json:"name,omitempty"`
+	Roles    []string               `json:"roles,omitempty"`  // Issued as the "roles" claim
+	Scopes   []string               `json:"scopes,omitempty"` // Issued as the space separated "scope" claim
+	Claims   map[string]interface{} `json:"claims,omitempty"` // Custom claims (reserved claim names are ignored)
+}
+
+// TokenPair is an access token with its refresh token.
+type TokenPair struct {
+	AccessToken   string `json:"accessToken"`
+	AccessExpire  int64  `json:"accessExpire"` // Unix seconds
+	RefreshToken  string `json:"refreshToken"`
+	RefreshExpire int64  `json:"refreshExpire"` // Unix seconds
+}
+
+// Issuer issues access tokens and rotating refresh tokens.
+//
+// Refresh tokens are opaque and single-use: each refresh returns a new pair and marks the old
+// refresh token as used. Presenting a used refresh token again is treated as theft: the whole
+// token family (every refresh token descending from the same login) is revoked.
+type Issuer struct {
+	conf  IssuerConf
+	store RefreshStore
+}
+
+// NewIssuer creates an Issuer with the given refresh token store.
+func NewIssuer(c IssuerConf, store RefreshStore) *Issuer {
+	return &Issuer{
+		conf:  c,
+		store: store,
+	}
+}
+
+// MustNewIssuer creates an Issuer with the refresh token store from config, panics on error.
+func MustNewIssuer(c IssuerConf) *Issuer {
+	if c.AccessSecret == "" {
+		logx.Must(fmt.Errorf("auth: AccessSecret is required to issue tokens"))
+	}
+	return NewIssuer(c, MustNewRefreshStore(c))
+}
+
+// IssueAccessToken builds and signs an access token for the identity.
+// It returns the token and its claims (including the generated jti and exp).
+func (i *Issuer) IssueAccessToken(id Identity) (string, jwt.MapClaims, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{}
+	for k, v := range id.Claims {
+		if !reservedClaims[k] {
+			claims[k] = v
+		}
+	}
+	claims[ClaimUserID] = id.UserID
+	if id.UserName != "" {
+		claims[ClaimUserName] = id.UserName
+	}
+	if len(id.Roles) > 0 {
+		claims[ClaimRoles] = id.Roles
+	}
+	if len(id.Scopes) > 0 {
+		claims[ClaimScope] = strings.Join(id.Scopes, " ")
+	}
+	claims["jti"] = randomID()
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(time.Duration(i.conf.AccessExpire) * time.Second).Unix()
+	if i.conf.Issuer != "" {
+		claims["iss"] = i.conf.Issuer
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(i.conf.AccessSecret))
+	if err != nil {
+		return "", nil, err
+	}
+	return token, claims, nil
+}
+
+// ParseAccessToken verifies an access token issued by this Issuer and returns its claims.
+func (i *Issuer) ParseAccessToken(tokenString string) (jwt.MapClaims, error) {
+	parser := jwt.NewParser(jwt.WithJSONNumber(), jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	tok, err := parser.Parse(tokenString, func(*jwt.Token) (interface{}, error) {
+		return []byte(i.conf.AccessSecret), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := tok.Claims.(jwt.MapClaims)
+	if !ok || !tok.Valid {
+		return nil, errcode.ErrTokenInvalid
+	}
+	return claims, nil
+}
+
+// Issue issues a new token pair, starting a new refresh token family (e.g. on login).
+func (i *Issuer) Issue(ctx context.Context, id Identity) (*TokenPair, error) {
+	return i.issuePair(ctx, id, randomID())
+}
+
+// Refresh rotates a refresh token: it returns a new token pair and invalidates the old refresh token.
+// Reusing a refresh token revokes its whole family and returns errcode.ErrRefreshTokenReused.
+func (i *Issuer) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	rt, reused, err := i.store.Consume(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if rt == nil || time.Now().After(rt.ExpiresAt) {
+		return nil, errcode.ErrRefreshTokenInvalid
+	}
+
+	revoked, err := i.store.IsFamilyRevoked(ctx, rt.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, errcode.ErrRefreshTokenInvalid
+	}
+
+	if reused {
+		logx.WithContext(ctx).Errorf("refresh token reuse detected: uid=%s family=%s", rt.Identity.UserID, rt.FamilyID)
+		if err := i.store.RevokeFamily(ctx, rt.FamilyID, i.refreshTTL()); err != nil {
+			return nil, err
+		}
+		// Access tokens issued to the family may be stolen as well. Their jtis are not tracked, so every
+		// access token of the user is revoked (all devices) if revocation is enabled; the other families
+		// keep their refresh tokens and sign in again silently.
+		if GetRevocationStore() != nil {
+			if err := RevokeUser(ctx, rt.Identity.UserID, time.Duration(i.conf.AccessExpire)*time.Second); err != nil {
+				logx.WithContext(ctx).Errorf("revoke access tokens of user %s failed: %v", rt.Identity.UserID, err)
+			}
+		}
+		return nil, errcode.ErrRefreshTokenReused
+	}
+
+	return i.issuePair(ctx, rt.Identity, rt.FamilyID)
+}
+
+// RevokeRefreshToken revokes the family of a refresh token (e.g. on logout).
+// Unknown refresh tokens are ignored.
+func (i *Issuer) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	rt, err := i.store.Get(ctx, hashToken(refreshToken))
+	if err != nil || rt == nil {
+		return err
+	}
+	return i.store.RevokeFamily(ctx, rt.FamilyID, i.refreshTTL())
+}
+
+func (i *Issuer) issuePair(ctx context.Context, id Identity, familyID string) (*TokenPair, error) {
+	accessToken, claims, err := i.IssueAccessToken(id)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken := randomToken()
+	refreshExpire := time.Now().Add(i.refreshTTL())
+	if err := i.store.Save(ctx, hashToken(refreshToken), RefreshToken{
+		FamilyID:  familyID,
+		Identity:  id,
+		ExpiresAt: refreshExpire,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:   accessToken,
+		AccessExpire:  claims["exp"].(int64),
+		RefreshToken:  refreshToken,
+		RefreshExpire: refreshExpire.Unix(),
+	}, nil
+}
+
+func (i *Issuer) refreshTTL() time.Duration {
+	return time.Duration(i.conf.RefreshExpire) * time.Second
+}
+
+// randomID returns a random 128-bit hex id (used for jti and refresh token families).
+func randomID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// randomToken returns a random 256-bit URL-safe token.
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// hashToken returns the store key of a refresh token, so that stored keys cannot be used as tokens.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
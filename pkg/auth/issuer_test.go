@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/addls/go-base/pkg/errcode"
+)
+
+func TestIssuerRefresh(t *testing.T) {
+	revocation := NewMemoryRevocationStore()
+	SetRevocationStore(revocation)
+	defer SetRevocationStore(nil)
+
+	ctx := context.Background()
+	issuer := NewIssuer(IssuerConf{AccessSecret: "issuer-secret", AccessExpire: 3600, RefreshExpire: 3600}, NewMemoryRefreshStore())
+	alice := Identity{UserID: "alice"}
+
+	stolen, err := issuer.Issue(ctx, alice) // Login on the device the token is stolen from
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := issuer.Issue(ctx, alice) // Login on another device
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := issuer.Refresh(ctx, stolen.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.RefreshToken == stolen.RefreshToken {
+		t.Fatal("refresh did not rotate the refresh token")
+	}
+	issuedAt := time.Unix(time.Now().Unix(), 0) // iat of the access tokens issued so far
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{name: "unknown token", token: "unknown", want: errcode.ErrRefreshTokenInvalid},
+		{name: "reused token", token: stolen.RefreshToken, want: errcode.ErrRefreshTokenReused},
+		{name: "rotated token of the revoked family", token: rotated.RefreshToken, want: errcode.ErrRefreshTokenInvalid},
+		{name: "other family", token: other.RefreshToken},
+	}
+	for _, tt := range tests {
+		if _, err := issuer.Refresh(ctx, tt.token); !errors.Is(err, tt.want) {
+			t.Fatalf("%s: Refresh error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	// Reuse revokes every access token of the user issued so far.
+	revoked, err := revocation.IsRevoked(ctx, "", "alice", issuedAt)
+	if err != nil || !revoked {
+		t.Errorf("access tokens of alice revoked = %v, %v; want true", revoked, err)
+	}
+}
+
+func TestIssuerRevokeRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	issuer := NewIssuer(IssuerConf{AccessSecret: "issuer-secret", AccessExpire: 3600, RefreshExpire: 3600}, NewMemoryRefreshStore())
+	pair, err := issuer.Issue(ctx, Identity{UserID: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := issuer.Refresh(ctx, pair.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Logging out with an earlier token of the family ends the whole session.
+	if err := issuer.RevokeRefreshToken(ctx, pair.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := issuer.Refresh(ctx, rotated.RefreshToken); !errors.Is(err, errcode.ErrRefreshTokenInvalid) {
+		t.Errorf("Refresh after logout error = %v, want %v", err, errcode.ErrRefreshTokenInvalid)
+	}
+	if err := issuer.RevokeRefreshToken(ctx, "unknown"); err != nil {
+		t.Errorf("RevokeRefreshToken of an unknown token = %v, want nil", err)
+	}
+}
@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// JwtIssuerHeader HTTP header name used to pass through the name of the issuer that authenticated the request.
+const JwtIssuerHeader = "x-jwt-issuer"
+
+// DefaultIssuerName is the issuer name of the legacy Auth.AccessSecret when named issuers are configured.
+const DefaultIssuerName = "default"
+
+// APIKeyIssuerName is the reserved issuer name that admits API keys on routes restricting issuers.
+const APIKeyIssuerName = AuthTypeAPIKey
+
+var (
+	errIssuerNotAccepted = errors.New("token issuer not accepted")
+	errAudienceMismatch  = errors.New("token audience not accepted")
+)
+
+// JwtIssuerConf a named token issuer accepted by the gateway.
+type JwtIssuerConf struct {
+	Name      string   // Issuer name, referenced by rules and forwarded to backends (x-jwt-issuer)
+	Secret    string   `json:",optional"` // HMAC secret (HS256/HS384/HS512)
+	PublicKey string   `json:",optional"` // PEM encoded RSA or EC public key (RS*/PS*/ES*)
+	Issuer    string   `json:",optional"` // Expected iss claim; empty skips the check
+	Audience  []string `json:",optional"` // Accepted aud values (any of them); empty skips the check
+}
+
+type jwtIssuer struct {
+	conf    JwtIssuerConf
+	key     interface{}
+	methods []string
+}
+
+// JwtVerifier verifies tokens against a set of named issuers.
+type JwtVerifier struct {
+	issuers []*jwtIssuer
+	byName  map[string]*jwtIssuer
+}
+
+// NewJwtVerifier creates a JwtVerifier from issuer configs.
+func NewJwtVerifier(issuers []JwtIssuerConf) (*JwtVerifier, error) {
+	v := &JwtVerifier{
+		byName: make(map[string]*jwtIssuer, len(issuers)),
+	}
+	for _, c := range issuers {
+		if c.Name == "" {
+			return nil, fmt.Errorf("auth: jwt issuer name is required")
+		}
+		if c.Name == APIKeyIssuerName {
+			return nil, fmt.Errorf("auth: jwt issuer name %q is reserved", c.Name)
+		}
+		if _, ok := v.byName[c.Name]; ok {
+			return nil, fmt.Errorf("auth: duplicate jwt issuer %q", c.Name)
+		}
+
+		iss := &jwtIssuer{conf: c}
+		switch {
+		case c.PublicKey != "":
+			if key, err := jwt.ParseRSAPublicKeyFromPEM([]byte(c.PublicKey)); err == nil {
+				iss.key = key
+				iss.methods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}
+			} else if key, err := jwt.ParseECPublicKeyFromPEM([]byte(c.PublicKey)); err == nil {
+				iss.key = key
+				iss.methods = []string{"ES256", "ES384", "ES512"}
+			} else {
+				return nil, fmt.Errorf("auth: jwt issuer %q: invalid public key", c.Name)
+			}
+		case c.Secret != "":
+			iss.key = []byte(c.Secret)
+			iss.methods = []string{"HS256", "HS384", "HS512"}
+		default:
+			return nil, fmt.Errorf("auth: jwt issuer %q requires Secret or PublicKey", c.Name)
+		}
+
+		v.issuers = append(v.issuers, iss)
+		v.byName[c.Name] = iss
+	}
+	return v, nil
+}
+
+// MustNewJwtVerifier creates a JwtVerifier from issuer configs, panics on error.
+func MustNewJwtVerifier(issuers []JwtIssuerConf) *JwtVerifier {
+	v, err := NewJwtVerifier(issuers)
+	logx.Must(err)
+	return v
+}
+
+// Check returns an error if any of the issuer names is not configured.
+// APIKeyIssuerName is always accepted.
+func (v *JwtVerifier) Check(names []string) error {
+	for _, name := range names {
+		if _, ok := v.byName[name]; !ok && name != APIKeyIssuerName {
+			return fmt.Errorf("auth: unknown jwt issuer %q", name)
+		}
+	}
+	return nil
+}
+
+// Verify verifies the token against the accepted issuers (all issuers if empty)
+// and returns the name of the issuer that signed it with the token claims.
+//
+// Issuers whose Issuer matches the token iss claim are tried first; if none does,
+// issuers without an Issuer check are tried. Expired tokens signed by an accepted
+// issuer are reported as jwt.ErrTokenExpired.
+func (v *JwtVerifier) Verify(tokenString string, accepted []string) (string, jwt.MapClaims, error) {
+	var unverified jwt.MapClaims
+	if _, _, err := jwt.NewParser(jwt.WithJSONNumber()).ParseUnverified(tokenString, &unverified); err != nil {
+		return "", nil, err
+	}
+	iss, _ := unverified["iss"].(string)
+
+	candidates := v.candidates(accepted, iss)
+	if len(candidates) == 0 {
+		return "", nil, errIssuerNotAccepted
+	}
+
+	var lastErr error
+	for _, c := range candidates {
+		claims := jwt.MapClaims{}
+		tok, err := jwt.NewParser(jwt.WithJSONNumber(), jwt.WithValidMethods(c.methods)).
+			ParseWithClaims(tokenString, claims, func(*jwt.Token) (interface{}, error) {
+				return c.key, nil
+			})
+		if err != nil {
+			var verr *jwt.ValidationError
+			if errors.As(err, &verr) && verr.Errors&(jwt.ValidationErrorSignatureInvalid|jwt.ValidationErrorUnverifiable) != 0 {
+				// Not signed by this issuer: try the next one.
+				lastErr = err
+				continue
+			}
+			return "", nil, err
+		}
+		if !tok.Valid {
+			lastErr = errIssuerNotAccepted
+			continue
+		}
+		if !c.acceptsAudience(claims) {
+			// Signed by this issuer but meant for another audience: try the next one.
+			lastErr = errAudienceMismatch
+			continue
+		}
+		return c.conf.Name, claims, nil
+	}
+	return "", nil, lastErr
+}
+
+// candidates returns the accepted issuers to try for a token with the given iss claim.
+func (v *JwtVerifier) candidates(accepted []string, iss string) []*jwtIssuer {
+	pool := v.issuers
+	if len(accepted) > 0 {
+		pool = make([]*jwtIssuer, 0, len(accepted))
+		for _, name := range accepted {
+			if c, ok := v.byName[name]; ok {
+				pool = append(pool, c)
+			}
+		}
+	}
+
+	var matched, unchecked []*jwtIssuer
+	for _, c := range pool {
+		switch c.conf.Issuer {
+		case "":
+			unchecked = append(unchecked, c)
+		case iss:
+			matched = append(matched, c)
+		}
+	}
+	if len(matched) > 0 {
+		return matched
+	}
+	return unchecked
+}
+
+func (c *jwtIssuer) acceptsAudience(claims jwt.MapClaims) bool {
+	if len(c.conf.Audience) == 0 {
+		return true
+	}
+	for _, aud := range c.conf.Audience {
+		if claims.VerifyAudience(aud, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// keyType returns a short description of the issuer key type (used in logs).
+func (c *jwtIssuer) keyType() string {
+	switch c.key.(type) {
+	case *rsa.PublicKey:
+		return "rsa"
+	case *ecdsa.PublicKey:
+		return "ec"
+	default:
+		return "hmac"
+	}
+}
+
+// String returns a short description of the configured issuers (used in logs).
+func (v *JwtVerifier) String() string {
+	parts := make([]string, 0, len(v.issuers))
+	for _, c := range v.issuers {
+		parts = append(parts, c.conf.Name+"("+c.keyType()+")")
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func signHS256(t *testing.T, secret string, claims jwt.MapClaims) string {
+	t.Helper()
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestNewJwtVerifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		issuers []JwtIssuerConf
+		wantErr bool
+	}{
+		{name: "valid", issuers: []JwtIssuerConf{{Name: "web", Secret: "s1"}, {Name: "partner", Secret: "s2"}}},
+		{name: "missing name", issuers: []JwtIssuerConf{{Secret: "s1"}}, wantErr: true},
+		{name: "reserved name", issuers: []JwtIssuerConf{{Name: APIKeyIssuerName, Secret: "s1"}}, wantErr: true},
+		{name: "duplicate name", issuers: []JwtIssuerConf{{Name: "web", Secret: "s1"}, {Name: "web", Secret: "s2"}}, wantErr: true},
+		{name: "missing key", issuers: []JwtIssuerConf{{Name: "web"}}, wantErr: true},
+		{name: "invalid public key", issuers: []JwtIssuerConf{{Name: "web", PublicKey: "not a key"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewJwtVerifier(tt.issuers); (err != nil) != tt.wantErr {
+				t.Errorf("NewJwtVerifier error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJwtVerifierVerify(t *testing.T) {
+	v, err := NewJwtVerifier([]JwtIssuerConf{
+		// Two clients of the same identity provider share its key and differ by audience.
+		{Name: "web", Secret: "idp-secret", Issuer: "https://idp.example", Audience: []string{"web"}},
+		{Name: "mobile", Secret: "idp-secret", Issuer: "https://idp.example", Audience: []string{"mobile"}},
+		{Name: "partner", Secret: "partner-secret"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		accepted []string
+		want     string
+		wantErr  error
+	}{
+		{name: "first audience", token: signHS256(t, "idp-secret", jwt.MapClaims{"iss": "https://idp.example", "aud": "web"}), want: "web"},
+		{name: "second audience", token: signHS256(t, "idp-secret", jwt.MapClaims{"iss": "https://idp.example", "aud": "mobile"}),
+			want: "mobile"},
+		{name: "unknown audience", token: signHS256(t, "idp-secret", jwt.MapClaims{"iss": "https://idp.example", "aud": "tv"}),
+			wantErr: errAudienceMismatch},
+		{name: "audience of an issuer not accepted", token: signHS256(t, "idp-secret", jwt.MapClaims{"iss": "https://idp.example", "aud": "mobile"}),
+			accepted: []string{"web"}, wantErr: errAudienceMismatch},
+		{name: "issuer without iss check", token: signHS256(t, "partner-secret", jwt.MapClaims{"uid": "p1"}), want: "partner"},
+		{name: "issuer not accepted", token: signHS256(t, "partner-secret", jwt.MapClaims{"uid": "p1"}), accepted: []string{"web"},
+			wantErr: errIssuerNotAccepted},
+		{name: "unknown key", token: signHS256(t, "other-secret", jwt.MapClaims{"uid": "p1"}), wantErr: jwt.ErrSignatureInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := v.Verify(tt.token, tt.accepted)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Verify error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Verify = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
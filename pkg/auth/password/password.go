@@ -0,0 +1,191 @@
+// Package password hashes and verifies user passwords (argon2id or bcrypt), checks password
+// policies and throttles failed login attempts.
+package password
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/addls/go-base/pkg/errcode"
+)
+
+// Hash algorithms.
+const (
+	AlgArgon2id = "argon2id"
+	AlgBcrypt   = "bcrypt"
+)
+
+// ErrUnsupportedHash is returned for encoded hashes in an unknown format.
+var ErrUnsupportedHash = errors.New("password: unsupported hash format")
+
+// Conf password hashing configuration.
+// Changing the parameters does not invalidate stored hashes: they are rehashed on the next login.
+type Conf struct {
+	Algorithm  string     `json:",default=argon2id,options=argon2id|bcrypt"`
+	Argon2     Argon2Conf // argon2id parameters
+	BcryptCost int        `json:",default=12,range=[4:31]"` // bcrypt cost
+}
+
+// Argon2Conf argon2id parameters (defaults follow RFC 9106 second recommended option, with less memory).
+type Argon2Conf struct {
+	Memory  uint32 `json:",default=65536"` // Memory in KiB
+	Time    uint32 `json:",default=3"`     // Iterations
+	Threads uint8  `json:",default=2"`     // Parallelism
+	SaltLen uint32 `json:",default=16"`    // Salt length in bytes
+	KeyLen  uint32 `json:",default=32"`    // Hash length in bytes
+}
+
+// Upper bounds of the argon2id parameters accepted from stored hashes.
+const (
+	maxArgon2Memory = 4 * 1024 * 1024 // KiB (4 GiB)
+	maxArgon2Time   = 64
+	maxArgon2KeyLen = 1024
+)
+
+// DefaultConf returns the default hashing configuration.
+func DefaultConf() Conf {
+	return Conf{
+		Algorithm: AlgArgon2id,
+		Argon2: Argon2Conf{
+			Memory:  64 * 1024,
+			Time:    3,
+			Threads: 2,
+			SaltLen: 16,
+			KeyLen:  32,
+		},
+		BcryptCost: 12,
+	}
+}
+
+// Hasher hashes and verifies passwords.
+type Hasher struct {
+	conf Conf
+}
+
+// NewHasher creates a Hasher.
+func NewHasher(c Conf) *Hasher {
+	return &Hasher{conf: c}
+}
+
+// Hash hashes a password into an encoded string that embeds the algorithm and parameters
+// (PHC format for argon2id: $argon2id$v=19$m=65536,t=3,p=2$salt$hash; standard $2a$ format for bcrypt).
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.conf.Algorithm == AlgBcrypt {
+		b, err := bcrypt.GenerateFromPassword([]byte(password), h.conf.BcryptCost)
+		return string(b), err
+	}
+
+	p := h.conf.Argon2
+	salt := make([]byte, p.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, p.Memory, p.Time, p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify compares a password with an encoded hash in constant time.
+// needsRehash reports whether the hash was made with another algorithm or other parameters
+// than the current configuration; callers should then store Hash(password).
+func (h *Hasher) Verify(password, encoded string) (ok, needsRehash bool, err error) {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		p, salt, key, err := decodeArgon2(encoded)
+		if err != nil {
+			return false, false, err
+		}
+		got := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(got, key) != 1 {
+			return false, false, nil
+		}
+		c := h.conf.Argon2
+		needsRehash = h.conf.Algorithm != AlgArgon2id || p.Memory != c.Memory || p.Time != c.Time ||
+			p.Threads != c.Threads || uint32(len(salt)) != c.SaltLen || uint32(len(key)) != c.KeyLen
+		return true, needsRehash, nil
+	case strings.HasPrefix(encoded, "$2"):
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, err
+		}
+		cost, err := bcrypt.Cost([]byte(encoded))
+		if err != nil {
+			return false, false, err
+		}
+		return true, h.conf.Algorithm != AlgBcrypt || cost != h.conf.BcryptCost, nil
+	}
+	return false, false, ErrUnsupportedHash
+}
+
+// Login verifies a login attempt for the given throttle key (e.g. the user name), counting failures.
+// It returns errcode.ErrUserLocked while the key is locked out, errcode.ErrUserPasswordWrong for a
+// wrong password, and a non-empty newHash when the stored hash should be replaced (rehash on login).
+// A nil throttle disables throttling.
+func (h *Hasher) Login(ctx context.Context, throttle *Throttle, key, password, encoded string) (newHash string, err error) {
+	if throttle != nil {
+		if err := throttle.Check(ctx, key); err != nil {
+			return "", err
+		}
+	}
+
+	ok, needsRehash, err := h.Verify(password, encoded)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		if throttle != nil {
+			throttle.Fail(ctx, key)
+		}
+		return "", errcode.ErrUserPasswordWrong
+	}
+
+	if throttle != nil {
+		throttle.Reset(ctx, key)
+	}
+	if needsRehash {
+		return h.Hash(password)
+	}
+	return "", nil
+}
+
+// decodeArgon2 parses a PHC encoded argon2id hash.
+func decodeArgon2(encoded string) (Argon2Conf, []byte, []byte, error) {
+	var p Argon2Conf
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, ErrUnsupportedHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrUnsupportedHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return p, nil, nil, ErrUnsupportedHash
+	}
+	// Out-of-range parameters would make argon2 panic or exhaust memory and CPU.
+	if p.Threads == 0 || p.Time == 0 || p.Time > maxArgon2Time ||
+		p.Memory < 8*uint32(p.Threads) || p.Memory > maxArgon2Memory {
+		return p, nil, nil, ErrUnsupportedHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrUnsupportedHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 || len(key) > maxArgon2KeyLen {
+		return p, nil, nil, ErrUnsupportedHash
+	}
+	p.SaltLen, p.KeyLen = uint32(len(salt)), uint32(len(key))
+	return p, salt, key, nil
+}
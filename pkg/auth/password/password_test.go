@@ -0,0 +1,119 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/addls/go-base/pkg/errcode"
+)
+
+// testConf keeps argon2 cheap in tests.
+func testConf() Conf {
+	c := DefaultConf()
+	c.Argon2.Memory, c.Argon2.Time, c.Argon2.Threads = 64, 1, 1
+	c.BcryptCost = 4
+	return c
+}
+
+func TestHasherVerify(t *testing.T) {
+	h := NewHasher(testConf())
+	encoded, err := h.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(encoded, "$") // "", argon2id, v=19, m=..,t=..,p=.., salt, key
+	withParams := func(params string) string {
+		return strings.Join([]string{"", parts[1], parts[2], params, parts[4], parts[5]}, "$")
+	}
+	withKey := func(key string) string {
+		return strings.Join([]string{"", parts[1], parts[2], parts[3], parts[4], key}, "$")
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		encoded  string
+		ok       bool
+		err      error
+	}{
+		{name: "match", password: "secret", encoded: encoded, ok: true},
+		{name: "mismatch", password: "wrong", encoded: encoded},
+		{name: "no threads", password: "secret", encoded: withParams("m=64,t=1,p=0"), err: ErrUnsupportedHash},
+		{name: "no iterations", password: "secret", encoded: withParams("m=64,t=0,p=1"), err: ErrUnsupportedHash},
+		{name: "too many iterations", password: "secret", encoded: withParams("m=64,t=1000000,p=1"), err: ErrUnsupportedHash},
+		{name: "memory below 8 KiB per thread", password: "secret", encoded: withParams("m=15,t=1,p=2"), err: ErrUnsupportedHash},
+		{name: "too much memory", password: "secret", encoded: withParams("m=4294967295,t=1,p=1"), err: ErrUnsupportedHash},
+		{name: "key too long", password: "secret", encoded: withKey(strings.Repeat("A", 2000)), err: ErrUnsupportedHash},
+		{name: "empty key", password: "secret", encoded: withKey(""), err: ErrUnsupportedHash},
+		{name: "unknown format", password: "secret", encoded: "plain", err: ErrUnsupportedHash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, _, err := h.Verify(tt.password, tt.encoded)
+			if !errors.Is(err, tt.err) || ok != tt.ok {
+				t.Errorf("Verify = %v, %v; want %v, %v", ok, err, tt.ok, tt.err)
+			}
+		})
+	}
+}
+
+func TestHasherNeedsRehash(t *testing.T) {
+	old := NewHasher(testConf())
+	encoded, err := old.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stronger := testConf()
+	stronger.Argon2.Time = 2
+	bcryptConf := testConf()
+	bcryptConf.Algorithm = AlgBcrypt
+
+	tests := []struct {
+		name string
+		conf Conf
+		want bool
+	}{
+		{name: "same parameters", conf: testConf()},
+		{name: "new parameters", conf: stronger, want: true},
+		{name: "new algorithm", conf: bcryptConf, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needsRehash, err := NewHasher(tt.conf).Verify("secret", encoded)
+			if err != nil || !ok || needsRehash != tt.want {
+				t.Errorf("Verify = %v, %v, %v; want true, %v, nil", ok, needsRehash, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasherLogin(t *testing.T) {
+	h := NewHasher(testConf())
+	encoded, err := h.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	throttle := NewThrottle(ThrottleConf{MaxAttempts: 2, Window: time.Minute, Lockout: time.Minute}, NewMemoryThrottleStore())
+
+	tests := []struct {
+		name     string
+		password string
+		want     error
+	}{
+		{name: "wrong password", password: "wrong", want: errcode.ErrUserPasswordWrong},
+		{name: "success resets the failures", password: "secret"},
+		{name: "first failure", password: "wrong", want: errcode.ErrUserPasswordWrong},
+		{name: "second failure locks out", password: "wrong", want: errcode.ErrUserPasswordWrong},
+		{name: "locked out", password: "secret", want: errcode.ErrUserLocked},
+	}
+	for _, tt := range tests {
+		if _, err := h.Login(ctx, throttle, "alice", tt.password, encoded); !errors.Is(err, tt.want) {
+			t.Fatalf("%s: Login error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
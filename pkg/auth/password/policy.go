@@ -0,0 +1,125 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/addls/go-base/pkg/errcode"
+)
+
+// Policy violation rules.
+const (
+	RuleMinLength    = "min_length"
+	RuleMaxLength    = "max_length"
+	RuleUpper        = "upper"
+	RuleLower        = "lower"
+	RuleDigit        = "digit"
+	RuleSymbol       = "symbol"
+	RuleContainsUser = "contains_user_input"
+	RuleCommon       = "common"
+)
+
+// commonPasswords are rejected regardless of the other rules.
+var commonPasswords = map[string]bool{
+	"123456": true, "12345678": true, "123456789": true, "1234567890": true, "password": true,
+	"password1": true, "qwerty": true, "qwerty123": true, "abc123": true, "111111": true,
+	"iloveyou": true, "admin": true, "admin123": true, "welcome": true, "letmein": true,
+}
+
+// PolicyConf password policy configuration.
+type PolicyConf struct {
+	MinLength     int  `json:",default=8"`   // Minimum length in characters
+	MaxLength     int  `json:",default=128"` // Maximum length in characters (bcrypt only uses the first 72 bytes)
+	RequireUpper  bool `json:",optional"`
+	RequireLower  bool `json:",optional"`
+	RequireDigit  bool `json:",optional"`
+	RequireSymbol bool `json:",optional"`
+	RejectCommon  bool `json:",default=true"` // Reject well-known passwords
+}
+
+// Violation is a failed policy rule.
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Policy checks passwords against a PolicyConf.
+type Policy struct {
+	conf PolicyConf
+}
+
+// NewPolicy creates a Policy.
+func NewPolicy(c PolicyConf) *Policy {
+	return &Policy{conf: c}
+}
+
+// Check returns all policy violations of the password (nil if it complies).
+// userInputs (user name, email, ...) must not appear in the password.
+func (p *Policy) Check(password string, userInputs ...string) []Violation {
+	var violations []Violation
+	add := func(rule, format string, args ...interface{}) {
+		violations = append(violations, Violation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	n := utf8.RuneCountInString(password)
+	if n < p.conf.MinLength {
+		add(RuleMinLength, "must be at least %d characters", p.conf.MinLength)
+	}
+	if p.conf.MaxLength > 0 && n > p.conf.MaxLength {
+		add(RuleMaxLength, "must be at most %d characters", p.conf.MaxLength)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.conf.RequireUpper && !upper {
+		add(RuleUpper, "must contain an uppercase letter")
+	}
+	if p.conf.RequireLower && !lower {
+		add(RuleLower, "must contain a lowercase letter")
+	}
+	if p.conf.RequireDigit && !digit {
+		add(RuleDigit, "must contain a digit")
+	}
+	if p.conf.RequireSymbol && !symbol {
+		add(RuleSymbol, "must contain a symbol")
+	}
+
+	lowered := strings.ToLower(password)
+	for _, in := range userInputs {
+		if len(in) >= 3 && strings.Contains(lowered, strings.ToLower(in)) {
+			add(RuleContainsUser, "must not contain the user name or email")
+			break
+		}
+	}
+	if p.conf.RejectCommon && commonPasswords[lowered] {
+		add(RuleCommon, "is too common")
+	}
+	return violations
+}
+
+// Validate checks the password and returns errcode.ErrPasswordPolicy listing the violations
+// (nil if it complies). Use Check to get the violations as structured details.
+func (p *Policy) Validate(password string, userInputs ...string) error {
+	violations := p.Check(password, userInputs...)
+	if len(violations) == 0 {
+		return nil
+	}
+	msgs := make([]string, 0, len(violations))
+	for _, v := range violations {
+		msgs = append(msgs, v.Message)
+	}
+	return errcode.ErrPasswordPolicy.WithMsg("password " + strings.Join(msgs, "; "))
+}
@@ -0,0 +1,244 @@
+package password
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+
+	"github.com/addls/go-base/pkg/errcode"
+	"github.com/addls/go-base/pkg/internal/redisx"
+)
+
+// Store types.
+const (
+	StoreMemory = "memory" // In-process, single instance only
+	StoreRedis  = "redis"  // Shared by all instances of a cluster
+)
+
+// ThrottleConf login throttling configuration.
+type ThrottleConf struct {
+	MaxAttempts int             `json:",default=5"`                           // Failures allowed within Window before lockout
+	Window      time.Duration   `json:",default=15m"`                         // Failure counting window
+	Lockout     time.Duration   `json:",default=15m"`                         // Lockout duration
+	Store       string          `json:",default=memory,options=memory|redis"` // memory (single instance) or redis (cluster)
+	Redis       redis.RedisConf `json:",optional"`                            // Required when Store is redis
+	KeyPrefix   string          `json:",default=gobase:login:"`               // Redis key prefix
+}
+
+// ThrottleStore counts failures and keeps lockouts.
+type ThrottleStore interface {
+	// Incr increments the failure counter of key (starting a window of the given length) and returns it.
+	Incr(ctx context.Context, key string, window time.Duration) (int, error)
+	// Lock locks key out for d and clears its counter.
+	Lock(ctx context.Context, key string, d time.Duration) error
+	// Locked reports whether key is locked out.
+	Locked(ctx context.Context, key string) (bool, error)
+	// Reset clears the failure counter of key.
+	Reset(ctx context.Context, key string) error
+}
+
+// Throttle locks out login keys (user names, IPs, ...) after repeated failures.
+type Throttle struct {
+	conf  ThrottleConf
+	store ThrottleStore
+}
+
+// NewThrottle creates a Throttle.
+func NewThrottle(c ThrottleConf, store ThrottleStore) *Throttle {
+	return &Throttle{
+		conf:  c,
+		store: store,
+	}
+}
+
+// MustNewThrottle creates a Throttle with the store from config, panics on error.
+func MustNewThrottle(c ThrottleConf) *Throttle {
+	if c.MaxAttempts <= 0 {
+		logx.Must(fmt.Errorf("password: MaxAttempts must be positive"))
+	}
+	if c.Store == StoreRedis {
+		return NewThrottle(c, NewRedisThrottleStore(redis.MustNewRedis(c.Redis), c.KeyPrefix))
+	}
+	return NewThrottle(c, NewMemoryThrottleStore())
+}
+
+// Check returns errcode.ErrUserLocked if key is locked out.
+func (t *Throttle) Check(ctx context.Context, key string) error {
+	locked, err := t.store.Locked(ctx, key)
+	if err != nil {
+		// Fail open: a store outage must not block every login.
+		logx.WithContext(ctx).Errorf("login throttle check failed: %v", err)
+		return nil
+	}
+	if locked {
+		return errcode.ErrUserLocked
+	}
+	return nil
+}
+
+// Fail records a failed attempt and locks key out once MaxAttempts is reached.
+// Store errors are logged: throttling fails open.
+func (t *Throttle) Fail(ctx context.Context, key string) {
+	n, err := t.store.Incr(ctx, key, t.conf.Window)
+	if err != nil {
+		logx.WithContext(ctx).Errorf("login throttle update failed: %v", err)
+		return
+	}
+	if n >= t.conf.MaxAttempts {
+		logx.WithContext(ctx).Infof("login locked out after %d failed attempts: %s", n, key)
+		if err := t.store.Lock(ctx, key, t.conf.Lockout); err != nil {
+			logx.WithContext(ctx).Errorf("login throttle lock failed: %v", err)
+		}
+	}
+}
+
+// Reset clears the failures of key (after a successful login).
+func (t *Throttle) Reset(ctx context.Context, key string) {
+	if err := t.store.Reset(ctx, key); err != nil {
+		logx.WithContext(ctx).Errorf("login throttle reset failed: %v", err)
+	}
+}
+
+// ----- In-memory store -----
+
+// sweepInterval controls how often expired counters and lockouts are purged from the in-memory store.
+const sweepInterval = time.Minute
+
+type memoryCounter struct {
+	count     int
+	expiresAt time.Time
+}
+
+// MemoryThrottleStore is an in-memory throttle store (single instance only).
+type MemoryThrottleStore struct {
+	mu        sync.Mutex
+	counters  map[string]memoryCounter
+	locks     map[string]time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryThrottleStore creates an in-memory throttle store.
+func NewMemoryThrottleStore() *MemoryThrottleStore {
+	return &MemoryThrottleStore{
+		counters:  make(map[string]memoryCounter),
+		locks:     make(map[string]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+// Incr implements ThrottleStore.
+func (s *MemoryThrottleStore) Incr(_ context.Context, key string, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.sweepLocked(now)
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.expiresAt) {
+		c = memoryCounter{expiresAt: now.Add(window)}
+	}
+	c.count++
+	s.counters[key] = c
+	return c.count, nil
+}
+
+// Lock implements ThrottleStore.
+func (s *MemoryThrottleStore) Lock(_ context.Context, key string, d time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.sweepLocked(now)
+	delete(s.counters, key)
+	s.locks[key] = now.Add(d)
+	return nil
+}
+
+// Locked implements ThrottleStore.
+func (s *MemoryThrottleStore) Locked(_ context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	until, ok := s.locks[key]
+	if ok && !time.Now().Before(until) {
+		delete(s.locks, key)
+		return false, nil
+	}
+	return ok, nil
+}
+
+// Reset implements ThrottleStore.
+func (s *MemoryThrottleStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.counters, key)
+	return nil
+}
+
+func (s *MemoryThrottleStore) sweepLocked(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for k, c := range s.counters {
+		if !now.Before(c.expiresAt) {
+			delete(s.counters, k)
+		}
+	}
+	for k, until := range s.locks {
+		if !now.Before(until) {
+			delete(s.locks, k)
+		}
+	}
+}
+
+// ----- Redis store -----
+
+// RedisThrottleStore is a Redis-backed throttle store shared by all instances of a cluster.
+type RedisThrottleStore struct {
+	rds    *redis.Redis
+	prefix string
+}
+
+// NewRedisThrottleStore creates a Redis-backed throttle store.
+func NewRedisThrottleStore(rds *redis.Redis, keyPrefix string) *RedisThrottleStore {
+	return &RedisThrottleStore{
+		rds:    rds,
+		prefix: keyPrefix,
+	}
+}
+
+// Incr implements ThrottleStore.
+func (s *RedisThrottleStore) Incr(ctx context.Context, key string, window time.Duration) (int, error) {
+	n, err := s.rds.IncrCtx(ctx, s.prefix+"fail:"+key)
+	if err != nil {
+		return 0, err
+	}
+	if n == 1 {
+		if err := s.rds.ExpireCtx(ctx, s.prefix+"fail:"+key, redisx.TTL(window)); err != nil {
+			return 0, err
+		}
+	}
+	return int(n), nil
+}
+
+// Lock implements ThrottleStore.
+func (s *RedisThrottleStore) Lock(ctx context.Context, key string, d time.Duration) error {
+	if err := s.rds.SetexCtx(ctx, s.prefix+"lock:"+key, "1", redisx.TTL(d)); err != nil {
+		return err
+	}
+	_, err := s.rds.DelCtx(ctx, s.prefix+"fail:"+key)
+	return err
+}
+
+// Locked implements ThrottleStore.
+func (s *RedisThrottleStore) Locked(ctx context.Context, key string) (bool, error) {
+	return s.rds.ExistsCtx(ctx, s.prefix+"lock:"+key)
+}
+
+// Reset implements ThrottleStore.
+func (s *RedisThrottleStore) Reset(ctx context.Context, key string) error {
+	_, err := s.rds.DelCtx(ctx, s.prefix+"fail:"+key)
+	return err
+}
@@ -0,0 +1,45 @@
+package password
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryThrottleStoreSweep(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryThrottleStore()
+	if _, err := s.Incr(ctx, "expired-counter", time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Lock(ctx, "expired-lock", time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Lock(ctx, "active-lock", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	// Keys that are never looked up again are purged by the next sweep.
+	s.mu.Lock()
+	s.lastSweep = time.Now().Add(-sweepInterval)
+	s.mu.Unlock()
+	if _, err := s.Incr(ctx, "active-counter", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.counters["expired-counter"]; ok {
+		t.Error("expired counter was not swept")
+	}
+	if _, ok := s.locks["expired-lock"]; ok {
+		t.Error("expired lock was not swept")
+	}
+	if _, ok := s.counters["active-counter"]; !ok {
+		t.Error("active counter was swept")
+	}
+	if _, ok := s.locks["active-lock"]; !ok {
+		t.Error("active lock was swept")
+	}
+}
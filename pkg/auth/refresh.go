@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/redis"
+
+	"github.com/addls/go-base/pkg/internal/redisx"
+)
+
+// RefreshToken is the stored state of an issued refresh token.
+type RefreshToken struct {
+	FamilyID  string    `json:"fid"`
+	Identity  Identity  `json:"identity"`
+	ExpiresAt time.Time `json:"exp"`
+}
+
+// RefreshStore stores refresh tokens by key (the token hash) for rotation and reuse detection.
+type RefreshStore interface {
+	// Save stores a refresh token until its expiration.
+	Save(ctx context.Context, key string, token RefreshToken) error
+	// Get returns the refresh token, or nil if it does not exist.
+	Get(ctx context.Context, key string) (*RefreshToken, error)
+	// Consume atomically marks the refresh token as used. It returns the token (nil if it does not exist)
+	// and whether it had already been used before.
+	Consume(ctx context.Context, key string) (*RefreshToken, bool, error)
+	// RevokeFamily revokes every refresh token of a family; the revocation is kept for ttl.
+	RevokeFamily(ctx context.Context, familyID string, ttl time.Duration) error
+	// IsFamilyRevoked reports whether a family has been revoked.
+	IsFamilyRevoked(ctx context.Context, familyID string) (bool, error)
+}
+
+// MustNewRefreshStore creates a refresh token store from config, panics on error.
+func MustNewRefreshStore(c IssuerConf) RefreshStore {
+	if c.Store == StoreRedis {
+		return NewRedisRefreshStore(redis.MustNewRedis(c.Redis), c.KeyPrefix)
+	}
+	return NewMemoryRefreshStore()
+}
+
+// ----- In-memory store -----
+
+type refreshEntry struct {
+	token RefreshToken
+	used  bool
+}
+
+// MemoryRefreshStore is an in-memory refresh token store (single instance only).
+type MemoryRefreshStore struct {
+	mu        sync.Mutex
+	tokens    map[string]*refreshEntry
+	families  map[string]time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryRefreshStore creates an in-memory refresh token store.
+func NewMemoryRefreshStore() *MemoryRefreshStore {
+	return &MemoryRefreshStore{
+		tokens:    make(map[string]*refreshEntry),
+		families:  make(map[string]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+// Save implements RefreshStore.
+func (s *MemoryRefreshStore) Save(_ context.Context, key string, token RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweepLocked()
+	s.tokens[key] = &refreshEntry{token: token}
+	return nil
+}
+
+// Get implements RefreshStore.
+func (s *MemoryRefreshStore) Get(_ context.Context, key string) (*RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.tokens[key]
+	if !ok {
+		return nil, nil
+	}
+	token := e.token
+	return &token, nil
+}
+
+// Consume implements RefreshStore.
+func (s *MemoryRefreshStore) Consume(_ context.Context, key string) (*RefreshToken, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.tokens[key]
+	if !ok {
+		return nil, false, nil
+	}
+	reused := e.used
+	e.used = true
+	token := e.token
+	return &token, reused, nil
+}
+
+// RevokeFamily implements RefreshStore.
+func (s *MemoryRefreshStore) RevokeFamily(_ context.Context, familyID string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.families[familyID] = time.Now().Add(ttl)
+	return nil
+}
+
+// IsFamilyRevoked implements RefreshStore.
+func (s *MemoryRefreshStore) IsFamilyRevoked(_ context.Context, familyID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	exp, ok := s.families[familyID]
+	return ok && time.Now().Before(exp), nil
+}
+
+func (s *MemoryRefreshStore) sweepLocked() {
+	now := time.Now()
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, e := range s.tokens {
+		if !now.Before(e.token.ExpiresAt) {
+			delete(s.tokens, key)
+		}
+	}
+	for fid, exp := range s.families {
+		if !now.Before(exp) {
+			delete(s.families, fid)
+		}
+	}
+}
+
+// ----- Redis store -----
+
+// RedisRefreshStore is a Redis-backed refresh token store shared by all instances of a cluster.
+type RedisRefreshStore struct {
+	rds    *redis.Redis
+	prefix string
+}
+
+// NewRedisRefreshStore creates a Redis-backed refresh token store.
+func NewRedisRefreshStore(rds *redis.Redis, keyPrefix string) *RedisRefreshStore {
+	return &RedisRefreshStore{
+		rds:    rds,
+		prefix: keyPrefix,
+	}
+}
+
+// Save implements RefreshStore.
+func (s *RedisRefreshStore) Save(ctx context.Context, key string, token RefreshToken) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return s.rds.SetexCtx(ctx, s.prefix+"rt:"+key, string(data), redisx.TTL(time.Until(token.ExpiresAt)))
+}
+
+// Get implements RefreshStore.
+func (s *RedisRefreshStore) Get(ctx context.Context, key string) (*RefreshToken, error) {
+	val, err := s.rds.GetCtx(ctx, s.prefix+"rt:"+key)
+	if err != nil || val == "" {
+		return nil, err
+	}
+	var token RefreshToken
+	if err := json.Unmarshal([]byte(val), &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Consume implements RefreshStore.
+func (s *RedisRefreshStore) Consume(ctx context.Context, key string) (*RefreshToken, bool, error) {
+	token, err := s.Get(ctx, key)
+	if err != nil || token == nil {
+		return nil, false, err
+	}
+	// SETNX on the "used" marker is atomic: only the first consumer wins.
+	first, err := s.rds.SetnxExCtx(ctx, s.prefix+"used:"+key, "1", redisx.TTL(time.Until(token.ExpiresAt)))
+	if err != nil {
+		return nil, false, err
+	}
+	return token, !first, nil
+}
+
+// RevokeFamily implements RefreshStore.
+func (s *RedisRefreshStore) RevokeFamily(ctx context.Context, familyID string, ttl time.Duration) error {
+	return s.rds.SetexCtx(ctx, s.prefix+"family:"+familyID, "1", redisx.TTL(ttl))
+}
+
+// IsFamilyRevoked implements RefreshStore.
+func (s *RedisRefreshStore) IsFamilyRevoked(ctx context.Context, familyID string) (bool, error) {
+	return s.rds.ExistsCtx(ctx, s.prefix+"family:"+familyID)
+}
@@ -0,0 +1,232 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/redis"
+
+	"github.com/addls/go-base/pkg/internal/redisx"
+)
+
+// Store types (revocation and refresh token stores).
+const (
+	StoreMemory = "memory" // In-process, single instance only
+	StoreRedis  = "redis"  // Shared by all instances of a cluster
+)
+
+// ErrRevocationDisabled is returned by Revoke and RevokeUser when no revocation store is set.
+var ErrRevocationDisabled = errors.New("token revocation is not enabled")
+
+// DefaultRevokeTTL is how long revocation entries are kept when no TTL is given (e.g. AccessExpire
+// is not configured). It should be at least the maximum access token lifetime.
+const DefaultRevokeTTL = 7 * 24 * time.Hour
+
+// RevocationConf token revocation configuration.
+type RevocationConf struct {
+	Enabled    bool            `json:",optional"`
+	Store      string          `json:",default=memory,options=memory|redis"` // memory (single instance) or redis (cluster)
+	Redis      redis.RedisConf `json:",optional"`                            // Required when Store is redis
+	KeyPrefix  string          `json:",default=gobase:revoke:"`              // Redis key prefix
+	AdminPath  string          `json:",optional"`                            // Gateway only: admin revoke endpoint path (e.g. /admin/auth/revoke)
+	AdminKey   string          `json:",optional"`                            // Gateway only: value required in the X-Admin-Key header
+	FailClosed bool            `json:",optional"`                            // Reject requests while the store is unavailable (default: let them through)
+}
+
+// RevocationStore is a denylist of revoked tokens.
+// Tokens are revoked individually by jti, or per user by an "issued up to" cutoff.
+type RevocationStore interface {
+	// RevokeToken revokes a single token by jti until expiresAt (usually the token's exp).
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	// RevokeUser revokes all tokens of the user issued up to the given time (to the second, like iat);
+	// the cutoff is kept for ttl.
+	RevokeUser(ctx context.Context, userID string, before time.Time, ttl time.Duration) error
+	// IsRevoked reports whether a token (jti, owner and issued-at time) has been revoked.
+	IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error)
+}
+
+var (
+	revocationStore   RevocationStore
+	revocationStoreMu sync.RWMutex
+)
+
+// SetRevocationStore sets the default revocation store used by Revoke and RevokeUser.
+// bootstrap sets it automatically when revocation is enabled in config.
+func SetRevocationStore(store RevocationStore) {
+	revocationStoreMu.Lock()
+	defer revocationStoreMu.Unlock()
+	revocationStore = store
+}
+
+// GetRevocationStore returns the default revocation store (nil if revocation is disabled).
+func GetRevocationStore() RevocationStore {
+	revocationStoreMu.RLock()
+	defer revocationStoreMu.RUnlock()
+	return revocationStore
+}
+
+// Revoke revokes a token by jti until expiresAt using the default revocation store.
+func Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	store := GetRevocationStore()
+	if store == nil {
+		return ErrRevocationDisabled
+	}
+	return store.RevokeToken(ctx, jti, expiresAt)
+}
+
+// RevokeUser revokes all tokens of the user issued until now using the default revocation store.
+// ttl should be at least the access token lifetime; zero uses a 7-day default.
+func RevokeUser(ctx context.Context, userID string, ttl time.Duration) error {
+	store := GetRevocationStore()
+	if store == nil {
+		return ErrRevocationDisabled
+	}
+	if ttl <= 0 {
+		ttl = DefaultRevokeTTL
+	}
+	return store.RevokeUser(ctx, userID, time.Now(), ttl)
+}
+
+// MustNewRevocationStore creates a revocation store from config, panics on error.
+func MustNewRevocationStore(c RevocationConf) RevocationStore {
+	if c.Store == StoreRedis {
+		return NewRedisRevocationStore(redis.MustNewRedis(c.Redis), c.KeyPrefix)
+	}
+	return NewMemoryRevocationStore()
+}
+
+// ----- In-memory store -----
+
+// sweepInterval controls how often expired entries are purged from the in-memory store.
+const sweepInterval = time.Minute
+
+type userCutoff struct {
+	before    time.Time
+	expiresAt time.Time
+}
+
+// MemoryRevocationStore is an in-memory revocation store with TTL (single instance only).
+type MemoryRevocationStore struct {
+	mu        sync.RWMutex
+	tokens    map[string]time.Time
+	users     map[string]userCutoff
+	lastSweep time.Time
+}
+
+// NewMemoryRevocationStore creates an in-memory revocation store.
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		tokens:    make(map[string]time.Time),
+		users:     make(map[string]userCutoff),
+		lastSweep: time.Now(),
+	}
+}
+
+// RevokeToken implements RevocationStore.
+func (s *MemoryRevocationStore) RevokeToken(_ context.Context, jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweepLocked()
+	s.tokens[jti] = expiresAt
+	return nil
+}
+
+// RevokeUser implements RevocationStore.
+func (s *MemoryRevocationStore) RevokeUser(_ context.Context, userID string, before time.Time, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweepLocked()
+	s.users[userID] = userCutoff{
+		before:    before.Truncate(time.Second),
+		expiresAt: time.Now().Add(ttl),
+	}
+	return nil
+}
+
+// IsRevoked implements RevocationStore.
+func (s *MemoryRevocationStore) IsRevoked(_ context.Context, jti, userID string, issuedAt time.Time) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	if jti != "" {
+		if exp, ok := s.tokens[jti]; ok && now.Before(exp) {
+			return true, nil
+		}
+	}
+	if userID != "" {
+		if c, ok := s.users[userID]; ok && now.Before(c.expiresAt) && !issuedAt.After(c.before) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *MemoryRevocationStore) sweepLocked() {
+	now := time.Now()
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for jti, exp := range s.tokens {
+		if !now.Before(exp) {
+			delete(s.tokens, jti)
+		}
+	}
+	for uid, c := range s.users {
+		if !now.Before(c.expiresAt) {
+			delete(s.users, uid)
+		}
+	}
+}
+
+// ----- Redis store -----
+
+// RedisRevocationStore is a Redis-backed revocation store shared by all instances of a cluster.
+type RedisRevocationStore struct {
+	rds    *redis.Redis
+	prefix string
+}
+
+// NewRedisRevocationStore creates a Redis-backed revocation store.
+func NewRedisRevocationStore(rds *redis.Redis, keyPrefix string) *RedisRevocationStore {
+	return &RedisRevocationStore{
+		rds:    rds,
+		prefix: keyPrefix,
+	}
+}
+
+// RevokeToken implements RevocationStore.
+func (s *RedisRevocationStore) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	return s.rds.SetexCtx(ctx, s.prefix+"jti:"+jti, "1", redisx.TTL(time.Until(expiresAt)))
+}
+
+// RevokeUser implements RevocationStore.
+func (s *RedisRevocationStore) RevokeUser(ctx context.Context, userID string, before time.Time, ttl time.Duration) error {
+	return s.rds.SetexCtx(ctx, s.prefix+"user:"+userID, strconv.FormatInt(before.Unix(), 10), redisx.TTL(ttl))
+}
+
+// IsRevoked implements RevocationStore.
+func (s *RedisRevocationStore) IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error) {
+	if jti != "" {
+		revoked, err := s.rds.ExistsCtx(ctx, s.prefix+"jti:"+jti)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+	if userID != "" {
+		val, err := s.rds.GetCtx(ctx, s.prefix+"user:"+userID)
+		if err != nil || val == "" {
+			return false, err
+		}
+		before, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return false, err
+		}
+		return !issuedAt.After(time.Unix(before, 0)), nil
+	}
+	return false, nil
+}
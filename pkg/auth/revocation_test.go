@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryRevocationStoreUserCutoff(t *testing.T) {
+	cutoff := time.Unix(1700000000, 0).Add(400 * time.Millisecond)
+	tests := []struct {
+		name     string
+		issuedAt time.Time
+		revoked  bool
+	}{
+		{name: "issued before the cutoff", issuedAt: cutoff.Add(-time.Hour), revoked: true},
+		{name: "issued in the same second", issuedAt: time.Unix(cutoff.Unix(), 0), revoked: true},
+		{name: "issued the next second", issuedAt: time.Unix(cutoff.Unix()+1, 0), revoked: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMemoryRevocationStore()
+			if err := s.RevokeUser(context.Background(), "alice", cutoff, time.Hour); err != nil {
+				t.Fatal(err)
+			}
+			revoked, err := s.IsRevoked(context.Background(), "", "alice", tt.issuedAt)
+			if err != nil || revoked != tt.revoked {
+				t.Errorf("IsRevoked = %v, %v; want %v", revoked, err, tt.revoked)
+			}
+			if revoked, _ := s.IsRevoked(context.Background(), "", "bob", tt.issuedAt); revoked {
+				t.Error("another user is revoked")
+			}
+		})
+	}
+}
+
+func TestRevokeUserDefaultTTL(t *testing.T) {
+	SetRevocationStore(NewMemoryRevocationStore())
+	defer SetRevocationStore(nil)
+
+	// Without AccessExpire the TTL is zero: the cutoff must still be kept.
+	if err := RevokeUser(context.Background(), "alice", 0); err != nil {
+		t.Fatal(err)
+	}
+	revoked, err := GetRevocationStore().IsRevoked(context.Background(), "", "alice", time.Now().Add(-time.Minute))
+	if err != nil || !revoked {
+		t.Errorf("IsRevoked = %v, %v; want true", revoked, err)
+	}
+}
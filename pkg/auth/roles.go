@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/addls/go-base/pkg/errcode"
+)
+
+// Role and scope claim names.
+const (
+	ClaimRoles  = "roles"  // Array of role names (or a comma/space separated string)
+	ClaimScope  = "scope"  // OAuth 2.0 style space separated scopes
+	ClaimScopes = "scopes" // Array of scopes
+)
+
+// Role and scope headers forwarded by the gateway (comma separated).
+const (
+	// JwtRolesHeader HTTP header name used to pass through the JWT roles.
+	JwtRolesHeader = "x-jwt-roles"
+	// JwtScopesHeader HTTP header name used to pass through the JWT scopes.
+	JwtScopesHeader = "x-jwt-scopes"
+)
+
+// RolesFromClaims extracts the roles from JWT claims.
+func RolesFromClaims(claims jwt.MapClaims) []string {
+	return claimList(claims[ClaimRoles])
+}
+
+// ScopesFromClaims extracts the scopes from JWT claims ("scope" string or "scopes" array).
+func ScopesFromClaims(claims jwt.MapClaims) []string {
+	scopes := claimList(claims[ClaimScope])
+	return append(scopes, claimList(claims[ClaimScopes])...)
+}
+
+// GetRoles extracts the caller roles from context (unified API, works for HTTP or gRPC).
+func GetRoles(ctx context.Context) []string {
+	return splitList(getIdentity(ctx, JwtRolesHeader))
+}
+
+// GetScopes extracts the caller scopes from context (unified API, works for HTTP or gRPC).
+func GetScopes(ctx context.Context) []string {
+	return splitList(getIdentity(ctx, JwtScopesHeader))
+}
+
+// HasRole reports whether the caller has any of the given roles.
+func HasRole(ctx context.Context, roles ...string) bool {
+	return containsAny(GetRoles(ctx), roles)
+}
+
+// HasScope reports whether the caller has all of the given scopes.
+func HasScope(ctx context.Context, scopes ...string) bool {
+	return containsAll(GetScopes(ctx), scopes)
+}
+
+// RequireRole returns errcode.ErrPermissionDenied unless the caller has any of the given roles
+// (errcode.ErrUnauthorized for anonymous callers).
+func RequireRole(ctx context.Context, roles ...string) error {
+	if GetUserID(ctx) == "" {
+		return errcode.ErrUnauthorized
+	}
+	if !HasRole(ctx, roles...) {
+		return errcode.ErrPermissionDenied
+	}
+	return nil
+}
+
+// RequireScope returns errcode.ErrPermissionDenied unless the caller has all of the given scopes
+// (errcode.ErrUnauthorized for anonymous callers).
+func RequireScope(ctx context.Context, scopes ...string) error {
+	if GetUserID(ctx) == "" {
+		return errcode.ErrUnauthorized
+	}
+	if !HasScope(ctx, scopes...) {
+		return errcode.ErrPermissionDenied
+	}
+	return nil
+}
+
+// JoinList joins roles or scopes into a forwarded header value.
+func JoinList(values []string) string {
+	return strings.Join(values, ",")
+}
+
+// claimList converts an array or a comma/space separated string claim into a list.
+func claimList(v interface{}) []string {
+	switch t := v.(type) {
+	case string:
+		return splitList(t)
+	case []string:
+		return t
+	case []interface{}:
+		list := make([]string, 0, len(t))
+		for _, item := range t {
+			if s, ok := item.(string); ok && s != "" {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+func splitList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+}
+
+func containsAny(have, want []string) bool {
+	for _, w := range want {
+		for _, h := range have {
+			if h == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsAll(have, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if h == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/addls/go-base/pkg/errcode"
+)
+
+// Identity signature headers, set by the gateway next to the forwarded x-jwt-* identity.
+const (
+	// JwtTimestampHeader signing time of the forwarded identity (unix seconds).
+	JwtTimestampHeader = "x-jwt-timestamp"
+	// JwtSignatureHeader base64 signature over the forwarded identity and timestamp.
+	JwtSignatureHeader = "x-jwt-signature"
+)
+
+// IdentityPrefix is the key prefix of identity fields forwarded by the gateway.
+const IdentityPrefix = "x-jwt-"
+
+// Identity signature algorithms.
+const (
+	SignAlgHmac    = "hmac"    // HMAC-SHA256 with a shared secret
+	SignAlgEd25519 = "ed25519" // Ed25519: the gateway holds the private key, backends the public key
+)
+
+// IdentitySignConf identity signature configuration.
+// The gateway signs the forwarded identity; backends verify it and reject unsigned or stale identities.
+type IdentitySignConf struct {
+	Enabled    bool          `json:",optional"`
+	Algorithm  string        `json:",default=hmac,options=hmac|ed25519"`
+	Secret     string        `json:",optional"`   // HMAC shared secret
+	PrivateKey string        `json:",optional"`   // Ed25519 private key or seed, base64 (gateway)
+	PublicKey  string        `json:",optional"`   // Ed25519 public key, base64 (backends)
+	MaxAge     time.Duration `json:",default=5m"` // Maximum age of a signed identity
+}
+
+// IdentitySigner signs and verifies forwarded identities.
+type IdentitySigner struct {
+	alg    string
+	secret []byte
+	priv   ed25519.PrivateKey
+	pub    ed25519.PublicKey
+	maxAge time.Duration
+}
+
+// NewIdentitySigner creates an IdentitySigner from config.
+func NewIdentitySigner(c IdentitySignConf) (*IdentitySigner, error) {
+	s := &IdentitySigner{
+		alg:    c.Algorithm,
+		maxAge: c.MaxAge,
+	}
+
+	switch c.Algorithm {
+	case SignAlgEd25519:
+		if c.PrivateKey != "" {
+			key, err := base64.StdEncoding.DecodeString(c.PrivateKey)
+			if err != nil {
+				return nil, fmt.Errorf("auth: invalid ed25519 private key: %w", err)
+			}
+			switch len(key) {
+			case ed25519.SeedSize:
+				s.priv = ed25519.NewKeyFromSeed(key)
+			case ed25519.PrivateKeySize:
+				s.priv = key
+			default:
+				return nil, fmt.Errorf("auth: invalid ed25519 private key size %d", len(key))
+			}
+			s.pub = s.priv.Public().(ed25519.PublicKey)
+		}
+		if c.PublicKey != "" {
+			key, err := base64.StdEncoding.DecodeString(c.PublicKey)
+			if err != nil || len(key) != ed25519.PublicKeySize {
+				return nil, fmt.Errorf("auth: invalid ed25519 public key")
+			}
+			s.pub = key
+		}
+		if s.pub == nil {
+			return nil, fmt.Errorf("auth: ed25519 identity signing requires PrivateKey or PublicKey")
+		}
+	default:
+		if c.Secret == "" {
+			return nil, fmt.Errorf("auth: hmac identity signing requires Secret")
+		}
+		s.alg = SignAlgHmac
+		s.secret = []byte(c.Secret)
+	}
+
+	return s, nil
+}
+
+// MustNewIdentitySigner creates an IdentitySigner from config, panics on error.
+func MustNewIdentitySigner(c IdentitySignConf) *IdentitySigner {
+	s, err := NewIdentitySigner(c)
+	logx.Must(err)
+	return s
+}
+
+// Sign signs the identity fields (x-jwt-* keys) at the given time and returns the timestamp and signature.
+func (s *IdentitySigner) Sign(fields map[string]string, now time.Time) (string, string, error) {
+	ts := strconv.FormatInt(now.Unix(), 10)
+	payload := canonicalIdentity(fields, ts)
+
+	switch s.alg {
+	case SignAlgEd25519:
+		if s.priv == nil {
+			return "", "", fmt.Errorf("auth: ed25519 private key is not configured")
+		}
+		return ts, base64.StdEncoding.EncodeToString(ed25519.Sign(s.priv, payload)), nil
+	default:
+		return ts, base64.StdEncoding.EncodeToString(s.hmac(payload)), nil
+	}
+}
+
+// Verify verifies the signature over the identity fields.
+// It returns errcode.ErrIdentitySignature for missing or invalid signatures,
+// and errcode.ErrIdentityExpired for signatures older than MaxAge.
+func (s *IdentitySigner) Verify(fields map[string]string, timestamp, signature string) error {
+	if timestamp == "" || signature == "" {
+		return errcode.ErrIdentitySignature
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return errcode.ErrIdentitySignature
+	}
+
+	payload := canonicalIdentity(fields, timestamp)
+	switch s.alg {
+	case SignAlgEd25519:
+		if !ed25519.Verify(s.pub, payload, sig) {
+			return errcode.ErrIdentitySignature
+		}
+	default:
+		if !hmac.Equal(sig, s.hmac(payload)) {
+			return errcode.ErrIdentitySignature
+		}
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errcode.ErrIdentitySignature
+	}
+	if age := time.Since(time.Unix(ts, 0)); s.maxAge > 0 && (age > s.maxAge || age < -s.maxAge) {
+		return errcode.ErrIdentityExpired
+	}
+	return nil
+}
+
+// VerifyContext verifies the signed identity in the incoming gRPC metadata.
+// It returns nil when the call carries no identity (anonymous call).
+func (s *IdentitySigner) VerifyContext(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	fields := make(map[string]string)
+	for key, values := range md {
+		if len(values) == 0 {
+			continue
+		}
+		// Metadata forwarded by the go-zero gateway carries the "gateway-" prefix.
+		if err := CollectIdentityField(fields, strings.TrimPrefix(key, "gateway-"), values[0]); err != nil {
+			return err
+		}
+	}
+	return s.VerifyFields(fields)
+}
+
+// VerifyFields verifies identity fields collected from headers or metadata, including the
+// timestamp and signature fields. It returns nil when there is no identity (anonymous request).
+func (s *IdentitySigner) VerifyFields(fields map[string]string) error {
+	ts, sig := fields[JwtTimestampHeader], fields[JwtSignatureHeader]
+	delete(fields, JwtTimestampHeader)
+	delete(fields, JwtSignatureHeader)
+	if len(fields) == 0 {
+		return nil
+	}
+	return s.Verify(fields, ts, sig)
+}
+
+// IsIdentityKey reports whether a (lowercase, unprefixed) header or metadata key is a forwarded identity field.
+func IsIdentityKey(key string) bool {
+	return strings.HasPrefix(key, IdentityPrefix)
+}
+
+// CollectIdentityField adds an identity field collected from headers or metadata (non-identity keys are ignored).
+// The same field seen twice with different values (e.g. with and without a prefix) is rejected.
+func CollectIdentityField(fields map[string]string, key, value string) error {
+	if !IsIdentityKey(key) {
+		return nil
+	}
+	if prev, ok := fields[key]; ok && prev != value {
+		return errcode.ErrIdentitySignature
+	}
+	fields[key] = value
+	return nil
+}
+
+func (s *IdentitySigner) hmac(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// canonicalIdentity builds the signed payload: version, timestamp and sorted key=value lines.
+func canonicalIdentity(fields map[string]string, timestamp string) []byte {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		if k != JwtTimestampHeader && k != JwtSignatureHeader {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("v1\n")
+	b.WriteString(timestamp)
+	for _, k := range keys {
+		b.WriteString("\n")
+		b.WriteString(k)
+		b.WriteString("=")
+		b.WriteString(fields[k])
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/addls/go-base/pkg/errcode"
+)
+
+func TestIdentitySigner(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	priv := ed25519.NewKeyFromSeed(seed)
+	pub := priv.Public().(ed25519.PublicKey)
+
+	signers := map[string][2]IdentitySignConf{
+		SignAlgHmac: {
+			{Algorithm: SignAlgHmac, Secret: "identity-secret", MaxAge: time.Minute},
+			{Algorithm: SignAlgHmac, Secret: "identity-secret", MaxAge: time.Minute},
+		},
+		SignAlgEd25519: {
+			{Algorithm: SignAlgEd25519, PrivateKey: base64.StdEncoding.EncodeToString(seed), MaxAge: time.Minute},
+			{Algorithm: SignAlgEd25519, PublicKey: base64.StdEncoding.EncodeToString(pub), MaxAge: time.Minute},
+		},
+	}
+	identity := map[string]string{JwtUserIdHeader: "alice", JwtRolesHeader: "user"}
+
+	tests := []struct {
+		name    string
+		signAt  time.Time
+		tamper  func(fields map[string]string, ts, sig *string)
+		wantErr error
+	}{
+		{name: "valid", signAt: time.Now()},
+		{name: "tampered value", signAt: time.Now(), tamper: func(f map[string]string, _, _ *string) {
+			f[JwtRolesHeader] = "admin"
+		}, wantErr: errcode.ErrIdentitySignature},
+		{name: "added field", signAt: time.Now(), tamper: func(f map[string]string, _, _ *string) {
+			f[JwtScopesHeader] = "orders:write"
+		}, wantErr: errcode.ErrIdentitySignature},
+		{name: "removed field", signAt: time.Now(), tamper: func(f map[string]string, _, _ *string) {
+			delete(f, JwtRolesHeader)
+		}, wantErr: errcode.ErrIdentitySignature},
+		{name: "moved timestamp", signAt: time.Now(), tamper: func(_ map[string]string, ts, _ *string) {
+			*ts = "1"
+		}, wantErr: errcode.ErrIdentitySignature},
+		{name: "missing signature", signAt: time.Now(), tamper: func(_ map[string]string, _, sig *string) {
+			*sig = ""
+		}, wantErr: errcode.ErrIdentitySignature},
+		{name: "stale", signAt: time.Now().Add(-time.Hour), wantErr: errcode.ErrIdentityExpired},
+		{name: "from the future", signAt: time.Now().Add(time.Hour), wantErr: errcode.ErrIdentityExpired},
+	}
+	for alg, confs := range signers {
+		gateway, err := NewIdentitySigner(confs[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		backend, err := NewIdentitySigner(confs[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tt := range tests {
+			t.Run(alg+"/"+tt.name, func(t *testing.T) {
+				ts, sig, err := gateway.Sign(identity, tt.signAt)
+				if err != nil {
+					t.Fatal(err)
+				}
+				fields := make(map[string]string, len(identity))
+				for k, v := range identity {
+					fields[k] = v
+				}
+				if tt.tamper != nil {
+					tt.tamper(fields, &ts, &sig)
+				}
+				if err := backend.Verify(fields, ts, sig); !errors.Is(err, tt.wantErr) {
+					t.Errorf("Verify = %v, want %v", err, tt.wantErr)
+				}
+			})
+		}
+	}
+}
+
+func TestIdentitySignerVerifyFields(t *testing.T) {
+	s, err := NewIdentitySigner(IdentitySignConf{Algorithm: SignAlgHmac, Secret: "identity-secret", MaxAge: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.VerifyFields(map[string]string{}); err != nil {
+		t.Errorf("anonymous: VerifyFields = %v, want nil", err)
+	}
+	unsigned := map[string]string{JwtUserIdHeader: "alice"}
+	if err := s.VerifyFields(unsigned); !errors.Is(err, errcode.ErrIdentitySignature) {
+		t.Errorf("unsigned: VerifyFields = %v, want ErrIdentitySignature", err)
+	}
+}
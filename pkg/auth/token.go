@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/golang-jwt/jwt/v4/request"
+	"github.com/zeromicro/go-zero/core/metric"
+
+	"github.com/addls/go-base/pkg/errcode"
+)
+
+// Token failure reasons (used in error classification, WWW-Authenticate and metrics).
+const (
+	TokenReasonMissing = "missing"
+	TokenReasonExpired = "expired"
+	TokenReasonInvalid = "invalid"
+	TokenReasonRevoked = "revoked"
+)
+
+var metricTokenFailures = metric.NewCounterVec(&metric.CounterVecOpts{
+	Namespace: "gobase",
+	Subsystem: "auth",
+	Name:      "token_failures_total",
+	Help:      "JWT verification failures by reason.",
+	Labels:    []string{"reason"},
+})
+
+// ClassifyTokenError classifies a JWT verification error into a failure reason.
+func ClassifyTokenError(err error) string {
+	switch {
+	case errors.Is(err, request.ErrNoTokenInRequest):
+		return TokenReasonMissing
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return TokenReasonExpired
+	case errcode.IsError(err, errcode.ErrTokenRevoked):
+		return TokenReasonRevoked
+	default:
+		return TokenReasonInvalid
+	}
+}
+
+// TokenErrorCode returns the error code for a token failure reason.
+func TokenErrorCode(reason string) *errcode.Error {
+	switch reason {
+	case TokenReasonMissing:
+		return errcode.ErrTokenMissing
+	case TokenReasonExpired:
+		return errcode.ErrTokenExpired
+	case TokenReasonRevoked:
+		return errcode.ErrTokenRevoked
+	default:
+		return errcode.ErrTokenInvalid
+	}
+}
+
+// WWWAuthenticate returns the WWW-Authenticate header value (RFC 6750) for a token failure reason.
+// A missing token carries no error attribute, as the client simply did not authenticate.
+func WWWAuthenticate(reason string) string {
+	if reason == TokenReasonMissing {
+		return "Bearer"
+	}
+	return fmt.Sprintf(`Bearer error="invalid_token", error_description=%q`, TokenErrorCode(reason).Msg)
+}
+
+// ReportTokenFailure records a JWT verification failure in metrics.
+func ReportTokenFailure(reason string) {
+	metricTokenFailures.Inc(reason)
+}
@@ -0,0 +1,163 @@
+// Package authz provides role and scope based authorization policies for HTTP routes and gRPC methods.
+package authz
+
+import (
+	"context"
+	"strings"
+
+	"github.com/zeromicro/go-zero/core/conf"
+
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/errcode"
+	"github.com/addls/go-base/pkg/pathmatch"
+)
+
+// Rule effects.
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// PolicyConf authorization policy (usually loaded from a policy file).
+//
+// Evaluation:
+//  1. Deny rules: if any matching deny rule applies to the caller, the request is rejected.
+//     A deny rule applies to callers having any of its Roles or all of its Scopes,
+//     or to every caller when it lists neither.
+//  2. Allow rules: the first matching allow rule decides. The caller must have any of its Roles
+//     (if set) and all of its Scopes (if set); a rule with neither only requires authentication,
+//     unless Public is set.
+//  3. No matching allow rule: Default decides (allow or deny).
+type PolicyConf struct {
+	Default string       `json:",default=allow,options=allow|deny"`
+	Rules   []PolicyRule `json:",optional"`
+}
+
+// PolicyRule maps HTTP routes or gRPC methods to required roles and scopes.
+type PolicyRule struct {
+	Path    string   `json:",optional"` // HTTP path pattern (see pathmatch.Pattern), e.g. /admin/**
+	Methods []string `json:",optional"` // HTTP methods; empty means all methods
+	Rpc     string   `json:",optional"` // gRPC full method pattern, e.g. /user.UserService/*
+	Roles   []string `json:",optional"` // Any of these roles
+	Scopes  []string `json:",optional"` // All of these scopes
+	Public  bool     `json:",optional"` // Allow rules only: also allow anonymous callers
+	Effect  string   `json:",default=allow,options=allow|deny"`
+}
+
+type compiledRule struct {
+	PolicyRule
+	path    *pathmatch.Pattern
+	rpc     *pathmatch.Pattern
+	methods map[string]bool
+}
+
+// Policy is a compiled authorization policy.
+type Policy struct {
+	defaultDeny bool
+	rules       []compiledRule
+}
+
+// NewPolicy compiles a policy.
+func NewPolicy(c PolicyConf) *Policy {
+	p := &Policy{defaultDeny: c.Default == EffectDeny}
+	for _, rule := range c.Rules {
+		cr := compiledRule{PolicyRule: rule}
+		if rule.Path != "" {
+			pattern := pathmatch.Compile(rule.Path)
+			cr.path = &pattern
+		}
+		if rule.Rpc != "" {
+			pattern := pathmatch.Compile(rule.Rpc)
+			cr.rpc = &pattern
+		}
+		if len(rule.Methods) > 0 {
+			cr.methods = make(map[string]bool, len(rule.Methods))
+			for _, m := range rule.Methods {
+				cr.methods[strings.ToUpper(m)] = true
+			}
+		}
+		p.rules = append(p.rules, cr)
+	}
+	return p
+}
+
+// MustLoadPolicy loads and compiles a policy file (yaml/json/toml), panics on error.
+func MustLoadPolicy(file string) *Policy {
+	var c PolicyConf
+	conf.MustLoad(file, &c)
+	return NewPolicy(c)
+}
+
+// AuthorizeHTTP authorizes an HTTP request by method and path for the caller in ctx.
+func (p *Policy) AuthorizeHTTP(ctx context.Context, method, path string) error {
+	return p.authorize(ctx, func(r *compiledRule) bool {
+		if r.path == nil || (r.methods != nil && !r.methods[method]) {
+			return false
+		}
+		return r.path.Match(path)
+	})
+}
+
+// AuthorizeRPC authorizes a gRPC call by full method name (/package.Service/Method) for the caller in ctx.
+func (p *Policy) AuthorizeRPC(ctx context.Context, fullMethod string) error {
+	return p.authorize(ctx, func(r *compiledRule) bool {
+		return r.rpc != nil && r.rpc.Match(fullMethod)
+	})
+}
+
+func (p *Policy) authorize(ctx context.Context, match func(*compiledRule) bool) error {
+	authenticated := auth.GetUserID(ctx) != ""
+
+	var allow *compiledRule
+	for i := range p.rules {
+		r := &p.rules[i]
+		if !match(r) {
+			continue
+		}
+		if r.Effect == EffectDeny {
+			if r.appliesTo(ctx) {
+				return denied(authenticated)
+			}
+			continue
+		}
+		if allow == nil {
+			allow = r
+		}
+	}
+
+	if allow == nil {
+		if p.defaultDeny {
+			return denied(authenticated)
+		}
+		return nil
+	}
+	if allow.Public && len(allow.Roles) == 0 && len(allow.Scopes) == 0 {
+		return nil
+	}
+	if !authenticated {
+		return errcode.ErrUnauthorized
+	}
+	if len(allow.Roles) > 0 && !auth.HasRole(ctx, allow.Roles...) {
+		return errcode.ErrPermissionDenied
+	}
+	if len(allow.Scopes) > 0 && !auth.HasScope(ctx, allow.Scopes...) {
+		return errcode.ErrPermissionDenied
+	}
+	return nil
+}
+
+// appliesTo reports whether a deny rule applies to the caller.
+func (r *compiledRule) appliesTo(ctx context.Context) bool {
+	if len(r.Roles) == 0 && len(r.Scopes) == 0 {
+		return true
+	}
+	return (len(r.Roles) > 0 && auth.HasRole(ctx, r.Roles...)) ||
+		(len(r.Scopes) > 0 && auth.HasScope(ctx, r.Scopes...))
+}
+
+func denied(authenticated bool) error {
+	if !authenticated {
+		return errcode.ErrUnauthorized
+	}
+	return errcode.ErrPermissionDenied
+}
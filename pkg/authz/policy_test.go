@@ -0,0 +1,99 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/errcode"
+)
+
+func caller(uid, roles, scopes string) context.Context {
+	md := metadata.MD{}
+	if uid != "" {
+		md.Set(auth.JwtUserIdHeader, uid)
+	}
+	if roles != "" {
+		md.Set(auth.JwtRolesHeader, roles)
+	}
+	if scopes != "" {
+		md.Set(auth.JwtScopesHeader, scopes)
+	}
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+func TestPolicyAuthorizeHTTP(t *testing.T) {
+	p := NewPolicy(PolicyConf{
+		Default: EffectDeny,
+		Rules: []PolicyRule{
+			{Path: "/admin/**", Roles: []string{"banned"}, Effect: EffectDeny},
+			{Path: "/admin/**", Roles: []string{"admin"}, Effect: EffectAllow},
+			{Path: "/orders", Methods: []string{"post"}, Scopes: []string{"orders:write"}, Effect: EffectAllow},
+			{Path: "/orders", Effect: EffectAllow},
+			{Path: "/health", Public: true, Effect: EffectAllow},
+		},
+	})
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		method string
+		path   string
+		want   error
+	}{
+		{name: "admin", ctx: caller("alice", "admin", ""), method: http.MethodGet, path: "/admin/users"},
+		{name: "admin denied by deny rule", ctx: caller("alice", "admin,banned", ""), method: http.MethodGet, path: "/admin/users",
+			want: errcode.ErrPermissionDenied},
+		{name: "missing role", ctx: caller("bob", "user", ""), method: http.MethodGet, path: "/admin/users", want: errcode.ErrPermissionDenied},
+		{name: "anonymous on protected route", ctx: caller("", "", ""), method: http.MethodGet, path: "/admin/users", want: errcode.ErrUnauthorized},
+		{name: "dot segments do not escape the rule", ctx: caller("bob", "user", ""), method: http.MethodGet, path: "/health/../admin/users",
+			want: errcode.ErrPermissionDenied},
+		{name: "method rule with scope", ctx: caller("bob", "", "orders:write"), method: http.MethodPost, path: "/orders"},
+		{name: "method rule without scope", ctx: caller("bob", "", "orders:read"), method: http.MethodPost, path: "/orders",
+			want: errcode.ErrPermissionDenied},
+		{name: "other method falls through", ctx: caller("bob", "", ""), method: http.MethodGet, path: "/orders"},
+		{name: "public", ctx: caller("", "", ""), method: http.MethodGet, path: "/health"},
+		{name: "default deny", ctx: caller("bob", "", ""), method: http.MethodGet, path: "/unknown", want: errcode.ErrPermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := p.AuthorizeHTTP(tt.ctx, tt.method, tt.path); !errors.Is(err, tt.want) {
+				t.Errorf("AuthorizeHTTP = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyAuthorizeRPC(t *testing.T) {
+	p := NewPolicy(PolicyConf{
+		Default: EffectAllow,
+		Rules: []PolicyRule{
+			{Rpc: "/user.UserService/Delete*", Roles: []string{"admin"}, Effect: EffectAllow},
+			{Rpc: "/user.UserService/*", Effect: EffectAllow},
+		},
+	})
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		method string
+		want   error
+	}{
+		{name: "admin", ctx: caller("alice", "admin", ""), method: "/user.UserService/DeleteUser"},
+		{name: "missing role", ctx: caller("bob", "user", ""), method: "/user.UserService/DeleteUser", want: errcode.ErrPermissionDenied},
+		{name: "authenticated", ctx: caller("bob", "", ""), method: "/user.UserService/GetUser"},
+		{name: "anonymous", ctx: caller("", "", ""), method: "/user.UserService/GetUser", want: errcode.ErrUnauthorized},
+		{name: "default allow", ctx: caller("", "", ""), method: "/order.OrderService/List"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := p.AuthorizeRPC(tt.ctx, tt.method); !errors.Is(err, tt.want) {
+				t.Errorf("AuthorizeRPC = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package bootstrap
+
+import (
+	"github.com/zeromicro/go-zero/zrpc"
+
+	"github.com/addls/go-base/pkg/interceptor"
+)
+
+// RpcClientConfig configuration for a downstream gRPC client (embeds zrpc.RpcClientConf).
+type RpcClientConfig struct {
+	zrpc.RpcClientConf
+
+	// Metadata keys of the incoming request propagated to downstream calls,
+	// in addition to the caller identity (x-jwt-*), e.g. x-request-id.
+	Propagate []string `json:",optional"`
+}
+
+// MustNewRpcClient creates a gRPC client that propagates the caller identity and
+// the allowlisted metadata to downstream calls, panics on error.
+func MustNewRpcClient(c RpcClientConfig, opts ...zrpc.ClientOption) zrpc.Client {
+	opts = append(opts,
+		zrpc.WithUnaryClientInterceptor(interceptor.PropagationUnaryClientInterceptor(c.Propagate...)),
+		zrpc.WithStreamClientInterceptor(interceptor.PropagationStreamClientInterceptor(c.Propagate...)),
+	)
+	return zrpc.MustNewClient(c.RpcClientConf, opts...)
+}
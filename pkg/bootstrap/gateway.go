@@ -2,13 +2,31 @@ package bootstrap
 
 import (
 	"flag"
+	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/zeromicro/go-zero/core/conf"
 	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/gateway"
+	"github.com/zeromicro/go-zero/rest"
+	"github.com/zeromicro/go-zero/rest/httpx"
 
+	"github.com/addls/go-base/pkg/accesslog"
+	"github.com/addls/go-base/pkg/audit"
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/auth/authhandler"
+	"github.com/addls/go-base/pkg/authz"
+	"github.com/addls/go-base/pkg/clientip"
+	"github.com/addls/go-base/pkg/compress"
 	"github.com/addls/go-base/pkg/config"
+	"github.com/addls/go-base/pkg/httpcache"
+	"github.com/addls/go-base/pkg/idempotency"
 	"github.com/addls/go-base/pkg/middleware"
+	"github.com/addls/go-base/pkg/oidc"
+	"github.com/addls/go-base/pkg/response"
+	"github.com/addls/go-base/pkg/signature"
+	"github.com/addls/go-base/pkg/tenant"
 )
 
 // GatewayConfig base configuration for the Gateway service (embeds gateway.GatewayConf).
@@ -17,15 +35,59 @@ type GatewayConfig struct {
 
 	// Auth configuration (optional).
 	Auth struct {
-		AccessSecret string   `json:",optional"` // JWT signing secret
-		AccessExpire int64    `json:",optional"` // Token expiration time in seconds (kept for consistency with API config)
-		SkipPaths    []string `json:",optional"` // Paths that skip JWT verification
+		AccessSecret string                `json:",optional"` // JWT signing secret
+		AccessExpire int64                 `json:",optional"` // Token expiration time in seconds (kept for consistency with API config)
+		SkipPaths    []string              `json:",optional"` // Path patterns that skip JWT verification
+		Rules        []middleware.JwtRule  `json:",optional"` // Per-route rules (path pattern, methods, skip/optional/required)
+		Revocation   auth.RevocationConf   `json:",optional"` // Token revocation denylist (by jti and user cutoff)
+		IdentitySign auth.IdentitySignConf `json:",optional"` // Signature over the forwarded x-jwt-* identity
+		Policy       string                `json:",optional"` // Role/scope authorization policy file (see authz.PolicyConf)
+		APIKey       auth.APIKeyConf       `json:",optional"` // API key authentication for machine clients
+		OIDC         oidc.Conf             `json:",optional"` // OpenID Connect login (relying party)
+		Issuers      []auth.JwtIssuerConf  `json:",optional"` // Named token issuers (AccessSecret, if set, is added as "default")
+		Upstreams    []UpstreamIssuers     `json:",optional"` // Accepted issuers per upstream (all routes of the upstream)
 	} `json:",optional"`
 
+	// HMAC request signature verification for partner requests (optional).
+	RequestSignature signature.Conf `json:",optional"`
+
+	// Idempotency-Key support for unsafe methods (optional).
+	Idempotency idempotency.Conf `json:",optional"`
+
+	// Response cache for GET routes (optional).
+	Cache httpcache.Conf `json:",optional"`
+
+	// Response compression negotiated by Accept-Encoding (optional).
+	Compress compress.Conf `json:",optional"`
+
+	// Client IP resolution behind trusted proxies, used by all middlewares (optional).
+	ClientIP clientip.Conf `json:",optional"`
+
+	// Client IP allow/deny lists per route (optional).
+	IPFilter middleware.IPFilterConf `json:",optional"`
+
+	// Security headers (HSTS, CSP, ...); applied by default when App.Env is prod.
+	SecurityHeaders middleware.SecurityHeadersConf `json:",optional"`
+
+	// Structured access log with body sampling and redaction (optional).
+	AccessLog accesslog.Conf `json:",optional"`
+
+	// Audit trail of state-changing operations (optional).
+	Audit audit.Conf `json:",optional"`
+
+	// Multi-tenancy: tenant resolution, forwarding and per-tenant rate limits (optional).
+	Tenant tenant.Conf `json:",optional"`
+
 	// Application configuration.
 	App config.AppConfig `json:",optional"`
 }
 
+// UpstreamIssuers accepted JWT issuers for all mapped routes of an upstream.
+type UpstreamIssuers struct {
+	Name    string   // Upstream name (Upstreams[].Name)
+	Issuers []string // Accepted issuer names
+}
+
 // GatewayOption options for starting the Gateway.
 type GatewayOption func(*gatewayOptions)
 
@@ -83,15 +145,133 @@ func RunGateway(opts ...GatewayOption) {
 	gw := gateway.MustNewServer(c.GatewayConf)
 	defer gw.Stop()
 
+	// Resolve client IPs behind the trusted proxies (access log, audit, IP filter, ...).
+	clientip.SetResolver(clientip.MustNewResolver(c.ClientIP))
+
 	// Register middlewares (similar to http.go).
-	// If auth is configured, add the JWT middleware.
-	if c.Auth.AccessSecret != "" {
-		jwtMw := middleware.RegisterJwtMiddleware(c.Auth.AccessSecret, c.Auth.SkipPaths)
+	// If compression is enabled, compress responses (outermost, so that other middlewares and
+	// ResponseMiddleware see plain bodies; upstreams are asked for uncompressed responses).
+	if c.Compress.Enabled {
+		gw.Server.Use(middleware.Compress(compress.New(c.Compress)))
+	}
+
+	// If the access log is enabled, log every request (first, so that rejected requests are logged too).
+	if c.AccessLog.Enabled {
+		gw.Server.Use(middleware.AccessLog(accesslog.New(c.AccessLog), gw.Server.Routes))
+	}
+
+	// If auditing is enabled, audit state-changing requests (before authentication, so that rejected ones are audited too).
+	if c.Audit.Enabled {
+		auditor := audit.MustNewAuditor(c.Audit)
+		audit.SetAuditor(auditor)
+		defer auditor.Close()
+		gw.Server.Use(middleware.Audit(auditor, gw.Server.Routes))
+	}
+
+	// Apply security headers to every response (rejected ones included), by default in prod.
+	if (c.SecurityHeaders.Enabled || c.App.IsProd()) && !c.SecurityHeaders.Disabled {
+		gw.Server.Use(middleware.SecurityHeaders(c.SecurityHeaders))
+	}
+
+	// If the IP filter is enabled, reject client IPs denied on the route.
+	if c.IPFilter.Enabled {
+		gw.Server.Use(middleware.IPFilter(c.IPFilter))
+	}
+
+	// Recover panics of gateway handlers with ErrInternal and report them (see recovery.SetReporter).
+	gw.Server.Use(middleware.Recover())
+
+	// Client-supplied identity headers are always stripped: only the gateway may set them.
+	gw.Server.Use(middleware.StripIdentityHeaders())
+
+	// If request signing is enabled, reject unsigned, tampered or replayed requests before authentication.
+	if c.RequestSignature.Enabled {
+		gw.Server.Use(middleware.RequestSignature(signature.MustNewVerifier(c.RequestSignature), c.RequestSignature.Paths...))
+	}
+
+	// If OIDC login is enabled, mount the login and callback endpoints (reachable without a token).
+	skipPaths := c.Auth.SkipPaths
+	if c.Auth.OIDC.Enabled {
+		skipPaths = append(registerOIDCEndpoints(gw, c), skipPaths...)
+	}
+
+	// If auth is configured, add the JWT (and API key) middleware.
+	authEnabled := c.Auth.AccessSecret != "" || c.Auth.APIKey.Enabled || len(c.Auth.Issuers) > 0
+	if c.Tenant.Enabled && !authEnabled {
+		logx.Must(fmt.Errorf("Tenant requires Auth (AccessSecret, APIKey or Issuers): tenants are resolved by the JWT middleware"))
+	}
+	if authEnabled {
+		// If revocation is enabled, create the denylist store and expose it to auth.Revoke.
+		var revocation auth.RevocationStore
+		if c.Auth.Revocation.Enabled {
+			revocation = auth.MustNewRevocationStore(c.Auth.Revocation)
+			auth.SetRevocationStore(revocation)
+			skipPaths = append(skipPaths, registerRevokeEndpoint(gw, c, revocation)...)
+		}
+
+		var signer *auth.IdentitySigner
+		if c.Auth.IdentitySign.Enabled {
+			signer = auth.MustNewIdentitySigner(c.Auth.IdentitySign)
+		}
+
+		var apiKeys *auth.APIKeyAuthenticator
+		if c.Auth.APIKey.Enabled {
+			apiKeys = auth.MustNewAPIKeyAuthenticator(c.Auth.APIKey)
+		}
+
+		var issuers *auth.JwtVerifier
+		var issuerRoutes []middleware.JwtIssuerRoute
+		if len(c.Auth.Issuers) > 0 {
+			issuers, issuerRoutes = mustBuildIssuers(c)
+			logx.Infof("JWT issuers configured: %s", issuers)
+		}
+
+		var tenants *tenant.Resolver
+		if c.Tenant.Enabled {
+			tenants = tenant.NewResolver(c.Tenant)
+		}
+
+		jwtMw := middleware.JwtWithConfig(middleware.JwtConfig{
+			Secret:       c.Auth.AccessSecret,
+			SkipPaths:    skipPaths,
+			Rules:        c.Auth.Rules,
+			Revocation:   revocation,
+			FailClosed:   c.Auth.Revocation.FailClosed,
+			Signer:       signer,
+			APIKeys:      apiKeys,
+			Issuers:      issuers,
+			IssuerRoutes: issuerRoutes,
+			Tenants:      tenants,
+		})
 		gw.Server.Use(jwtMw)
-		logx.Infof("JWT middleware configured with secret (length: %d), skip paths: %v", len(c.Auth.AccessSecret), c.Auth.SkipPaths)
+		logx.Infof("JWT middleware configured with secret (length: %d), skip paths: %v, rules: %d, revocation: %v, identity signing: %v, api keys: %v, issuers: %d",
+			len(c.Auth.AccessSecret), skipPaths, len(c.Auth.Rules), c.Auth.Revocation.Enabled, c.Auth.IdentitySign.Enabled, c.Auth.APIKey.Enabled, len(c.Auth.Issuers))
+	}
+
+	// If per-tenant rate limits are enabled, limit each tenant resolved above.
+	if c.Tenant.Enabled && c.Tenant.RateLimit.Enabled {
+		gw.Server.Use(middleware.TenantRateLimit(tenant.MustNewLimiter(c.Tenant.RateLimit)))
+	}
+
+	// If an authorization policy is configured, enforce it on the authenticated identity.
+	if c.Auth.Policy != "" {
+		gw.Server.Use(middleware.Authorize(authz.MustLoadPolicy(c.Auth.Policy)))
+		logx.Infof("Authorization policy loaded from %s", c.Auth.Policy)
 	}
-	
+
+	// If idempotency is enabled, replay stored responses (in the unified format) to retried requests.
+	if c.Idempotency.Enabled {
+		gw.Server.Use(middleware.Idempotency(c.Idempotency, idempotency.MustNewStore(c.Idempotency)))
+	}
+
+	// If the response cache is enabled, serve cached responses of GET routes (after authorization).
+	if c.Cache.Enabled {
+		gw.Server.Use(middleware.Cache(c.Cache, httpcache.MustNewStore(c.Cache)))
+	}
+
 	// Add unified response format middleware.
+	// gRPC errors keep their business code and field violations (see response.ErrorHandler).
+	httpx.SetErrorHandlerCtx(response.ErrorHandler)
 	gw.Server.Use(middleware.ResponseMiddleware())
 
 	// Before-start callback (can be used to register other middlewares, etc.).
@@ -117,3 +297,89 @@ func RunGateway(opts ...GatewayOption) {
 	gw.Start()
 }
 
+// mustBuildIssuers creates the named issuer verifier and expands the per-upstream issuer selection
+// into routes (one per upstream mapping), panics on invalid config.
+func mustBuildIssuers(c GatewayConfig) (*auth.JwtVerifier, []middleware.JwtIssuerRoute) {
+	confs := c.Auth.Issuers
+	if c.Auth.AccessSecret != "" {
+		confs = append([]auth.JwtIssuerConf{{
+			Name:   auth.DefaultIssuerName,
+			Secret: c.Auth.AccessSecret,
+		}}, confs...)
+	}
+	issuers := auth.MustNewJwtVerifier(confs)
+
+	for _, rule := range c.Auth.Rules {
+		logx.Must(issuers.Check(rule.Issuers))
+	}
+
+	var routes []middleware.JwtIssuerRoute
+	for _, u := range c.Auth.Upstreams {
+		logx.Must(issuers.Check(u.Issuers))
+
+		var upstream *gateway.Upstream
+		for i := range c.Upstreams {
+			if c.Upstreams[i].Name == u.Name {
+				upstream = &c.Upstreams[i]
+				break
+			}
+		}
+		if upstream == nil {
+			logx.Must(fmt.Errorf("Auth.Upstreams: unknown upstream %q", u.Name))
+		}
+		if len(upstream.Mappings) == 0 {
+			logx.Errorf("Auth.Upstreams: upstream %q has no Mappings, use Auth.Rules to select its issuers", u.Name)
+			continue
+		}
+		for _, m := range upstream.Mappings {
+			routes = append(routes, middleware.JwtIssuerRoute{
+				Path:    m.Path,
+				Methods: []string{m.Method},
+				Issuers: u.Issuers,
+			})
+		}
+	}
+
+	return issuers, routes
+}
+
+// registerRevokeEndpoint mounts the admin token revocation endpoint if configured and returns its path.
+// The endpoint is authenticated by the admin key only, so it is reachable without a token.
+func registerRevokeEndpoint(gw *gateway.Server, c GatewayConfig, store auth.RevocationStore) []string {
+	if c.Auth.Revocation.AdminPath == "" {
+		return nil
+	}
+	if c.Auth.Revocation.AdminKey == "" {
+		logx.Errorf("Revocation admin endpoint %s not registered: AdminKey is empty", c.Auth.Revocation.AdminPath)
+		return nil
+	}
+
+	// Without AccessExpire, entries are kept for auth.DefaultRevokeTTL (see RevokeHandler).
+	ttl := time.Duration(c.Auth.AccessExpire) * time.Second
+	gw.Server.AddRoute(rest.Route{
+		Method:  http.MethodPost,
+		Path:    c.Auth.Revocation.AdminPath,
+		Handler: authhandler.RevokeHandler(store, c.Auth.Revocation.AdminKey, ttl),
+	})
+	logx.Infof("Revocation admin endpoint registered: POST %s", c.Auth.Revocation.AdminPath)
+	return []string{c.Auth.Revocation.AdminPath}
+}
+
+// registerOIDCEndpoints mounts the OIDC login and callback endpoints and returns their paths.
+// Identities are converted into access tokens signed with Auth.AccessSecret.
+func registerOIDCEndpoints(gw *gateway.Server, c GatewayConfig) []string {
+	if c.Auth.AccessSecret == "" || c.Auth.AccessExpire <= 0 {
+		logx.Must(fmt.Errorf("Auth.AccessSecret and Auth.AccessExpire are required for OIDC login"))
+	}
+
+	rp := oidc.MustNewRelyingParty(c.Auth.OIDC)
+	issuer := auth.NewIssuer(auth.IssuerConf{
+		AccessSecret: c.Auth.AccessSecret,
+		AccessExpire: c.Auth.AccessExpire,
+	}, nil)
+	gw.Server.AddRoutes(authhandler.OIDCRoutes(c.Auth.OIDC, rp, issuer))
+	logx.Infof("OIDC login endpoints registered: GET %s, GET %s (issuer %s)",
+		c.Auth.OIDC.LoginPath, c.Auth.OIDC.CallbackPath, c.Auth.OIDC.Issuer)
+
+	return []string{c.Auth.OIDC.LoginPath, c.Auth.OIDC.CallbackPath}
+}
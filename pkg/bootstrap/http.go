@@ -7,15 +7,68 @@ import (
 	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/rest"
 
+	"github.com/addls/go-base/pkg/accesslog"
+	"github.com/addls/go-base/pkg/audit"
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/authz"
+	"github.com/addls/go-base/pkg/clientip"
+	"github.com/addls/go-base/pkg/compress"
 	"github.com/addls/go-base/pkg/config"
+	"github.com/addls/go-base/pkg/httpcache"
+	"github.com/addls/go-base/pkg/idempotency"
 	"github.com/addls/go-base/pkg/middleware"
 	"github.com/addls/go-base/pkg/response"
+	"github.com/addls/go-base/pkg/session"
+	"github.com/addls/go-base/pkg/signature"
+	"github.com/addls/go-base/pkg/tenant"
 )
 
 // HttpConfig base configuration for the HTTP service (embeds rest.RestConf).
 type HttpConfig struct {
 	rest.RestConf
 
+	// Verification of the gateway-signed x-jwt-* identity headers (optional).
+	IdentitySign auth.IdentitySignConf `json:",optional"`
+
+	// Trust unsigned x-jwt-* identity headers (only if clients can reach the service through the gateway only).
+	TrustIdentityHeaders bool `json:",optional"`
+
+	// Role/scope authorization policy file (optional, see authz.PolicyConf).
+	AuthPolicy string `json:",optional"`
+
+	// HMAC request signature verification for partner requests (optional).
+	RequestSignature signature.Conf `json:",optional"`
+
+	// Idempotency-Key support for unsafe methods (optional).
+	Idempotency idempotency.Conf `json:",optional"`
+
+	// Response cache for GET routes (optional).
+	Cache httpcache.Conf `json:",optional"`
+
+	// Response compression negotiated by Accept-Encoding (optional).
+	Compress compress.Conf `json:",optional"`
+
+	// Client IP resolution behind trusted proxies, used by all middlewares (optional).
+	ClientIP clientip.Conf `json:",optional"`
+
+	// Client IP allow/deny lists per route (optional).
+	IPFilter middleware.IPFilterConf `json:",optional"`
+
+	// Security headers (HSTS, CSP, ...); applied by default when App.Env is prod.
+	SecurityHeaders middleware.SecurityHeadersConf `json:",optional"`
+
+	// Structured access log with body sampling and redaction (optional).
+	AccessLog accesslog.Conf `json:",optional"`
+
+	// Audit trail of state-changing operations (optional).
+	Audit audit.Conf `json:",optional"`
+
+	// Multi-tenancy: tenant resolution, forwarding and per-tenant rate limits (optional).
+	Tenant tenant.Conf `json:",optional"`
+
+	// Cookie session authentication with CSRF protection for browser clients (optional).
+	Session session.Conf `json:",optional"`
+
 	// Application configuration.
 	App config.AppConfig `json:",optional"`
 }
@@ -97,11 +150,89 @@ func RunHttp(opts ...HttpOption) {
 	server := rest.MustNewServer(c.RestConf, rest.WithUnauthorizedCallback(response.UnauthorizedCallback))
 	defer server.Stop()
 
+	// Resolve client IPs behind the trusted proxies (access log, audit, IP filter, ...).
+	clientip.SetResolver(clientip.MustNewResolver(c.ClientIP))
+
+	// If compression is enabled, compress responses (outermost, so that other middlewares see plain bodies).
+	if c.Compress.Enabled {
+		server.Use(middleware.Compress(compress.New(c.Compress)))
+	}
+
+	// If the access log is enabled, log every request (first, so that rejected requests are logged too).
+	if c.AccessLog.Enabled {
+		server.Use(middleware.AccessLog(accesslog.New(c.AccessLog), server.Routes))
+	}
+
+	// If auditing is enabled, audit state-changing requests (before authentication, so that rejected ones are audited too).
+	if c.Audit.Enabled {
+		auditor := audit.MustNewAuditor(c.Audit)
+		audit.SetAuditor(auditor)
+		defer auditor.Close()
+		server.Use(middleware.Audit(auditor, server.Routes))
+	}
+
+	// Apply security headers to every response (rejected ones included), by default in prod.
+	if (c.SecurityHeaders.Enabled || c.App.IsProd()) && !c.SecurityHeaders.Disabled {
+		server.Use(middleware.SecurityHeaders(c.SecurityHeaders))
+	}
+
+	// If the IP filter is enabled, reject client IPs denied on the route.
+	if c.IPFilter.Enabled {
+		server.Use(middleware.IPFilter(c.IPFilter))
+	}
+
+	// Forwarded identity headers are only trusted by AuthContext (default middlewares) once verified,
+	// or when explicitly trusted.
+	if c.IdentitySign.Enabled {
+		// Reject unsigned or stale forwarded identities.
+		server.Use(middleware.IdentitySignature(auth.MustNewIdentitySigner(c.IdentitySign)))
+	} else if c.TrustIdentityHeaders {
+		server.Use(middleware.TrustIdentityHeaders())
+	}
+
 	// Register middlewares.
 	for _, m := range o.middlewares {
 		server.Use(m)
 	}
 
+	// If request signing is enabled, reject unsigned, tampered or replayed requests.
+	if c.RequestSignature.Enabled {
+		server.Use(middleware.RequestSignature(signature.MustNewVerifier(c.RequestSignature), c.RequestSignature.Paths...))
+	}
+
+	// If sessions are enabled, authenticate session cookies and protect them against CSRF.
+	if c.Session.Enabled {
+		sessions := session.MustNewManager(c.Session)
+		session.SetManager(sessions)
+		server.Use(middleware.Session(sessions))
+		if c.Session.CSRF.Enabled {
+			server.Use(middleware.CSRF(sessions))
+		}
+	}
+
+	// If multi-tenancy is enabled, resolve the tenant of the caller and limit each tenant.
+	if c.Tenant.Enabled {
+		server.Use(middleware.Tenant(tenant.NewResolver(c.Tenant)))
+		if c.Tenant.RateLimit.Enabled {
+			server.Use(middleware.TenantRateLimit(tenant.MustNewLimiter(c.Tenant.RateLimit)))
+		}
+	}
+
+	// If an authorization policy is configured, enforce it on the caller identity.
+	if c.AuthPolicy != "" {
+		server.Use(middleware.Authorize(authz.MustLoadPolicy(c.AuthPolicy)))
+	}
+
+	// If idempotency is enabled, replay stored responses to retried requests.
+	if c.Idempotency.Enabled {
+		server.Use(middleware.Idempotency(c.Idempotency, idempotency.MustNewStore(c.Idempotency)))
+	}
+
+	// If the response cache is enabled, serve cached responses of GET routes (after authorization).
+	if c.Cache.Enabled {
+		server.Use(middleware.Cache(c.Cache, httpcache.MustNewStore(c.Cache)))
+	}
+
 	// Before-start callback.
 	if o.beforeStart != nil {
 		o.beforeStart(server)
@@ -8,13 +8,40 @@ import (
 	"github.com/zeromicro/go-zero/zrpc"
 	"google.golang.org/grpc"
 
+	"github.com/addls/go-base/pkg/accesslog"
+	"github.com/addls/go-base/pkg/audit"
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/authz"
 	"github.com/addls/go-base/pkg/config"
+	"github.com/addls/go-base/pkg/interceptor"
+	"github.com/addls/go-base/pkg/tenant"
 )
 
 // RpcConfig base configuration for the gRPC service (embeds zrpc.RpcServerConf).
 type RpcConfig struct {
 	zrpc.RpcServerConf
 
+	// Token revocation denylist (optional; checks identities forwarded by the gateway).
+	Revocation auth.RevocationConf `json:",optional"`
+
+	// Verification of the gateway-signed x-jwt-* identity metadata (optional).
+	IdentitySign auth.IdentitySignConf `json:",optional"`
+
+	// Role/scope authorization policy file (optional, see authz.PolicyConf).
+	AuthPolicy string `json:",optional"`
+
+	// Structured access log with body sampling and redaction (optional).
+	AccessLog accesslog.Conf `json:",optional"`
+
+	// Audit trail of state-changing operations (optional).
+	Audit audit.Conf `json:",optional"`
+
+	// Per-tenant rate limit of the calls carrying a tenant forwarded by the gateway (optional).
+	TenantRateLimit tenant.RateLimitConf `json:",optional"`
+
+	// Disables request validation by proto rules (enabled by default, see interceptor.ValidateUnaryInterceptor).
+	SkipValidation bool `json:",optional"`
+
 	// Application configuration.
 	App config.AppConfig `json:",optional"`
 }
@@ -28,6 +55,8 @@ type RpcOption func(*rpcOptions)
 type rpcOptions struct {
 	config          *RpcConfig // Optional: if provided use directly; otherwise load from file.
 	interceptors    []grpc.UnaryServerInterceptor
+	streams         []grpc.StreamServerInterceptor
+	validators      []interceptor.Validator
 	serviceRegister ServiceRegister
 	beforeStart     func(*zrpc.RpcServer)
 	afterStart      func(*zrpc.RpcServer)
@@ -47,6 +76,21 @@ func WithRpcInterceptor(interceptors ...grpc.UnaryServerInterceptor) RpcOption {
 	}
 }
 
+// WithRpcStreamInterceptor adds gRPC stream interceptors.
+func WithRpcStreamInterceptor(interceptors ...grpc.StreamServerInterceptor) RpcOption {
+	return func(o *rpcOptions) {
+		o.streams = append(o.streams, interceptors...)
+	}
+}
+
+// WithRpcValidator adds request validators run after the generated proto validation
+// (e.g. an adapter of a protovalidate validator).
+func WithRpcValidator(validators ...interceptor.Validator) RpcOption {
+	return func(o *rpcOptions) {
+		o.validators = append(o.validators, validators...)
+	}
+}
+
 // WithRpcService registers gRPC services.
 func WithRpcService(register ServiceRegister) RpcOption {
 	return func(o *rpcOptions) {
@@ -98,9 +142,67 @@ func RunRpc(opts ...RpcOption) {
 		}
 	})
 
+	// If the access log is enabled, log every call (first, so that rejected calls are logged too).
+	if c.AccessLog.Enabled {
+		logger := accesslog.New(c.AccessLog)
+		server.AddUnaryInterceptors(interceptor.AccessLogUnaryInterceptor(logger))
+		server.AddStreamInterceptors(interceptor.AccessLogStreamInterceptor(logger))
+	}
+
+	// If auditing is enabled, audit the calls matching the audit rules (before authorization, so that rejected ones are audited too).
+	if c.Audit.Enabled {
+		auditor := audit.MustNewAuditor(c.Audit)
+		audit.SetAuditor(auditor)
+		defer auditor.Close()
+		server.AddUnaryInterceptors(interceptor.AuditUnaryInterceptor(auditor))
+		server.AddStreamInterceptors(interceptor.AuditStreamInterceptor(auditor))
+	}
+
+	// Recover panics of RPC methods with ErrInternal and report them (see recovery.SetReporter).
+	server.AddUnaryInterceptors(interceptor.RecoverUnaryInterceptor())
+	server.AddStreamInterceptors(interceptor.RecoverStreamInterceptor())
+
+	// If identity signing is enabled, reject unsigned or stale forwarded identities.
+	if c.IdentitySign.Enabled {
+		signer := auth.MustNewIdentitySigner(c.IdentitySign)
+		server.AddUnaryInterceptors(interceptor.IdentityUnaryInterceptor(signer))
+		server.AddStreamInterceptors(interceptor.IdentityStreamInterceptor(signer))
+	}
+
+	// If revocation is enabled, reject calls carrying revoked token identities.
+	if c.Revocation.Enabled {
+		store := auth.MustNewRevocationStore(c.Revocation)
+		auth.SetRevocationStore(store)
+		server.AddUnaryInterceptors(interceptor.RevocationUnaryInterceptor(store, c.Revocation.FailClosed))
+		server.AddStreamInterceptors(interceptor.RevocationStreamInterceptor(store, c.Revocation.FailClosed))
+	}
+
+	// If an authorization policy is configured, enforce it on gRPC methods.
+	if c.AuthPolicy != "" {
+		policy := authz.MustLoadPolicy(c.AuthPolicy)
+		server.AddUnaryInterceptors(interceptor.AuthzUnaryInterceptor(policy))
+		server.AddStreamInterceptors(interceptor.AuthzStreamInterceptor(policy))
+	}
+
+	// If per-tenant rate limits are enabled, limit each tenant forwarded by the gateway.
+	if c.TenantRateLimit.Enabled {
+		limiter := tenant.MustNewLimiter(c.TenantRateLimit)
+		server.AddUnaryInterceptors(interceptor.TenantRateLimitUnaryInterceptor(limiter))
+		server.AddStreamInterceptors(interceptor.TenantRateLimitStreamInterceptor(limiter))
+	}
+
+	// Validate requests by the rules declared in the proto, after authentication and authorization.
+	if !c.SkipValidation {
+		server.AddUnaryInterceptors(interceptor.ValidateUnaryInterceptor(o.validators...))
+		server.AddStreamInterceptors(interceptor.ValidateStreamInterceptor(o.validators...))
+	}
+
 	// Register interceptors.
-	for _, interceptor := range o.interceptors {
-		server.AddUnaryInterceptors(interceptor)
+	for _, unary := range o.interceptors {
+		server.AddUnaryInterceptors(unary)
+	}
+	for _, stream := range o.streams {
+		server.AddStreamInterceptors(stream)
 	}
 
 	defer server.Stop()
@@ -0,0 +1,189 @@
+// Package clientip resolves the client IP of HTTP requests. The forwarding header set by the
+// trusted proxies (Forwarded, X-Forwarded-For or X-Real-Ip) is only read when the request comes
+// from a trusted proxy, so clients cannot spoof their address. It is shared by the go-base
+// middlewares (access log, audit, recover, IP filter) through FromRequest.
+package clientip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// Forwarding headers.
+const (
+	HeaderForwarded     = "Forwarded"
+	HeaderXForwardedFor = "X-Forwarded-For"
+	HeaderXRealIP       = "X-Real-Ip"
+)
+
+// Conf client IP configuration.
+type Conf struct {
+	TrustedProxies []string `json:",optional"` // CIDRs or IPs of the proxies (load balancers, ingress) allowed to set the forwarding header; empty trusts none
+	Header         string   `json:",optional"` // Forwarding header the trusted proxies set (Forwarded, X-Forwarded-For or X-Real-Ip); required with TrustedProxies
+}
+
+// Resolver resolves the client IP of HTTP requests.
+type Resolver struct {
+	trusted []netip.Prefix
+	header  string
+}
+
+// NewResolver creates a Resolver from config.
+func NewResolver(c Conf) (*Resolver, error) {
+	trusted, err := ParsePrefixes(c.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("clientip: trusted proxies: %w", err)
+	}
+	if len(trusted) > 0 && c.Header == "" {
+		// Only the header the proxies set is safe: any other one passes through from the client.
+		return nil, errors.New("clientip: Header is required with TrustedProxies")
+	}
+	return &Resolver{trusted: trusted, header: http.CanonicalHeaderKey(c.Header)}, nil
+}
+
+// MustNewResolver creates a Resolver from config, panics on error.
+func MustNewResolver(c Conf) *Resolver {
+	r, err := NewResolver(c)
+	logx.Must(err)
+	return r
+}
+
+// Resolve returns the client IP of a request. The peer address is returned unless it is a trusted
+// proxy; then the forwarding header is walked from the nearest hop and the first address that is not
+// a trusted proxy is returned (the farthest one if all are trusted). The walk stops at the first
+// malformed element, as the elements before it were not written by a trusted proxy. It returns ""
+// if the client IP is unknown: the peer address is not an IP (e.g. a unix socket), or a trusted peer
+// did not forward a valid client address.
+func (r *Resolver) Resolve(req *http.Request) string {
+	remote, ok := parseAddr(req.RemoteAddr)
+	if !ok {
+		return ""
+	}
+	if !r.isTrusted(remote) {
+		return remote.String()
+	}
+
+	elements := r.elements(req.Header)
+	for i := len(elements) - 1; i >= 0; i-- {
+		ip, ok := parseAddr(elements[i])
+		if !ok {
+			return ""
+		}
+		if !r.isTrusted(ip) || i == 0 {
+			return ip.String()
+		}
+	}
+	return ""
+}
+
+func (r *Resolver) isTrusted(ip netip.Addr) bool {
+	return Contains(r.trusted, ip)
+}
+
+// elements returns the client addresses listed in the forwarding header, farthest first.
+func (r *Resolver) elements(header http.Header) []string {
+	if r.header == "" {
+		return nil
+	}
+	var elements []string
+	for _, v := range header.Values(r.header) {
+		for _, element := range strings.Split(v, ",") {
+			element = strings.TrimSpace(element)
+			if r.header == HeaderForwarded {
+				element = forwardedFor(element)
+			}
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+// forwardedFor returns the for= parameter of a Forwarded element (RFC 7239), e.g.
+// for="[2001:db8::1]:4711";proto=https -> [2001:db8::1]:4711.
+func forwardedFor(element string) string {
+	for _, pair := range strings.Split(element, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && strings.EqualFold(key, "for") {
+			return strings.Trim(value, `"`)
+		}
+	}
+	return ""
+}
+
+// parseAddr parses an IP with an optional port (e.g. 10.0.0.1, 10.0.0.1:80, [::1]:80).
+// IPv4-mapped IPv6 addresses are unmapped.
+func parseAddr(s string) (netip.Addr, bool) {
+	if ip, err := netip.ParseAddr(s); err == nil {
+		return ip.Unmap(), true
+	}
+	host, _, err := net.SplitHostPort(s)
+	if err != nil {
+		host = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap(), true
+}
+
+// ParsePrefixes parses CIDRs or single IPs (as /32 or /128 prefixes).
+func ParsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if strings.Contains(v, "/") {
+			p, err := netip.ParsePrefix(v)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, p.Masked())
+			continue
+		}
+		ip, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, err
+		}
+		ip = ip.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(ip, ip.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// Contains reports whether ip is in one of the prefixes.
+func Contains(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	defaultMu       sync.RWMutex
+	defaultResolver = &Resolver{}
+)
+
+// SetResolver sets the Resolver used by FromRequest (bootstrap sets it from the ClientIP config).
+func SetResolver(r *Resolver) {
+	defaultMu.Lock()
+	defaultResolver = r
+	defaultMu.Unlock()
+}
+
+// FromRequest returns the client IP of a request with the Resolver set by SetResolver. Until then,
+// no proxy is trusted and the peer address is returned.
+func FromRequest(r *http.Request) string {
+	defaultMu.RLock()
+	resolver := defaultResolver
+	defaultMu.RUnlock()
+	return resolver.Resolve(r)
+}
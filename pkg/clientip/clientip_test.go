@@ -0,0 +1,104 @@
+package clientip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewResolver(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    Conf
+		wantErr bool
+	}{
+		{name: "no proxies", conf: Conf{}},
+		{name: "proxies with header", conf: Conf{TrustedProxies: []string{"10.0.0.0/8", "192.168.1.1"}, Header: HeaderXForwardedFor}},
+		{name: "proxies without header", conf: Conf{TrustedProxies: []string{"10.0.0.0/8"}}, wantErr: true},
+		{name: "malformed proxy", conf: Conf{TrustedProxies: []string{"10.0.0.0/33"}, Header: HeaderXForwardedFor}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewResolver(tt.conf); (err != nil) != tt.wantErr {
+				t.Errorf("NewResolver error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolverResolve(t *testing.T) {
+	proxies := []string{"10.0.0.0/8", "2001:db8::/32"}
+
+	tests := []struct {
+		name    string
+		header  string // Header the proxies set; empty trusts no proxy
+		remote  string
+		headers map[string][]string
+		want    string
+	}{
+		{name: "no trusted proxy", remote: "203.0.113.7:4711", headers: map[string][]string{
+			HeaderXForwardedFor: {"198.51.100.1"},
+		}, want: "203.0.113.7"},
+		{name: "untrusted peer", header: HeaderXForwardedFor, remote: "203.0.113.7:4711", headers: map[string][]string{
+			HeaderXForwardedFor: {"198.51.100.1"},
+		}, want: "203.0.113.7"},
+		{name: "trusted peer", header: HeaderXForwardedFor, remote: "10.0.0.2:4711", headers: map[string][]string{
+			HeaderXForwardedFor: {"198.51.100.1"},
+		}, want: "198.51.100.1"},
+		{name: "client-prepended hops are skipped", header: HeaderXForwardedFor, remote: "10.0.0.2:4711", headers: map[string][]string{
+			HeaderXForwardedFor: {"1.2.3.4, 198.51.100.1, 10.0.0.3"},
+		}, want: "198.51.100.1"},
+		{name: "repeated header lines", header: HeaderXForwardedFor, remote: "10.0.0.2:4711", headers: map[string][]string{
+			HeaderXForwardedFor: {"1.2.3.4", "198.51.100.1"},
+		}, want: "198.51.100.1"},
+		{name: "all hops trusted", header: HeaderXForwardedFor, remote: "10.0.0.2:4711", headers: map[string][]string{
+			HeaderXForwardedFor: {"10.0.0.4, 10.0.0.3"},
+		}, want: "10.0.0.4"},
+		{name: "malformed hop", header: HeaderXForwardedFor, remote: "10.0.0.2:4711", headers: map[string][]string{
+			HeaderXForwardedFor: {"198.51.100.1, unknown, 10.0.0.3"},
+		}, want: ""},
+		{name: "trusted peer without header", header: HeaderXForwardedFor, remote: "10.0.0.2:4711", want: ""},
+		{name: "other header is ignored", header: HeaderXForwardedFor, remote: "10.0.0.2:4711", headers: map[string][]string{
+			HeaderXRealIP: {"198.51.100.1"},
+		}, want: ""},
+		{name: "x-real-ip", header: HeaderXRealIP, remote: "10.0.0.2:4711", headers: map[string][]string{
+			HeaderXRealIP:       {"198.51.100.1"},
+			HeaderXForwardedFor: {"1.2.3.4"},
+		}, want: "198.51.100.1"},
+		{name: "forwarded", header: HeaderForwarded, remote: "10.0.0.2:4711", headers: map[string][]string{
+			HeaderForwarded: {`for=1.2.3.4, for="[2606:4700::17]:4711";proto=https, for=10.0.0.3`},
+		}, want: "2606:4700::17"},
+		{name: "forwarded without for", header: HeaderForwarded, remote: "10.0.0.2:4711", headers: map[string][]string{
+			HeaderForwarded: {"proto=https"},
+		}, want: ""},
+		{name: "ipv6 peer", header: HeaderXForwardedFor, remote: "[2001:db8::1]:4711", headers: map[string][]string{
+			HeaderXForwardedFor: {"198.51.100.1"},
+		}, want: "198.51.100.1"},
+		{name: "ipv4-mapped peer", header: HeaderXForwardedFor, remote: "[::ffff:10.0.0.2]:4711", headers: map[string][]string{
+			HeaderXForwardedFor: {"198.51.100.1"},
+		}, want: "198.51.100.1"},
+		{name: "unix socket peer", header: HeaderXForwardedFor, remote: "@", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Conf{Header: tt.header}
+			if tt.header != "" {
+				c.TrustedProxies = proxies
+			}
+			r, err := NewResolver(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			for k, values := range tt.headers {
+				for _, v := range values {
+					req.Header.Add(k, v)
+				}
+			}
+			if got := r.Resolve(req); got != tt.want {
+				t.Errorf("Resolve = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+// Package compress negotiates and encodes compressed HTTP responses (gzip, deflate, br, zstd),
+// used by middleware.Compress.
+package compress
+
+import (
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/addls/go-base/pkg/pathmatch"
+)
+
+// Content codings.
+const (
+	EncodingBrotli  = "br"
+	EncodingZstd    = "zstd"
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+)
+
+// Compression levels.
+const (
+	LevelFastest = "fastest"
+	LevelDefault = "default"
+	LevelBest    = "best"
+)
+
+// Defaults used when the corresponding Conf list is empty.
+var (
+	// DefaultEncodings in server preference order (used to break q-value ties).
+	DefaultEncodings = []string{EncodingBrotli, EncodingZstd, EncodingGzip, EncodingDeflate}
+	// DefaultContentTypes are the compressed media types ("text/*" matches every text type).
+	DefaultContentTypes = []string{
+		"application/json", "application/problem+json", "application/javascript", "application/xml",
+		"image/svg+xml", "text/html", "text/plain", "text/css", "text/javascript", "text/xml", "text/csv",
+	}
+)
+
+// Conf response compression configuration.
+type Conf struct {
+	Enabled      bool     `json:",optional"`
+	Encodings    []string `json:",optional"`                                     // Enabled codings in preference order (default DefaultEncodings)
+	Level        string   `json:",default=default,options=fastest|default|best"` // Compression level
+	MinSize      int      `json:",default=1024,range=[0:]"`                      // Smaller responses are sent uncompressed
+	ContentTypes []string `json:",optional"`                                     // Compressed media types (default DefaultContentTypes)
+	SkipPaths    []string `json:",optional"`                                     // Path patterns never compressed
+}
+
+// Encoder is a compressing writer.
+type Encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// Compressor negotiates codings and hands out pooled encoders.
+type Compressor struct {
+	conf         Conf
+	encodings    []string
+	contentTypes map[string]bool
+	prefixes     []string
+	skip         []pathmatch.Pattern
+	pools        map[string]*sync.Pool
+}
+
+// New creates a Compressor from config. Unknown codings are ignored with an error log.
+func New(c Conf) *Compressor {
+	if c.Level == "" {
+		c.Level = LevelDefault
+	}
+
+	cp := &Compressor{
+		conf:         c,
+		contentTypes: make(map[string]bool),
+		pools:        make(map[string]*sync.Pool),
+	}
+
+	encodings := c.Encodings
+	if len(encodings) == 0 {
+		encodings = DefaultEncodings
+	}
+	for _, e := range encodings {
+		e = strings.ToLower(strings.TrimSpace(e))
+		newEncoder := encoderFactory(e, c.Level)
+		if newEncoder == nil {
+			logx.Errorf("compress: unknown encoding %q ignored", e)
+			continue
+		}
+		cp.encodings = append(cp.encodings, e)
+		cp.pools[e] = &sync.Pool{New: func() interface{} { return newEncoder() }}
+	}
+
+	types := c.ContentTypes
+	if len(types) == 0 {
+		types = DefaultContentTypes
+	}
+	for _, t := range types {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if strings.HasSuffix(t, "/*") {
+			cp.prefixes = append(cp.prefixes, strings.TrimSuffix(t, "*"))
+		} else {
+			cp.contentTypes[t] = true
+		}
+	}
+
+	for _, p := range c.SkipPaths {
+		cp.skip = append(cp.skip, pathmatch.Compile(p))
+	}
+	return cp
+}
+
+// MinSize returns the minimum size of compressed responses.
+func (c *Compressor) MinSize() int {
+	return c.conf.MinSize
+}
+
+// Skip reports whether responses of the path are never compressed.
+func (c *Compressor) Skip(path string) bool {
+	for _, p := range c.skip {
+		if p.Match(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Compressible reports whether a response of the content type is compressed.
+func (c *Compressor) Compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if c.contentTypes[mediaType] {
+		return true
+	}
+	for _, prefix := range c.prefixes {
+		if strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Negotiate returns the coding to use for an Accept-Encoding header, or "" for none.
+// The highest q-value wins; ties are broken by the configured preference order.
+// Codings with q=0 are refused, and "*" applies to the codings not listed.
+func (c *Compressor) Negotiate(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	accepted := make(map[string]float64)
+	wildcard := -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, q := parseCoding(part)
+		switch name {
+		case "":
+		case "*":
+			wildcard = q
+		default:
+			accepted[name] = q
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, e := range c.encodings {
+		q, ok := accepted[e]
+		if !ok {
+			if wildcard < 0 {
+				continue
+			}
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = e, q
+		}
+	}
+	return best
+}
+
+// parseCoding parses a coding with its q-value, e.g. "gzip;q=0.8" (q defaults to 1).
+func parseCoding(s string) (string, float64) {
+	name, params, _ := strings.Cut(s, ";")
+	name = strings.ToLower(strings.TrimSpace(name))
+	q := 1.0
+	for _, param := range strings.Split(params, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || strings.ToLower(strings.TrimSpace(key)) != "q" {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || v < 0 || v > 1 {
+			return "", 0
+		}
+		q = v
+	}
+	return name, q
+}
+
+// Encoder returns a pooled encoder of the coding writing to w. Release it with Release after Close.
+func (c *Compressor) Encoder(encoding string, w io.Writer) Encoder {
+	enc := c.pools[encoding].Get().(Encoder)
+	enc.Reset(w)
+	return enc
+}
+
+// Release returns a closed encoder to its pool.
+func (c *Compressor) Release(encoding string, enc Encoder) {
+	enc.Reset(io.Discard)
+	c.pools[encoding].Put(enc)
+}
+
+// encoderFactory returns the constructor of a coding's encoder at the level, or nil if unknown.
+func encoderFactory(encoding, level string) func() Encoder {
+	switch encoding {
+	case EncodingGzip:
+		lvl := pick(level, gzip.BestSpeed, gzip.DefaultCompression, gzip.BestCompression)
+		return func() Encoder {
+			w, _ := gzip.NewWriterLevel(io.Discard, lvl)
+			return w
+		}
+	case EncodingDeflate:
+		// HTTP "deflate" is the zlib format (RFC 9110).
+		lvl := pick(level, zlib.BestSpeed, zlib.DefaultCompression, zlib.BestCompression)
+		return func() Encoder {
+			w, _ := zlib.NewWriterLevel(io.Discard, lvl)
+			return w
+		}
+	case EncodingBrotli:
+		lvl := pick(level, brotli.BestSpeed, 4, 9)
+		return func() Encoder {
+			return brotli.NewWriterLevel(io.Discard, lvl)
+		}
+	case EncodingZstd:
+		lvl := pick(level, zstd.SpeedFastest, zstd.SpeedDefault, zstd.SpeedBetterCompression)
+		return func() Encoder {
+			// Browsers only decode windows up to 8 MB.
+			w, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderLevel(lvl), zstd.WithEncoderConcurrency(1),
+				zstd.WithWindowSize(8<<20))
+			return w
+		}
+	}
+	return nil
+}
+
+func pick[T any](level string, fastest, def, best T) T {
+	switch level {
+	case LevelFastest:
+		return fastest
+	case LevelBest:
+		return best
+	default:
+		return def
+	}
+}
@@ -8,6 +8,13 @@ import (
 // Unified config file flag definition, defaulting to the go-zero convention: etc/config.yaml.
 var configFile = flag.String("f", "etc/config.yaml", "the config file")
 
+// Application environments (AppConfig.Env).
+const (
+	EnvDev  = "dev"
+	EnvTest = "test"
+	EnvProd = "prod"
+)
+
 // AppConfig application configuration.
 type AppConfig struct {
 	Name    string `json:",default=app"`
@@ -19,3 +26,8 @@ type AppConfig struct {
 func ConfigFile() string {
 	return *configFile
 }
+
+// IsProd reports whether the application runs in production.
+func (c AppConfig) IsProd() bool {
+	return c.Env == EnvProd
+}
@@ -30,6 +30,15 @@ var (
 	ErrForbidden        = NewWithHTTP(20005, "forbidden", http.StatusForbidden)
 	ErrValidationFailed = NewWithHTTP(20006, "validation failed", http.StatusBadRequest)
 	ErrParseFailed      = NewWithHTTP(20007, "parse failed", http.StatusBadRequest)
+
+	ErrIdempotencyInFlight = NewWithHTTP(20008, "a request with this idempotency key is in progress", http.StatusConflict)
+	ErrIdempotencyMismatch = NewWithHTTP(20009, "idempotency key was used with a different request", http.StatusUnprocessableEntity)
+
+	ErrCSRFTokenMissing = NewWithHTTP(20010, "csrf token is missing", http.StatusForbidden)
+	ErrCSRFTokenInvalid = NewWithHTTP(20011, "csrf token is invalid", http.StatusForbidden)
+
+	ErrTenantMissing  = NewWithHTTP(20012, "tenant is missing", http.StatusBadRequest)
+	ErrTenantMismatch = NewWithHTTP(20013, "tenant does not match the token", http.StatusForbidden)
 )
 
 // ============== Authentication & authorization (21xxx) ==============
@@ -39,6 +48,19 @@ var (
 	ErrTokenExpired     = NewWithHTTP(21002, "token has expired", http.StatusUnauthorized)
 	ErrTokenMissing     = NewWithHTTP(21003, "token is missing", http.StatusUnauthorized)
 	ErrPermissionDenied = NewWithHTTP(21004, "permission denied", http.StatusForbidden)
+	ErrTokenRevoked     = NewWithHTTP(21005, "token has been revoked", http.StatusUnauthorized)
+
+	ErrRefreshTokenInvalid = NewWithHTTP(21006, "refresh token is invalid", http.StatusUnauthorized)
+	ErrRefreshTokenReused  = NewWithHTTP(21007, "refresh token reuse detected", http.StatusUnauthorized)
+	ErrIdentitySignature   = NewWithHTTP(21008, "identity signature is missing or invalid", http.StatusUnauthorized)
+	ErrIdentityExpired     = NewWithHTTP(21009, "identity signature has expired", http.StatusUnauthorized)
+	ErrAPIKeyInvalid       = NewWithHTTP(21010, "api key is invalid", http.StatusUnauthorized)
+	ErrAPIKeyExpired       = NewWithHTTP(21011, "api key has expired", http.StatusUnauthorized)
+	ErrSignatureInvalid    = NewWithHTTP(21012, "request signature is missing or invalid", http.StatusUnauthorized)
+	ErrSignatureExpired    = NewWithHTTP(21013, "request signature has expired", http.StatusUnauthorized)
+	ErrSignatureReplayed   = NewWithHTTP(21014, "request signature has already been used", http.StatusUnauthorized)
+	ErrOIDCStateInvalid    = NewWithHTTP(21015, "login state is invalid or expired", http.StatusBadRequest)
+	ErrOIDCLoginFailed     = NewWithHTTP(21016, "identity provider login failed", http.StatusUnauthorized)
 )
 
 // ============== Database (22xxx) ==============
@@ -57,4 +79,6 @@ var (
 	ErrUserAlreadyExists = New(30102, "user already exists")
 	ErrUserPasswordWrong = New(30103, "incorrect password")
 	ErrUserDisabled      = New(30104, "user is disabled")
+	ErrPasswordPolicy    = NewWithHTTP(30105, "password does not meet the policy", http.StatusBadRequest)
+	ErrUserLocked        = NewWithHTTP(30106, "too many failed login attempts, try again later", http.StatusTooManyRequests)
 )
@@ -86,6 +86,12 @@ func Code(err error) int {
 	return ErrInternal.Code
 }
 
+// IsSystemCode reports whether a business code is a system error (1xxxx, e.g. ErrInternal or
+// ErrServiceUnavailable): the request failed on the server side and may succeed on retry.
+func IsSystemCode(code int) bool {
+	return code >= 10000 && code < 20000
+}
+
 // Msg returns the error message.
 func Msg(err error) string {
 	if err == nil {
@@ -0,0 +1,70 @@
+package errcode
+
+import (
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// ErrorInfoDomain is the errdetails.ErrorInfo domain of business errors carried in gRPC statuses.
+const ErrorInfoDomain = "go-base"
+
+// GrpcStatus converts e into a gRPC status with the given code. The business code is carried in an
+// errdetails.ErrorInfo detail (Reason is the code), followed by any extra details.
+func GrpcStatus(c codes.Code, e *Error, details ...protoadapt.MessageV1) *status.Status {
+	st := status.New(c, e.Msg)
+	all := append([]protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason: strconv.Itoa(e.Code),
+		Domain: ErrorInfoDomain,
+	}}, details...)
+	if withDetails, err := st.WithDetails(all...); err == nil {
+		return withDetails
+	}
+	return st
+}
+
+// FromGrpcStatus converts a gRPC status into an error: the business code of its ErrorInfo detail
+// if present, otherwise an error mapped from the gRPC code, with the status message.
+func FromGrpcStatus(st *status.Status) *Error {
+	e := fromGrpcCode(st.Code())
+	for _, d := range st.Details() {
+		info, ok := d.(*errdetails.ErrorInfo)
+		if !ok || info.Domain != ErrorInfoDomain {
+			continue
+		}
+		if code, err := strconv.Atoi(info.Reason); err == nil {
+			e = NewWithHTTP(code, e.Msg, e.HTTPCode)
+		}
+		break
+	}
+	if msg := st.Message(); msg != "" {
+		e = e.WithMsg(msg)
+	}
+	return e
+}
+
+func fromGrpcCode(c codes.Code) *Error {
+	switch c {
+	case codes.InvalidArgument, codes.OutOfRange:
+		return ErrInvalidParam
+	case codes.NotFound:
+		return ErrNotFound
+	case codes.AlreadyExists:
+		return ErrAlreadyExists
+	case codes.Unauthenticated:
+		return ErrUnauthorized
+	case codes.PermissionDenied:
+		return ErrForbidden
+	case codes.ResourceExhausted:
+		return ErrTooManyRequests
+	case codes.Unavailable:
+		return ErrServiceUnavailable
+	case codes.DeadlineExceeded:
+		return ErrTimeout
+	default:
+		return ErrInternal
+	}
+}
@@ -0,0 +1,163 @@
+// Package httpcache caches successful responses of GET routes (see middleware.Cache), with per-route
+// TTLs and cache keys, stale-while-revalidate and in-memory LRU or Redis stores.
+package httpcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/redis"
+
+	"github.com/addls/go-base/pkg/pathmatch"
+)
+
+// Store types.
+const (
+	StoreMemory = "memory" // In-process LRU, single instance only
+	StoreRedis  = "redis"  // Shared by all instances of a cluster
+)
+
+// Cache key scopes.
+const (
+	ScopeUser   = "user"   // One entry per caller (anonymous callers share one)
+	ScopeTenant = "tenant" // One entry per tenant
+	ScopePublic = "public" // One entry for all callers
+)
+
+// Conf response cache configuration.
+type Conf struct {
+	Enabled     bool            `json:",optional"`
+	Rules       []Rule          `json:",optional"`                            // Cached routes; the first rule matching a GET request applies
+	TTL         time.Duration   `json:",default=1m"`                          // Default time responses are fresh
+	StaleTTL    time.Duration   `json:",default=0s"`                          // Default time stale responses are served while revalidating
+	MaxEntries  int             `json:",default=10000,range=[1:]"`            // Capacity of the memory store (least recently used entries are evicted)
+	MaxBodySize int             `json:",default=1048576,range=[1:]"`          // Larger responses are not cached
+	Store       string          `json:",default=memory,options=memory|redis"` // memory (single instance) or redis (cluster)
+	Redis       redis.RedisConf `json:",optional"`                            // Required when Store is redis
+	KeyPrefix   string          `json:",default=gobase:cache:"`               // Redis key prefix
+}
+
+// Rule is the cache policy of a route.
+type Rule struct {
+	Path     string        // Path pattern (see pathmatch)
+	TTL      time.Duration `json:",optional"`                                // Time responses are fresh (default Conf.TTL)
+	StaleTTL time.Duration `json:",optional"`                                // Stale-while-revalidate window (default Conf.StaleTTL)
+	Query    []string      `json:",optional"`                                // Query parameters in the key; empty means the whole query
+	Headers  []string      `json:",optional"`                                // Request headers in the key, e.g. Accept-Language
+	Scope    string        `json:",default=user,options=user|tenant|public"` // Who shares cached entries
+}
+
+// Entry is a cached response.
+type Entry struct {
+	Status     int
+	Header     http.Header `json:",omitempty"`
+	Body       []byte      `json:",omitempty"`
+	StoredAt   time.Time
+	FreshUntil time.Time // Served as is until then
+	StaleUntil time.Time // Served while revalidating until then
+}
+
+// Fresh reports whether the entry may be served without revalidation.
+func (e *Entry) Fresh(now time.Time) bool {
+	return now.Before(e.FreshUntil)
+}
+
+// Usable reports whether the entry may be served (fresh, or stale within the stale window).
+func (e *Entry) Usable(now time.Time) bool {
+	return now.Before(e.StaleUntil)
+}
+
+// Store keeps cached responses.
+type Store interface {
+	// Get returns the entry of the key, or nil if absent or expired.
+	Get(ctx context.Context, key string) (*Entry, error)
+	// Set stores the entry until its StaleUntil.
+	Set(ctx context.Context, key string, e Entry) error
+}
+
+// MustNewStore creates a store from config, panics on error.
+func MustNewStore(c Conf) Store {
+	if c.Store == StoreRedis {
+		return NewRedisStore(redis.MustNewRedis(c.Redis), c.KeyPrefix)
+	}
+	return NewMemoryStore(c.MaxEntries)
+}
+
+// Policy is a compiled Rule.
+type Policy struct {
+	pattern  pathmatch.Pattern
+	TTL      time.Duration
+	StaleTTL time.Duration
+	Scope    string
+	query    []string
+	headers  []string
+}
+
+// Compile compiles the rules of the config, applying the default TTLs.
+func Compile(c Conf) []*Policy {
+	policies := make([]*Policy, 0, len(c.Rules))
+	for _, rule := range c.Rules {
+		p := &Policy{
+			pattern:  pathmatch.Compile(rule.Path),
+			TTL:      rule.TTL,
+			StaleTTL: rule.StaleTTL,
+			Scope:    rule.Scope,
+			query:    append([]string(nil), rule.Query...),
+			headers:  append([]string(nil), rule.Headers...),
+		}
+		if p.TTL <= 0 {
+			p.TTL = c.TTL
+		}
+		if p.StaleTTL <= 0 {
+			p.StaleTTL = c.StaleTTL
+		}
+		if p.Scope == "" {
+			p.Scope = ScopeUser
+		}
+		sort.Strings(p.query)
+		policies = append(policies, p)
+	}
+	return policies
+}
+
+// Match returns the policy of the first rule matching the path, or nil.
+func Match(policies []*Policy, path string) *Policy {
+	for _, p := range policies {
+		if p.pattern.Match(path) {
+			return p
+		}
+	}
+	return nil
+}
+
+// Key builds the cache key of a request within a scope value (user or tenant id, empty for public
+// entries) from its path, the selected query parameters and request headers.
+func (p *Policy) Key(r *http.Request, scope string) string {
+	h := sha256.New()
+	h.Write([]byte(p.Scope + "\n" + scope + "\n" + r.URL.Path + "\n" + p.canonicalQuery(r.URL.Query()) + "\n"))
+	for _, name := range p.headers {
+		h.Write([]byte(strings.ToLower(name) + ":" + strings.Join(r.Header.Values(name), ",") + "\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// canonicalQuery encodes the selected query parameters sorted by name, so that parameter order
+// does not split entries.
+func (p *Policy) canonicalQuery(query url.Values) string {
+	if len(p.query) == 0 {
+		return query.Encode()
+	}
+	selected := make(url.Values, len(p.query))
+	for _, name := range p.query {
+		if values, ok := query[name]; ok {
+			selected[name] = values
+		}
+	}
+	return selected.Encode()
+}
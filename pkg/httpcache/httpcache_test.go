@@ -0,0 +1,80 @@
+package httpcache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPolicyKey(t *testing.T) {
+	policies := Compile(Conf{TTL: time.Minute, Rules: []Rule{
+		{Path: "/products", Query: []string{"page", "size"}, Headers: []string{"Accept-Language"}},
+		{Path: "/search"},
+	}})
+
+	tests := []struct {
+		name   string
+		a, b   string
+		langA  string
+		langB  string
+		scopeB string
+		same   bool
+	}{
+		{name: "same request", a: "/products?page=1", b: "/products?page=1", same: true},
+		{name: "parameter order", a: "/products?page=1&size=10", b: "/products?size=10&page=1", same: true},
+		{name: "ignored parameter", a: "/products?page=1", b: "/products?page=1&utm=x", same: true},
+		{name: "selected parameter", a: "/products?page=1", b: "/products?page=2"},
+		{name: "whole query without selection", a: "/search?q=a", b: "/search?q=b"},
+		{name: "selected header", a: "/products", b: "/products", langA: "en", langB: "de"},
+		{name: "scope value", a: "/products", b: "/products", scopeB: "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := func(target, lang, scope string) string {
+				r := httptest.NewRequest(http.MethodGet, target, nil)
+				if lang != "" {
+					r.Header.Set("Accept-Language", lang)
+				}
+				return Match(policies, r.URL.Path).Key(r, scope)
+			}
+			if same := key(tt.a, tt.langA, "") == key(tt.b, tt.langB, tt.scopeB); same != tt.same {
+				t.Errorf("same key = %v, want %v", same, tt.same)
+			}
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(2)
+	now := time.Now()
+	fresh := Entry{Status: http.StatusOK, FreshUntil: now.Add(time.Minute), StaleUntil: now.Add(time.Minute)}
+	expired := Entry{Status: http.StatusOK, FreshUntil: now.Add(-time.Minute), StaleUntil: now.Add(-time.Second)}
+
+	for _, kv := range []struct {
+		key string
+		e   Entry
+	}{{"a", fresh}, {"b", fresh}, {"expired", expired}} {
+		if err := s.Set(ctx, kv.key, kv.e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{key: "a"},             // Least recently used, evicted
+		{key: "b", want: true}, // Kept
+		{key: "expired"},       // Past its stale window
+		{key: "missing"},
+	}
+	for _, tt := range tests {
+		e, err := s.Get(ctx, tt.key)
+		if err != nil || (e != nil) != tt.want {
+			t.Errorf("Get(%q) = %v, %v; want present %v", tt.key, e, err, tt.want)
+		}
+	}
+}
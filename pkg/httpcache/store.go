@@ -0,0 +1,122 @@
+package httpcache
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/redis"
+
+	"github.com/addls/go-base/pkg/internal/redisx"
+)
+
+// ----- In-memory store -----
+
+type memoryItem struct {
+	key   string
+	entry Entry
+}
+
+// MemoryStore is an in-memory LRU response cache (single instance only).
+type MemoryStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	items      map[string]*list.Element
+	lru        *list.List // Front is the most recently used
+}
+
+// NewMemoryStore creates an in-memory store holding at most maxEntries responses.
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	if maxEntries <= 0 {
+		maxEntries = 10000
+	}
+	return &MemoryStore{
+		maxEntries: maxEntries,
+		items:      make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(_ context.Context, key string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.items[key]
+	if !ok {
+		return nil, nil
+	}
+	item := el.Value.(*memoryItem)
+	if !item.entry.Usable(time.Now()) {
+		s.removeLocked(el)
+		return nil, nil
+	}
+	s.lru.MoveToFront(el)
+	e := item.entry
+	return &e, nil
+}
+
+// Set implements Store.
+func (s *MemoryStore) Set(_ context.Context, key string, e Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.items[key]; ok {
+		el.Value.(*memoryItem).entry = e
+		s.lru.MoveToFront(el)
+		return nil
+	}
+	s.items[key] = s.lru.PushFront(&memoryItem{key: key, entry: e})
+	for s.lru.Len() > s.maxEntries {
+		s.removeLocked(s.lru.Back())
+	}
+	return nil
+}
+
+func (s *MemoryStore) removeLocked(el *list.Element) {
+	s.lru.Remove(el)
+	delete(s.items, el.Value.(*memoryItem).key)
+}
+
+// ----- Redis store -----
+
+// RedisStore is a Redis-backed response cache shared by all instances of a cluster.
+type RedisStore struct {
+	rds    *redis.Redis
+	prefix string
+}
+
+// NewRedisStore creates a Redis-backed store.
+func NewRedisStore(rds *redis.Redis, keyPrefix string) *RedisStore {
+	return &RedisStore{
+		rds:    rds,
+		prefix: keyPrefix,
+	}
+}
+
+// Get implements Store.
+func (s *RedisStore) Get(ctx context.Context, key string) (*Entry, error) {
+	val, err := s.rds.GetCtx(ctx, s.prefix+key)
+	if err != nil || val == "" {
+		return nil, err
+	}
+	var e Entry
+	if err := json.Unmarshal([]byte(val), &e); err != nil {
+		return nil, err
+	}
+	if !e.Usable(time.Now()) {
+		return nil, nil
+	}
+	return &e, nil
+}
+
+// Set implements Store.
+func (s *RedisStore) Set(ctx context.Context, key string, e Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return s.rds.SetexCtx(ctx, s.prefix+key, string(b), redisx.TTL(time.Until(e.StaleUntil)))
+}
@@ -0,0 +1,76 @@
+// Package idempotency stores the first response of requests carrying an Idempotency-Key,
+// so that client retries are answered with the stored response instead of being executed twice.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/redis"
+)
+
+// Store types.
+const (
+	StoreMemory = "memory" // In-process, single instance only
+	StoreRedis  = "redis"  // Shared by all instances of a cluster
+)
+
+// Conf idempotency configuration.
+type Conf struct {
+	Enabled   bool            `json:",optional"`
+	Header    string          `json:",default=Idempotency-Key"`             // Request header carrying the key
+	Methods   []string        `json:",optional"`                            // Methods honoring the key (default POST and PATCH)
+	Paths     []string        `json:",optional"`                            // Path patterns honoring the key (see pathmatch); empty means all
+	TTL       time.Duration   `json:",default=24h"`                         // How long completed responses are kept
+	LockTTL   time.Duration   `json:",default=30s"`                         // How long an in-flight request holds the key
+	MaxKeyLen int             `json:",default=255"`                         // Longer keys are rejected
+	Store     string          `json:",default=memory,options=memory|redis"` // memory (single instance) or redis (cluster)
+	Redis     redis.RedisConf `json:",optional"`                            // Required when Store is redis
+	KeyPrefix string          `json:",default=gobase:idem:"`                // Redis key prefix
+}
+
+// Record is the state stored for an idempotency key.
+type Record struct {
+	Fingerprint string      // Hash of the request (method, path, query and body)
+	Done        bool        // False while the first request is in flight
+	Status      int         `json:",omitempty"`
+	Header      http.Header `json:",omitempty"`
+	Body        []byte      `json:",omitempty"`
+}
+
+// Store keeps idempotency records.
+type Store interface {
+	// Begin claims the key for an in-flight request with the given fingerprint, held for lockTTL.
+	// It returns nil if the key was claimed, or the existing record otherwise.
+	Begin(ctx context.Context, key, fingerprint string, lockTTL time.Duration) (*Record, error)
+	// Complete stores the response of the request holding the key for ttl.
+	Complete(ctx context.Context, key string, rec Record, ttl time.Duration) error
+	// Release frees the key (the request failed and may be retried).
+	Release(ctx context.Context, key string) error
+}
+
+// MustNewStore creates a store from config, panics on error.
+func MustNewStore(c Conf) Store {
+	if c.Store == StoreRedis {
+		return NewRedisStore(redis.MustNewRedis(c.Redis), c.KeyPrefix)
+	}
+	return NewMemoryStore()
+}
+
+// Key builds the store key of an idempotency key within a caller scope (e.g. the user id),
+// so that different callers never share keys.
+func Key(scope, idempotencyKey string) string {
+	sum := sha256.Sum256([]byte(scope + "\n" + idempotencyKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// Fingerprint hashes the parts of a request that must match on retries.
+func Fingerprint(method, path, query string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + "\n" + path + "\n" + query + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
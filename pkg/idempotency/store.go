@@ -0,0 +1,145 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/redis"
+
+	"github.com/addls/go-base/pkg/internal/redisx"
+)
+
+// ----- In-memory store -----
+
+// sweepInterval controls how often expired records are purged from the in-memory store.
+const sweepInterval = time.Minute
+
+type memoryEntry struct {
+	rec       Record
+	expiresAt time.Time
+}
+
+// MemoryStore is an in-memory idempotency store (single instance only).
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+}
+
+// NewMemoryStore creates an in-memory idempotency store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries:   make(map[string]memoryEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+// Begin implements Store.
+func (s *MemoryStore) Begin(_ context.Context, key, fingerprint string, lockTTL time.Duration) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweepLocked(now)
+	if e, ok := s.entries[key]; ok && now.Before(e.expiresAt) {
+		rec := e.rec
+		return &rec, nil
+	}
+	s.entries[key] = memoryEntry{
+		rec:       Record{Fingerprint: fingerprint},
+		expiresAt: now.Add(lockTTL),
+	}
+	return nil, nil
+}
+
+// Complete implements Store.
+func (s *MemoryStore) Complete(_ context.Context, key string, rec Record, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec.Done = true
+	s.entries[key] = memoryEntry{
+		rec:       rec,
+		expiresAt: time.Now().Add(ttl),
+	}
+	return nil
+}
+
+// Release implements Store.
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *MemoryStore) sweepLocked(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for k, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+}
+
+// ----- Redis store -----
+
+// RedisStore is a Redis-backed idempotency store shared by all instances of a cluster.
+type RedisStore struct {
+	rds    *redis.Redis
+	prefix string
+}
+
+// NewRedisStore creates a Redis-backed idempotency store.
+func NewRedisStore(rds *redis.Redis, keyPrefix string) *RedisStore {
+	return &RedisStore{
+		rds:    rds,
+		prefix: keyPrefix,
+	}
+}
+
+// Begin implements Store.
+func (s *RedisStore) Begin(ctx context.Context, key, fingerprint string, lockTTL time.Duration) (*Record, error) {
+	b, err := json.Marshal(Record{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.rds.SetnxExCtx(ctx, s.prefix+key, string(b), redisx.TTL(lockTTL))
+	if err != nil || ok {
+		return nil, err
+	}
+
+	val, err := s.rds.GetCtx(ctx, s.prefix+key)
+	if err != nil {
+		return nil, err
+	}
+	if val == "" {
+		// Expired between SETNX and GET: report it as in flight, the client retries later.
+		return &Record{Fingerprint: fingerprint}, nil
+	}
+	var rec Record
+	if err := json.Unmarshal([]byte(val), &rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// Complete implements Store.
+func (s *RedisStore) Complete(ctx context.Context, key string, rec Record, ttl time.Duration) error {
+	rec.Done = true
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return s.rds.SetexCtx(ctx, s.prefix+key, string(b), redisx.TTL(ttl))
+}
+
+// Release implements Store.
+func (s *RedisStore) Release(ctx context.Context, key string) error {
+	_, err := s.rds.DelCtx(ctx, s.prefix+key)
+	return err
+}
@@ -0,0 +1,96 @@
+package interceptor
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/addls/go-base/pkg/accesslog"
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/errcode"
+)
+
+// AccessLogUnaryInterceptor logs every call with its method, gRPC code, business code (carried in the
+// status ErrorInfo), latency, caller, request id and, for sampled calls, the redacted messages.
+func AccessLogUnaryInterceptor(l *accesslog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if l.Skip(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		e := newAccessEntry(ctx, l, info.FullMethod, start, err)
+		if l.SampleBody() {
+			e.ReqBody = redactMessage(l, req)
+			if err == nil {
+				e.RespBody = redactMessage(l, resp)
+			}
+		}
+		l.Log(ctx, e)
+		return resp, err
+	}
+}
+
+// AccessLogStreamInterceptor is the stream variant of AccessLogUnaryInterceptor (messages are not logged).
+func AccessLogStreamInterceptor(l *accesslog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if l.Skip(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		start := time.Now()
+		err := handler(srv, ss)
+		l.Log(ss.Context(), newAccessEntry(ss.Context(), l, info.FullMethod, start, err))
+		return err
+	}
+}
+
+func newAccessEntry(ctx context.Context, l *accesslog.Logger, method string, start time.Time, err error) accesslog.Entry {
+	st := status.Convert(err)
+	e := accesslog.Entry{
+		Protocol:  accesslog.ProtocolGRPC,
+		Route:     method,
+		Path:      method,
+		Status:    int(st.Code()),
+		Duration:  time.Since(start),
+		UserID:    auth.GetUserID(ctx),
+		RequestID: auth.GetValue(ctx, accesslog.RequestIdHeader),
+	}
+	if err != nil {
+		code := errcode.FromGrpcStatus(st).Code
+		e.Code = &code
+		e.Err = err
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		e.ClientIP = p.Addr.String()
+	}
+	if headers := l.Headers(); len(headers) > 0 {
+		e.Headers = make(map[string]string, len(headers))
+		for _, h := range headers {
+			if v := auth.GetValue(ctx, h); v != "" {
+				e.Headers[h] = l.RedactHeader(h, v)
+			}
+		}
+	}
+	return e
+}
+
+// redactMessage returns the redacted JSON of a proto message (field names as in the gateway JSON).
+func redactMessage(l *accesslog.Logger, msg interface{}) string {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return ""
+	}
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		return ""
+	}
+	return l.RedactBody(b)
+}
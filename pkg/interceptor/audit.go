@@ -0,0 +1,118 @@
+package interceptor
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/addls/go-base/pkg/accesslog"
+	"github.com/addls/go-base/pkg/audit"
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/errcode"
+)
+
+// AuditUnaryInterceptor emits an audit event for every call matching an auditor rule (Rpc pattern),
+// with the caller, the action (rule action or full method), the rule IDFields of the request as
+// resource ids, the outcome business code and the client IP.
+func AuditUnaryInterceptor(a *audit.Auditor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		rule, ok := a.MatchRPC(info.FullMethod)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		ctx = audit.WithRequestInfo(ctx, rpcRequestInfo(ctx))
+		resp, err := handler(ctx, req)
+		e := newAuditEvent(ctx, rule, info.FullMethod, err)
+		e.Resource.IDs = messageIDs(req, rule.IDFields)
+		a.Emit(e)
+		return resp, err
+	}
+}
+
+// AuditStreamInterceptor is the stream variant of AuditUnaryInterceptor (no resource ids are recorded).
+func AuditStreamInterceptor(a *audit.Auditor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		rule, ok := a.MatchRPC(info.FullMethod)
+		if !ok {
+			return handler(srv, ss)
+		}
+
+		ctx := audit.WithRequestInfo(ss.Context(), rpcRequestInfo(ss.Context()))
+		err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		a.Emit(newAuditEvent(ctx, rule, info.FullMethod, err))
+		return err
+	}
+}
+
+func rpcRequestInfo(ctx context.Context) audit.RequestInfo {
+	info := audit.RequestInfo{RequestID: auth.GetValue(ctx, accesslog.RequestIdHeader)}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		info.ClientIP = p.Addr.String()
+	}
+	return info
+}
+
+func newAuditEvent(ctx context.Context, rule audit.Rule, method string, err error) audit.Event {
+	info, _ := audit.RequestInfoFromContext(ctx)
+	e := audit.Event{
+		Actor:     audit.ActorFromContext(ctx),
+		Action:    rule.Action,
+		Resource:  audit.Resource{Type: rule.Resource},
+		Outcome:   audit.Outcome{Success: err == nil},
+		ClientIP:  info.ClientIP,
+		RequestID: info.RequestID,
+		Source:    audit.SourceGRPC,
+	}
+	if e.Action == "" {
+		e.Action = method
+	}
+	if err != nil {
+		st := status.Convert(err)
+		e.Outcome.Status = int(st.Code())
+		e.Outcome.Code = errcode.FromGrpcStatus(st).Code
+		e.Outcome.Message = st.Message()
+	}
+	return e
+}
+
+// messageIDs reads the given fields (proto or JSON names) of a request message.
+func messageIDs(req interface{}, fields []string) map[string]string {
+	m, ok := req.(proto.Message)
+	if !ok || len(fields) == 0 {
+		return nil
+	}
+
+	msg := m.ProtoReflect()
+	descs := msg.Descriptor().Fields()
+	ids := make(map[string]string, len(fields))
+	for _, name := range fields {
+		fd := descs.ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = descs.ByJSONName(name)
+		}
+		if fd == nil || fd.IsList() || fd.IsMap() || fd.Message() != nil || !msg.Has(fd) {
+			continue
+		}
+		ids[name] = fmt.Sprint(msg.Get(fd).Interface())
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return ids
+}
+
+// contextStream overrides the context of a server stream.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,44 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/addls/go-base/pkg/authz"
+	"github.com/addls/go-base/pkg/errcode"
+)
+
+// AuthzUnaryInterceptor enforces a role/scope authorization policy on gRPC methods.
+func AuthzUnaryInterceptor(policy *authz.Policy) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := authorizeRPC(ctx, policy, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// AuthzStreamInterceptor is the stream variant of AuthzUnaryInterceptor.
+func AuthzStreamInterceptor(policy *authz.Policy) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorizeRPC(ss.Context(), policy, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func authorizeRPC(ctx context.Context, policy *authz.Policy, fullMethod string) error {
+	err := policy.AuthorizeRPC(ctx, fullMethod)
+	if err == nil {
+		return nil
+	}
+	e := errcode.FromError(err)
+	if errors.Is(err, errcode.ErrUnauthorized) {
+		return errcode.GrpcStatus(codes.Unauthenticated, e).Err()
+	}
+	return errcode.GrpcStatus(codes.PermissionDenied, e).Err()
+}
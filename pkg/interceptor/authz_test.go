@@ -0,0 +1,51 @@
+package interceptor
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/authz"
+	"github.com/addls/go-base/pkg/errcode"
+)
+
+func TestAuthzUnaryInterceptor(t *testing.T) {
+	policy := authz.NewPolicy(authz.PolicyConf{
+		Default: authz.EffectAllow,
+		Rules:   []authz.PolicyRule{{Rpc: "/user.UserService/*", Roles: []string{"admin"}, Effect: authz.EffectAllow}},
+	})
+
+	tests := []struct {
+		name     string
+		md       metadata.MD
+		grpcCode codes.Code
+		code     int // Business code seen by the gateway; 0 if the call succeeds
+	}{
+		{name: "admin", md: metadata.Pairs(auth.JwtUserIdHeader, "alice", auth.JwtRolesHeader, "admin"), grpcCode: codes.OK},
+		{name: "anonymous", md: metadata.MD{}, grpcCode: codes.Unauthenticated, code: errcode.ErrUnauthorized.Code},
+		{name: "missing role", md: metadata.Pairs(auth.JwtUserIdHeader, "bob", auth.JwtRolesHeader, "user"),
+			grpcCode: codes.PermissionDenied, code: errcode.ErrPermissionDenied.Code},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			_, err := AuthzUnaryInterceptor(policy)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/user.UserService/GetUser"},
+				func(context.Context, interface{}) (interface{}, error) { return "ok", nil })
+
+			st := status.Convert(err)
+			if st.Code() != tt.grpcCode {
+				t.Fatalf("grpc code = %v, want %v (%v)", st.Code(), tt.grpcCode, err)
+			}
+			if tt.code != 0 {
+				if got := errcode.FromGrpcStatus(st).Code; got != tt.code {
+					t.Errorf("business code = %d, want %d", got, tt.code)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package interceptor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/errcode"
+)
+
+func TestIdentityUnaryInterceptor(t *testing.T) {
+	signer := auth.MustNewIdentitySigner(auth.IdentitySignConf{
+		Algorithm: auth.SignAlgHmac,
+		Secret:    "identity-secret",
+		MaxAge:    time.Minute,
+	})
+	identity := map[string]string{auth.JwtUserIdHeader: "alice"}
+	ts, sig, err := signer.Sign(identity, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	staleTs, staleSig, err := signer.Sign(identity, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		md   metadata.MD
+		code int // Business code seen by the gateway; 0 if the call succeeds
+	}{
+		{name: "anonymous", md: metadata.MD{}},
+		{name: "signed", md: metadata.Pairs(auth.JwtUserIdHeader, "alice", auth.JwtTimestampHeader, ts, auth.JwtSignatureHeader, sig)},
+		{name: "signed through the gateway prefix", md: metadata.Pairs(
+			"gateway-"+auth.JwtUserIdHeader, "alice", "gateway-"+auth.JwtTimestampHeader, ts, "gateway-"+auth.JwtSignatureHeader, sig)},
+		{name: "unsigned", md: metadata.Pairs(auth.JwtUserIdHeader, "alice"), code: errcode.ErrIdentitySignature.Code},
+		{name: "spoofed user", md: metadata.Pairs(auth.JwtUserIdHeader, "admin", auth.JwtTimestampHeader, ts, auth.JwtSignatureHeader, sig),
+			code: errcode.ErrIdentitySignature.Code},
+		{name: "stale", md: metadata.Pairs(auth.JwtUserIdHeader, "alice", auth.JwtTimestampHeader, staleTs, auth.JwtSignatureHeader, staleSig),
+			code: errcode.ErrIdentityExpired.Code},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			_, err := IdentityUnaryInterceptor(signer)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test.Service/Call"},
+				func(context.Context, interface{}) (interface{}, error) { return "ok", nil })
+			if tt.code == 0 {
+				if err != nil {
+					t.Fatalf("err = %v, want nil", err)
+				}
+				return
+			}
+			st := status.Convert(err)
+			if st.Code() != codes.Unauthenticated {
+				t.Errorf("grpc code = %v, want Unauthenticated", st.Code())
+			}
+			if got := errcode.FromGrpcStatus(st).Code; got != tt.code {
+				t.Errorf("business code = %d, want %d", got, tt.code)
+			}
+		})
+	}
+}
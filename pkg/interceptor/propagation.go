@@ -0,0 +1,80 @@
+package interceptor
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/addls/go-base/pkg/auth"
+)
+
+// gatewayMetadataPrefix is the prefix the go-zero gateway adds to forwarded metadata keys.
+const gatewayMetadataPrefix = "gateway-"
+
+// PropagationUnaryClientInterceptor copies the caller identity (every x-jwt-* key, including the
+// identity signature) and the allowlisted metadata keys of the incoming request into the outgoing
+// context of downstream calls, so that auth.GetUserID works at every hop.
+// Values already set on the outgoing context by the caller take precedence.
+func PropagationUnaryClientInterceptor(allowlist ...string) grpc.UnaryClientInterceptor {
+	keys := normalizeKeys(allowlist)
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(propagate(ctx, keys), method, req, reply, cc, opts...)
+	}
+}
+
+// PropagationStreamClientInterceptor is the stream variant of PropagationUnaryClientInterceptor.
+func PropagationStreamClientInterceptor(allowlist ...string) grpc.StreamClientInterceptor {
+	keys := normalizeKeys(allowlist)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(propagate(ctx, keys), desc, cc, method, opts...)
+	}
+}
+
+func normalizeKeys(keys []string) []string {
+	normalized := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key = strings.ToLower(key); key != "" {
+			normalized = append(normalized, key)
+		}
+	}
+	return normalized
+}
+
+func propagate(ctx context.Context, allowlist []string) context.Context {
+	values := make(map[string]string)
+
+	// Identity: auth.GetClaims reads the HTTP claim context or the incoming gRPC metadata.
+	// Unprefixed keys win over the gateway- prefixed copies.
+	for key, v := range auth.GetClaims(ctx) {
+		s, ok := v.(string)
+		k := strings.TrimPrefix(key, gatewayMetadataPrefix)
+		if !ok || !auth.IsIdentityKey(k) {
+			continue
+		}
+		if _, exists := values[k]; exists && k != key {
+			continue
+		}
+		values[k] = s
+	}
+	for _, key := range allowlist {
+		if v := auth.GetValue(ctx, key); v != "" {
+			values[key] = v
+		}
+	}
+
+	outgoing, _ := metadata.FromOutgoingContext(ctx)
+	var pairs []string
+	for key, v := range values {
+		if len(outgoing.Get(key)) == 0 {
+			pairs = append(pairs, key, v)
+		}
+	}
+	if len(pairs) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
@@ -0,0 +1,63 @@
+package interceptor
+
+import (
+	"context"
+	"runtime/debug"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+
+	"github.com/addls/go-base/pkg/accesslog"
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/errcode"
+	"github.com/addls/go-base/pkg/recovery"
+)
+
+// RecoverUnaryInterceptor recovers panics of RPC methods. Recovered panics are logged with their stack,
+// passed to the recovery Reporter (see recovery.SetReporter) and answered with an Internal status
+// carrying ErrInternal and the trace id (errdetails.RequestInfo), which the gateway returns as traceId.
+func RecoverUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				err = recoverPanic(ctx, info.FullMethod, v)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// RecoverStreamInterceptor is the stream variant of RecoverUnaryInterceptor.
+func RecoverStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				err = recoverPanic(ss.Context(), info.FullMethod, v)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+func recoverPanic(ctx context.Context, method string, v interface{}) error {
+	p := recovery.Panic{
+		Value:     v,
+		Stack:     string(debug.Stack()),
+		Protocol:  recovery.ProtocolGRPC,
+		Path:      method,
+		UserID:    auth.GetUserID(ctx),
+		RequestID: auth.GetValue(ctx, accesslog.RequestIdHeader),
+		TraceID:   recovery.TraceID(ctx),
+	}
+	if pr, ok := peer.FromContext(ctx); ok && pr.Addr != nil {
+		p.ClientIP = pr.Addr.String()
+	}
+	recovery.Handle(ctx, p)
+
+	if p.TraceID == "" {
+		return errcode.GrpcStatus(codes.Internal, errcode.ErrInternal).Err()
+	}
+	return errcode.GrpcStatus(codes.Internal, errcode.ErrInternal, &errdetails.RequestInfo{RequestId: p.TraceID}).Err()
+}
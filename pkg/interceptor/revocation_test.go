@@ -0,0 +1,70 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/errcode"
+)
+
+// failingRevocationStore is a revocation store whose backend is down.
+type failingRevocationStore struct {
+	auth.RevocationStore
+}
+
+func (failingRevocationStore) IsRevoked(context.Context, string, string, time.Time) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func TestRevocationUnaryInterceptor(t *testing.T) {
+	revoked := auth.NewMemoryRevocationStore()
+	if err := revoked.RevokeToken(context.Background(), "jti-revoked", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		store      auth.RevocationStore
+		failClosed bool
+		jti        string
+		grpcCode   codes.Code
+		code       int // Business code seen by the gateway; 0 if the call succeeds
+	}{
+		{name: "valid token", store: revoked, jti: "jti-ok", grpcCode: codes.OK},
+		{name: "revoked token", store: revoked, jti: "jti-revoked", grpcCode: codes.Unauthenticated, code: errcode.ErrTokenRevoked.Code},
+		{name: "store down, fail open", store: failingRevocationStore{}, jti: "jti-ok", grpcCode: codes.OK},
+		{name: "store down, fail closed", store: failingRevocationStore{}, failClosed: true, jti: "jti-ok",
+			grpcCode: codes.Unavailable, code: errcode.ErrServiceUnavailable.Code},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+				auth.JwtUserIdHeader, "alice",
+				auth.JwtTokenIdHeader, tt.jti,
+				auth.JwtIssuedAtHeader, strconv.FormatInt(time.Now().Unix(), 10),
+			))
+			interceptor := RevocationUnaryInterceptor(tt.store, tt.failClosed)
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test.Service/Call"},
+				func(context.Context, interface{}) (interface{}, error) { return "ok", nil })
+
+			st := status.Convert(err)
+			if st.Code() != tt.grpcCode {
+				t.Fatalf("code = %v, want %v (%v)", st.Code(), tt.grpcCode, err)
+			}
+			if tt.code != 0 {
+				if got := errcode.FromGrpcStatus(st).Code; got != tt.code {
+					t.Errorf("business code = %d, want %d", got, tt.code)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package interceptor
+
+import (
+	"context"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/addls/go-base/pkg/errcode"
+	"github.com/addls/go-base/pkg/tenant"
+)
+
+// TenantRateLimitUnaryInterceptor limits the call rate of each tenant (tenant.FromContext, forwarded by
+// the gateway); calls without tenant are not limited. Limited calls fail with ResourceExhausted.
+func TenantRateLimitUnaryInterceptor(limiter tenant.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := limitTenant(ctx, limiter); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// TenantRateLimitStreamInterceptor is the stream variant of TenantRateLimitUnaryInterceptor.
+func TenantRateLimitStreamInterceptor(limiter tenant.Limiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := limitTenant(ss.Context(), limiter); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func limitTenant(ctx context.Context, limiter tenant.Limiter) error {
+	tid := tenant.FromContext(ctx)
+	if tid == "" || limiter.Allow(ctx, tid) {
+		return nil
+	}
+	logx.WithContext(ctx).Infof("tenant %s exceeded its rate limit", tid)
+	return errcode.GrpcStatus(codes.ResourceExhausted, errcode.ErrTooManyRequests).Err()
+}
@@ -0,0 +1,135 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/addls/go-base/pkg/errcode"
+	"github.com/addls/go-base/pkg/validation"
+)
+
+// Validator validates a request message, e.g. an adapter of a protovalidate validator.
+// Returning validation.Errors reports each failed field; other errors are reported as is.
+type Validator func(msg proto.Message) error
+
+// pgvFieldError is implemented by the field errors generated by protoc-gen-validate.
+type pgvFieldError interface {
+	Field() string
+	Reason() string
+	Cause() error
+}
+
+// pgvMultiError is implemented by the errors returned by the generated ValidateAll methods.
+type pgvMultiError interface {
+	AllErrors() []error
+}
+
+// ValidateUnaryInterceptor validates request messages declaring validation rules in the proto
+// (the ValidateAll or Validate methods generated by protoc-gen-validate) and with the given validators.
+// Invalid requests fail with InvalidArgument carrying errcode.ErrValidationFailed (ErrorInfo) and
+// the field violations (BadRequest).
+func ValidateUnaryInterceptor(validators ...Validator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := validateMessage(req, validators); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// ValidateStreamInterceptor is the stream variant of ValidateUnaryInterceptor: every received message is validated.
+func ValidateStreamInterceptor(validators ...Validator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &validatingStream{ServerStream: ss, validators: validators})
+	}
+}
+
+type validatingStream struct {
+	grpc.ServerStream
+	validators []Validator
+}
+
+func (s *validatingStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return validateMessage(m, s.validators)
+}
+
+func validateMessage(msg interface{}, validators []Validator) error {
+	var err error
+	switch m := msg.(type) {
+	case interface{ ValidateAll() error }:
+		err = m.ValidateAll()
+	case interface{ Validate() error }:
+		err = m.Validate()
+	}
+	if pm, ok := msg.(proto.Message); ok {
+		for _, v := range validators {
+			if err != nil {
+				break
+			}
+			err = v(pm)
+		}
+	}
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	violations := validationErrors(err)
+	fields := make([]*errdetails.BadRequest_FieldViolation, 0, len(violations))
+	for _, v := range violations {
+		fields = append(fields, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Field,
+			Description: v.Message,
+		})
+	}
+	e := errcode.ErrValidationFailed.WithMsg(violations.Error())
+	return errcode.GrpcStatus(codes.InvalidArgument, e, &errdetails.BadRequest{FieldViolations: fields}).Err()
+}
+
+// validationErrors converts a validation error into field errors.
+func validationErrors(err error) validation.Errors {
+	var errs validation.Errors
+	if errors.As(err, &errs) {
+		return errs
+	}
+	errs = collectPGV(err, "", errs)
+	if len(errs) == 0 {
+		errs = validation.Errors{{Message: err.Error()}}
+	}
+	return errs
+}
+
+// collectPGV flattens protoc-gen-validate errors (nested messages report their fields under the parent field).
+func collectPGV(err error, prefix string, errs validation.Errors) validation.Errors {
+	switch e := err.(type) {
+	case pgvMultiError:
+		for _, item := range e.AllErrors() {
+			errs = collectPGV(item, prefix, errs)
+		}
+	case pgvFieldError:
+		field := e.Field()
+		if prefix != "" {
+			field = prefix + "." + field
+		}
+		switch e.Cause().(type) {
+		case pgvMultiError, pgvFieldError:
+			return collectPGV(e.Cause(), field, errs)
+		}
+		errs = append(errs, validation.FieldError{
+			Field:   field,
+			Message: field + ": " + e.Reason(),
+		})
+	}
+	return errs
+}
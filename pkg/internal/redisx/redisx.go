@@ -0,0 +1,13 @@
+// Package redisx holds helpers shared by the Redis-backed stores of go-base.
+package redisx
+
+import "time"
+
+// TTL converts a duration into a Redis TTL in seconds, rounded up (at least one second), so that
+// entries such as denylisted tokens or nonces never expire before the time they cover.
+func TTL(d time.Duration) int {
+	if secs := int((d + time.Second - 1) / time.Second); secs > 0 {
+		return secs
+	}
+	return 1
+}
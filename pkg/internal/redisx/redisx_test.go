@@ -0,0 +1,26 @@
+package redisx
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTL(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want int
+	}{
+		{d: -time.Minute, want: 1},
+		{d: 0, want: 1},
+		{d: time.Millisecond, want: 1},
+		{d: time.Second, want: 1},
+		{d: 1500 * time.Millisecond, want: 2},
+		{d: time.Hour, want: 3600},
+		{d: time.Hour + time.Nanosecond, want: 3601},
+	}
+	for _, tt := range tests {
+		if got := TTL(tt.d); got != tt.want {
+			t.Errorf("TTL(%v) = %d, want %d", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/rest"
+
+	"github.com/addls/go-base/pkg/accesslog"
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/clientip"
+	"github.com/addls/go-base/pkg/pathmatch"
+)
+
+// maxCapturedBody bounds the response body kept for logging (bodies are truncated to MaxBodySize anyway,
+// but JSON must be complete to be redacted).
+const maxCapturedBody = 1 << 20
+
+// codePrefix matches the business code at the start of a unified response ({"code":...}).
+var codePrefix = regexp.MustCompile(`^\s*\{\s*"code"\s*:\s*(-?\d+)`)
+
+// AccessLog logs every request with its route pattern, status, business code, latency, caller,
+// request id and, for sampled requests, the redacted request and response bodies.
+// routes returns the registered routes used to resolve the route pattern (e.g. server.Routes); it may be nil.
+// Use it as the first middleware so that rejected requests are logged as well.
+func AccessLog(l *accesslog.Logger, routes func() []rest.Route) rest.Middleware {
+	resolver := &routeResolver{routes: routes}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if l.Skip(r.URL.Path) {
+				next(w, r)
+				return
+			}
+
+			start := time.Now()
+			sampled := l.SampleBody()
+			var reqBody []byte
+			if sampled && r.Body != nil {
+				reqBody, _ = io.ReadAll(r.Body)
+				r.Body = io.NopCloser(bytes.NewReader(reqBody))
+			}
+
+			ctx, identity := auth.TrackIdentity(r.Context())
+			aw := &accessWriter{ResponseWriter: w, capture: sampled}
+			next(aw, r.WithContext(ctx))
+
+			e := accesslog.Entry{
+				Protocol:  accesslog.ProtocolHTTP,
+				Method:    r.Method,
+				Route:     resolver.resolve(r),
+				Path:      r.URL.Path,
+				Status:    aw.statusCode(),
+				Code:      aw.code(),
+				Duration:  time.Since(start),
+				UserID:    accessUserID(r, identity()),
+				RequestID: r.Header.Get(accesslog.RequestIdHeader),
+				ClientIP:  clientip.FromRequest(r),
+			}
+			if headers := l.Headers(); len(headers) > 0 {
+				e.Headers = make(map[string]string, len(headers))
+				for _, h := range headers {
+					if v := r.Header.Get(h); v != "" {
+						e.Headers[h] = l.RedactHeader(h, v)
+					}
+				}
+			}
+			if sampled {
+				e.ReqBody = l.RedactBody(reqBody)
+				e.RespBody = l.RedactBody(aw.body.Bytes())
+			}
+			l.Log(r.Context(), e)
+		}
+	}
+}
+
+// accessUserID returns the caller: the identity set further down the chain (gateway JWT, API key,
+// session or forwarded identity), or the go-zero JWT claim verified before the middlewares.
+func accessUserID(r *http.Request, claims map[string]interface{}) string {
+	if uid, ok := claims[auth.JwtUserIdHeader].(string); ok && uid != "" {
+		return uid
+	}
+	if uid := auth.GetUserID(r.Context()); uid != "" {
+		return uid
+	}
+	uid, _ := r.Context().Value(auth.ClaimUserID).(string)
+	return uid
+}
+
+// routeResolver resolves the registered route pattern of a request (e.g. /users/:id).
+type routeResolver struct {
+	routes func() []rest.Route
+	once   sync.Once
+	list   []resolvedRoute
+}
+
+type resolvedRoute struct {
+	method  string
+	path    string
+	pattern pathmatch.Pattern
+}
+
+func (rr *routeResolver) resolve(r *http.Request) string {
+	if rr.routes == nil {
+		return r.URL.Path
+	}
+	// Routes are read on the first request: the gateway registers upstream routes on start.
+	rr.once.Do(func() {
+		for _, route := range rr.routes() {
+			rr.list = append(rr.list, resolvedRoute{
+				method:  route.Method,
+				path:    route.Path,
+				pattern: pathmatch.Compile(route.Path),
+			})
+		}
+		// Static segments win over path parameters, like the router does.
+		sort.SliceStable(rr.list, func(i, j int) bool {
+			return strings.Count(rr.list[i].path, ":") < strings.Count(rr.list[j].path, ":")
+		})
+	})
+
+	for _, route := range rr.list {
+		if route.method == r.Method && route.pattern.Match(r.URL.Path) {
+			return route.path
+		}
+	}
+	return r.URL.Path
+}
+
+// accessWriter records the status, the business code and (for sampled requests) the response body.
+type accessWriter struct {
+	http.ResponseWriter
+	status  int
+	head    []byte
+	capture bool
+	body    bytes.Buffer
+}
+
+func (w *accessWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *accessWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if len(w.head) < 64 {
+		n := 64 - len(w.head)
+		if n > len(b) {
+			n = len(b)
+		}
+		w.head = append(w.head, b[:n]...)
+	}
+	if w.capture && w.body.Len() < maxCapturedBody {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher (streaming responses).
+func (w *accessWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker (websockets).
+func (w *accessWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("server doesn't support hijacking")
+}
+
+func (w *accessWriter) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// code returns the business code of a unified response, if any.
+func (w *accessWriter) code() *int {
+	return unifiedCode(w.head)
+}
+
+// unifiedCode returns the business code of a unified response body, if any.
+func unifiedCode(body []byte) *int {
+	m := codePrefix.FindSubmatch(body)
+	if m == nil {
+		return nil
+	}
+	code, err := strconv.Atoi(string(m[1]))
+	if err != nil {
+		return nil
+	}
+	return &code
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/zeromicro/go-zero/rest"
+	"github.com/zeromicro/go-zero/rest/pathvar"
+
+	"github.com/addls/go-base/pkg/accesslog"
+	"github.com/addls/go-base/pkg/audit"
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/clientip"
+)
+
+// Audit emits an audit event for every request matching the auditor rules (by default every POST,
+// PUT, PATCH and DELETE), with the caller, the action (rule action or "METHOD route"), the route
+// parameters as resource ids, the outcome business code and the client IP.
+// routes returns the registered routes used to resolve the route pattern (e.g. server.Routes); it may be nil.
+// Use it before the authentication and authorization middlewares so that rejected requests are audited as well.
+func Audit(a *audit.Auditor, routes func() []rest.Route) rest.Middleware {
+	resolver := &routeResolver{routes: routes}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			rule, ok := a.MatchHTTP(r.Method, r.URL.Path)
+			if !ok {
+				next(w, r)
+				return
+			}
+
+			info := audit.RequestInfo{
+				ClientIP:  clientip.FromRequest(r),
+				RequestID: r.Header.Get(accesslog.RequestIdHeader),
+			}
+			ctx, identity := auth.TrackIdentity(audit.WithRequestInfo(r.Context(), info))
+			aw := &accessWriter{ResponseWriter: w}
+			next(aw, r.WithContext(ctx))
+
+			e := audit.Event{
+				Action:    rule.Action,
+				Resource:  audit.Resource{Type: rule.Resource, IDs: pathvar.Vars(r)},
+				Outcome:   httpOutcome(aw),
+				ClientIP:  info.ClientIP,
+				RequestID: info.RequestID,
+				Source:    audit.SourceHTTP,
+			}
+			if e.Action == "" {
+				e.Action = r.Method + " " + resolver.resolve(r)
+			}
+			if claims := identity(); claims != nil {
+				e.Actor = audit.ActorFromContext(auth.WithClaims(r.Context(), claims))
+			} else {
+				e.Actor = audit.ActorFromContext(r.Context())
+			}
+			if e.Actor.UserID == "" {
+				e.Actor.UserID = accessUserID(r, nil)
+			}
+			a.Emit(e)
+		}
+	}
+}
+
+// httpOutcome derives the outcome from the status and the business code of a unified response.
+func httpOutcome(aw *accessWriter) audit.Outcome {
+	o := audit.Outcome{Status: aw.statusCode()}
+	if code := aw.code(); code != nil {
+		o.Code = *code
+	}
+	o.Success = o.Status < http.StatusBadRequest && o.Code == 0
+	return o
+}
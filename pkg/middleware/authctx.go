@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/golang-jwt/jwt/v4/request"
+	"github.com/zeromicro/go-zero/rest"
+
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/tenant"
+)
+
+// grpcMetadataPrefix is the header prefix grpc-gateway maps into gRPC metadata.
+// The HTTP-to-HTTP gateway forwards such headers unchanged, so HTTP backends see them as-is.
+const grpcMetadataPrefix = "grpc-metadata-"
+
+// AuthContext populates the auth claim context in HTTP services, so that auth.GetUserID,
+// auth.GetUserName and auth.GetClaims work the same as in gRPC services.
+//
+// Identity is collected from:
+//   - go-zero JWT (rest.WithJwt) claims verified on this route; identity headers are then ignored;
+//   - otherwise, gateway-forwarded x-jwt-* headers (with or without the Grpc-Metadata- prefix), only
+//     if they were verified by IdentitySignature or trusted with TrustIdentityHeaders (both must run
+//     before AuthContext). Unsigned identity headers are ignored by default, as any client can send them.
+func AuthContext() rest.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			claims := make(jwt.MapClaims)
+
+			// go-zero's handler.Authorize runs before global middlewares and stores non-standard
+			// claims into context; the presence of uid means the bearer token was verified on this route.
+			if uid, ok := r.Context().Value(auth.ClaimUserID).(string); ok && uid != "" {
+				addVerifiedClaims(claims, r)
+				claims[auth.JwtUserIdHeader] = uid
+				if name, ok := r.Context().Value(auth.ClaimUserName).(string); ok && name != "" {
+					claims[auth.JwtUserNameHeader] = name
+				}
+			} else if identityTrusted(r) {
+				for key, values := range r.Header {
+					if k := identityKey(key); auth.IsIdentityKey(k) && len(values) > 0 {
+						claims[k] = values[0]
+					}
+				}
+			}
+
+			if len(claims) == 0 {
+				next(w, r)
+				return
+			}
+			next(w, r.WithContext(auth.WithClaims(r.Context(), claims)))
+		}
+	}
+}
+
+// callerScope identifies the caller of a request for per-caller storage (response cache, idempotency
+// keys) by its tenant, how it authenticated (API key id) and its user id, so that the same user id in
+// another tenant, or a key acting for its owner, never shares entries. It is empty for anonymous requests.
+func callerScope(r *http.Request) string {
+	uid := accessUserID(r, nil)
+	if uid == "" {
+		return ""
+	}
+	ctx := r.Context()
+	parts := []string{tenant.FromContext(ctx), auth.GetAuthType(ctx), auth.GetAPIKeyID(ctx), uid}
+	for i, part := range parts {
+		parts[i] = strconv.Quote(part)
+	}
+	return strings.Join(parts, ":")
+}
+
+// AuthContextMiddleware populates the auth claim context in HTTP services.
+func AuthContextMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return AuthContext()(next)
+}
+
+// addVerifiedClaims copies the claims of the (already verified) bearer token,
+// mapping jti, iat, roles and scopes to their x-jwt-* keys like the gateway does.
+func addVerifiedClaims(claims jwt.MapClaims, r *http.Request) {
+	tok, err := request.AuthorizationHeaderExtractor.ExtractToken(r)
+	if err != nil {
+		return
+	}
+	parsed, _, err := jwt.NewParser(jwt.WithJSONNumber()).ParseUnverified(tok, jwt.MapClaims{})
+	if err != nil {
+		return
+	}
+	tokenClaims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok {
+		return
+	}
+
+	for k, v := range tokenClaims {
+		claims[k] = v
+	}
+	if jti, ok := tokenClaims[jwtId].(string); ok && jti != "" {
+		claims[auth.JwtTokenIdHeader] = jti
+	}
+	if iat, ok := tokenClaims[jwtIssueAt].(json.Number); ok {
+		claims[auth.JwtIssuedAtHeader] = iat.String()
+	}
+	if roles := auth.RolesFromClaims(tokenClaims); len(roles) > 0 {
+		claims[auth.JwtRolesHeader] = auth.JoinList(roles)
+	}
+	if scopes := auth.ScopesFromClaims(tokenClaims); len(scopes) > 0 {
+		claims[auth.JwtScopesHeader] = auth.JoinList(scopes)
+	}
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/zeromicro/go-zero/rest"
+
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/errcode"
+)
+
+func TestAuthContext(t *testing.T) {
+	signer := auth.MustNewIdentitySigner(auth.IdentitySignConf{
+		Algorithm: auth.SignAlgHmac,
+		Secret:    "identity-secret",
+		MaxAge:    time.Minute,
+	})
+	ts, sig, err := signer.Sign(map[string]string{auth.JwtUserIdHeader: "alice", auth.JwtRolesHeader: "user"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := map[string]string{
+		auth.JwtUserIdHeader:    "alice",
+		auth.JwtRolesHeader:     "user",
+		auth.JwtTimestampHeader: ts,
+		auth.JwtSignatureHeader: sig,
+	}
+	forged := map[string]string{auth.JwtUserIdHeader: "mallory", auth.JwtRolesHeader: "admin"}
+
+	tests := []struct {
+		name     string
+		trust    rest.Middleware
+		headers  map[string]string
+		prefix   string // Header name prefix of the identity headers
+		verified jwt.MapClaims
+		code     int
+		uid      string
+		roles    string
+	}{
+		{name: "unsigned headers are ignored", headers: forged},
+		{name: "trusted headers", trust: TrustIdentityHeaders(), headers: forged, uid: "mallory", roles: "admin"},
+		{name: "trusted headers with the gateway prefix", trust: TrustIdentityHeaders(), headers: forged, prefix: "Grpc-Metadata-",
+			uid: "mallory", roles: "admin"},
+		{name: "signed headers", trust: IdentitySignature(signer), headers: signed, uid: "alice", roles: "user"},
+		{name: "signed headers with the gateway prefix", trust: IdentitySignature(signer), headers: signed, prefix: "Grpc-Metadata-",
+			uid: "alice", roles: "user"},
+		{name: "forged headers with a signer", trust: IdentitySignature(signer), headers: forged, code: errcode.ErrIdentitySignature.Code},
+		{name: "verified token", verified: jwt.MapClaims{"uid": "alice", "roles": []string{"user"}}, uid: "alice", roles: "user"},
+		{name: "verified token ignores trusted headers", trust: TrustIdentityHeaders(), headers: forged,
+			verified: jwt.MapClaims{"uid": "alice", "roles": []string{"user"}}, uid: "alice", roles: "user"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+			h := AuthContext()(func(w http.ResponseWriter, r *http.Request) { got = r })
+			if tt.trust != nil {
+				h = tt.trust(h)
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(tt.prefix+k, v)
+			}
+			if tt.verified != nil {
+				// What go-zero's handler.Authorize leaves behind for a verified bearer token.
+				r.Header.Set("Authorization", "Bearer "+signToken(t, testJwtSecret, tt.verified))
+				r = r.WithContext(context.WithValue(r.Context(), auth.ClaimUserID, tt.verified["uid"]))
+			}
+			w := httptest.NewRecorder()
+			h(w, r)
+
+			if got == nil {
+				if code := responseCode(w); code != tt.code {
+					t.Fatalf("code = %d, want %d", code, tt.code)
+				}
+				return
+			}
+			if tt.code != 0 {
+				t.Fatalf("request passed, want code %d", tt.code)
+			}
+			if uid := auth.GetUserID(got.Context()); uid != tt.uid {
+				t.Errorf("user id = %q, want %q", uid, tt.uid)
+			}
+			if roles := strings.Join(auth.GetRoles(got.Context()), ","); roles != tt.roles {
+				t.Errorf("roles = %q, want %q", roles, tt.roles)
+			}
+		})
+	}
+}
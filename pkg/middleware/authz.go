@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/zeromicro/go-zero/rest"
+
+	"github.com/addls/go-base/pkg/authz"
+	"github.com/addls/go-base/pkg/errcode"
+	"github.com/addls/go-base/pkg/response"
+)
+
+// Authorize enforces a role/scope authorization policy on HTTP routes.
+// It must run after the identity is in context (gateway JWT or AuthContext).
+func Authorize(policy *authz.Policy) rest.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if err := policy.AuthorizeHTTP(r.Context(), r.Method, r.URL.Path); err != nil {
+				response.Error(w, errcode.FromError(err))
+				return
+			}
+			next(w, r)
+		}
+	}
+}
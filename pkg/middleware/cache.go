@@ -0,0 +1,256 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/metric"
+	"github.com/zeromicro/go-zero/core/syncx"
+	"github.com/zeromicro/go-zero/core/threading"
+	"github.com/zeromicro/go-zero/rest"
+
+	"github.com/addls/go-base/pkg/httpcache"
+	"github.com/addls/go-base/pkg/tenant"
+)
+
+// CacheStatusHeader reports how a cached route was served: HIT, STALE (served while revalidating) or MISS.
+const CacheStatusHeader = "X-Cache"
+
+// revalidateTimeout bounds background revalidations, which outlive the request that triggered them.
+const revalidateTimeout = 30 * time.Second
+
+var metricCacheRequests = metric.NewCounterVec(&metric.CounterVecOpts{
+	Namespace: "gobase",
+	Subsystem: "httpcache",
+	Name:      "requests_total",
+	Help:      "Requests of cached routes by result.",
+	Labels:    []string{"result"}, // hit, stale, miss, bypass
+})
+
+// Cache serves GET requests of the configured routes from the response cache. Entries are keyed by
+// path, the selected query parameters and headers, and the rule scope (caller, tenant or public).
+// Only 200 responses in the unified format with code 0 are stored, unless the response carries
+// Set-Cookie or Cache-Control no-store, no-cache or private; its max-age or s-maxage caps the TTL.
+// Requests with Cache-Control no-cache skip the cached entry, no-store bypasses the cache.
+// Concurrent misses of the same entry run the handler once, and stale entries are served while
+// a single background request revalidates them. Store errors fail open.
+//
+// It must run after authentication and authorization (the cache must not serve unauthorized
+// callers) and outside ResponseMiddleware (so the unified envelope is stored).
+func Cache(c httpcache.Conf, store httpcache.Store) rest.Middleware {
+	policies := httpcache.Compile(c)
+	flight := syncx.NewSingleFlight()
+	var revalidating sync.Map
+
+	fill := func(next http.HandlerFunc, r *http.Request, policy *httpcache.Policy, key string) (*cacheRecorder, bool) {
+		rec := &cacheRecorder{header: make(http.Header), status: http.StatusOK}
+		next(rec, r)
+
+		e, ok := rec.entry(policy, c.MaxBodySize)
+		if !ok {
+			return rec, false
+		}
+		if err := store.Set(r.Context(), key, e); err != nil {
+			logx.WithContext(r.Context()).Errorf("response cache update failed: %v", err)
+		}
+		return rec, true
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				next(w, r)
+				return
+			}
+			policy := httpcache.Match(policies, r.URL.Path)
+			if policy == nil {
+				next(w, r)
+				return
+			}
+			directives := cacheControl(r.Header.Values("Cache-Control"))
+			if _, ok := directives["no-store"]; ok {
+				metricCacheRequests.Inc("bypass")
+				next(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			key := policy.Key(r, cacheScope(r, policy.Scope))
+
+			if _, ok := directives["no-cache"]; !ok {
+				e, err := store.Get(ctx, key)
+				if err != nil {
+					logx.WithContext(ctx).Errorf("response cache unavailable: %v", err)
+					metricCacheRequests.Inc("bypass")
+					next(w, r)
+					return
+				}
+				if e != nil {
+					if e.Fresh(time.Now()) {
+						metricCacheRequests.Inc("hit")
+						writeCached(w, e, "HIT")
+						return
+					}
+
+					metricCacheRequests.Inc("stale")
+					writeCached(w, e, "STALE")
+					if _, running := revalidating.LoadOrStore(key, struct{}{}); !running {
+						bg, cancel := context.WithTimeout(context.WithoutCancel(ctx), revalidateTimeout)
+						threading.GoSafe(func() {
+							defer cancel()
+							defer revalidating.Delete(key)
+							fill(next, r.Clone(bg), policy, key)
+						})
+					}
+					return
+				}
+			}
+
+			metricCacheRequests.Inc("miss")
+			v, leader, _ := flight.DoEx(key, func() (any, error) {
+				rec, cached := fill(next, r, policy, key)
+				return cacheFill{rec: rec, cached: cached}, nil
+			})
+			result := v.(cacheFill)
+			if !leader && !result.cached {
+				// Responses that may not be cached may not be shared either (e.g. Set-Cookie).
+				next(w, r)
+				return
+			}
+			result.rec.writeTo(w)
+		}
+	}
+}
+
+type cacheFill struct {
+	rec    *cacheRecorder
+	cached bool
+}
+
+// cacheScope returns the scope value of the cache key: the caller (see callerScope), the tenant or none.
+func cacheScope(r *http.Request, scope string) string {
+	switch scope {
+	case httpcache.ScopePublic:
+		return ""
+	case httpcache.ScopeTenant:
+		if tid := tenant.FromContext(r.Context()); tid != "" {
+			return tid
+		}
+		// Kept apart from the caller tenant: anonymous requests must not read entries of its members.
+		if tid := tenant.RequestedFromContext(r.Context()); tid != "" {
+			return "requested:" + tid
+		}
+		return ""
+	default:
+		return callerScope(r)
+	}
+}
+
+// cacheControl parses Cache-Control directives (lowercase names, unquoted values).
+func cacheControl(values []string) map[string]string {
+	directives := make(map[string]string)
+	for _, v := range values {
+		for _, d := range strings.Split(v, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(d), "=")
+			if name != "" {
+				directives[strings.ToLower(name)] = strings.Trim(value, `"`)
+			}
+		}
+	}
+	return directives
+}
+
+// writeCached writes a cached response with its age.
+func writeCached(w http.ResponseWriter, e *httpcache.Entry, status string) {
+	copyHeader(w.Header(), e.Header)
+	w.Header().Set("Age", strconv.Itoa(int(time.Since(e.StoredAt)/time.Second)))
+	w.Header().Set(CacheStatusHeader, status)
+	w.WriteHeader(e.Status)
+	_, _ = w.Write(e.Body)
+}
+
+// cacheRecorder records the response of a cache fill.
+type cacheRecorder struct {
+	header      http.Header
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (w *cacheRecorder) Header() http.Header {
+	return w.header
+}
+
+func (w *cacheRecorder) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.status = code
+	}
+}
+
+func (w *cacheRecorder) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
+
+// entry returns the cache entry of the recorded response, if it may be cached.
+func (w *cacheRecorder) entry(policy *httpcache.Policy, maxBodySize int) (httpcache.Entry, bool) {
+	if w.status != http.StatusOK || w.body.Len() > maxBodySize || len(w.header.Values("Set-Cookie")) > 0 {
+		return httpcache.Entry{}, false
+	}
+	if code := unifiedCode(w.body.Bytes()); code == nil || *code != 0 {
+		return httpcache.Entry{}, false
+	}
+
+	ttl := policy.TTL
+	directives := cacheControl(w.header.Values("Cache-Control"))
+	for _, d := range []string{"no-store", "no-cache", "private"} {
+		if _, ok := directives[d]; ok {
+			return httpcache.Entry{}, false
+		}
+	}
+	for _, d := range []string{"s-maxage", "max-age"} {
+		if v, ok := directives[d]; ok {
+			if secs, err := strconv.Atoi(v); err == nil {
+				if maxAge := time.Duration(secs) * time.Second; maxAge < ttl {
+					ttl = maxAge
+				}
+				break
+			}
+		}
+	}
+	if ttl <= 0 {
+		return httpcache.Entry{}, false
+	}
+
+	now := time.Now()
+	return httpcache.Entry{
+		Status:     w.status,
+		Header:     w.header.Clone(),
+		Body:       append([]byte(nil), w.body.Bytes()...),
+		StoredAt:   now,
+		FreshUntil: now.Add(ttl),
+		StaleUntil: now.Add(ttl + policy.StaleTTL),
+	}, true
+}
+
+// writeTo writes the recorded response.
+func (w *cacheRecorder) writeTo(rw http.ResponseWriter) {
+	copyHeader(rw.Header(), w.header)
+	rw.Header().Set(CacheStatusHeader, "MISS")
+	rw.WriteHeader(w.status)
+	_, _ = rw.Write(w.body.Bytes())
+}
+
+// copyHeader copies the header values (cached headers are shared by concurrent requests).
+func copyHeader(dst, src http.Header) {
+	for k, values := range src {
+		dst[k] = append([]string(nil), values...)
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/httpcache"
+	"github.com/addls/go-base/pkg/tenant"
+)
+
+func TestCacheScope(t *testing.T) {
+	alice := withIdentity(context.Background(), map[string]string{auth.JwtUserIdHeader: "alice", tenant.MetadataKey: "acme"})
+	tests := []struct {
+		name  string
+		scope string
+		first context.Context
+		retry context.Context
+		want  string // X-Cache of the retry
+	}{
+		{name: "same caller", scope: httpcache.ScopeUser, first: alice, retry: alice, want: "HIT"},
+		{name: "same user id in another tenant", scope: httpcache.ScopeUser, first: alice,
+			retry: withIdentity(context.Background(), map[string]string{auth.JwtUserIdHeader: "alice", tenant.MetadataKey: "beta"}), want: "MISS"},
+		{name: "API key of the same owner", scope: httpcache.ScopeUser, first: alice,
+			retry: withIdentity(context.Background(), map[string]string{
+				auth.JwtUserIdHeader:   "alice",
+				tenant.MetadataKey:     "acme",
+				auth.JwtAuthTypeHeader: auth.AuthTypeAPIKey,
+				auth.JwtKeyIdHeader:    "key-1",
+			}), want: "MISS"},
+		{name: "anonymous callers share an entry", scope: httpcache.ScopeUser, first: context.Background(), retry: context.Background(),
+			want: "HIT"},
+		{name: "same tenant", scope: httpcache.ScopeTenant, first: alice,
+			retry: withIdentity(context.Background(), map[string]string{auth.JwtUserIdHeader: "bob", tenant.MetadataKey: "acme"}), want: "HIT"},
+		{name: "anonymous request naming the tenant", scope: httpcache.ScopeTenant, first: alice,
+			retry: tenant.NewRequestedContext(context.Background(), "acme"), want: "MISS"},
+		{name: "public", scope: httpcache.ScopePublic, first: alice, retry: context.Background(), want: "HIT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := httpcache.Conf{
+				Rules:       []httpcache.Rule{{Path: "/profile", Scope: tt.scope}},
+				TTL:         time.Minute,
+				MaxBodySize: 1 << 20,
+			}
+			h := Cache(c, httpcache.NewMemoryStore(100))(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"code":0,"msg":"success"}`))
+			})
+			h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/profile", nil).WithContext(tt.first))
+
+			w := httptest.NewRecorder()
+			h(w, httptest.NewRequest(http.MethodGet, "/profile", nil).WithContext(tt.retry))
+			if got := w.Header().Get(CacheStatusHeader); got != tt.want {
+				t.Errorf("%s = %q, want %q", CacheStatusHeader, got, tt.want)
+			}
+		})
+	}
+}
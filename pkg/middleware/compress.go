@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/rest"
+
+	"github.com/addls/go-base/pkg/compress"
+)
+
+// Compress compresses responses with the coding negotiated from Accept-Encoding (br, zstd, gzip or
+// deflate, by q-value). Responses are buffered up to MinSize before deciding, so small responses are
+// sent as-is; streaming responses are compressed as soon as they are flushed.
+//
+// Responses already encoded, partial (206), bodiless (204, 304, HEAD) or marked Cache-Control:
+// no-transform are never compressed. Compressed responses drop Content-Length and get a weak ETag,
+// as they are a different representation (backends must compare If-None-Match weakly).
+//
+// Use it as the first middleware: every other middleware (access log, idempotency replay,
+// ResponseMiddleware) then sees the uncompressed body. The request Accept-Encoding is consumed, so
+// gateway upstreams always answer uncompressed.
+func Compress(c *compress.Compressor) rest.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if c.Skip(r.URL.Path) {
+				next(w, r)
+				return
+			}
+
+			encoding := c.Negotiate(r.Header.Get("Accept-Encoding"))
+			r.Header.Del("Accept-Encoding")
+			if r.Method == http.MethodHead {
+				encoding = ""
+			}
+
+			cw := &compressWriter{ResponseWriter: w, compressor: c, encoding: encoding}
+			defer cw.close()
+			next(cw, r)
+		}
+	}
+}
+
+// compressWriter buffers the start of the response until it can decide whether to compress it.
+type compressWriter struct {
+	http.ResponseWriter
+	compressor *compress.Compressor
+	encoding   string // Negotiated coding ("" if the client accepts none)
+
+	status  int
+	buf     []byte
+	decided bool
+	encoder compress.Encoder
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code >= 100 && code < 200 {
+		// Informational responses (e.g. 103 Early Hints) pass through.
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.status == 0 {
+		w.status = code
+	}
+	if !bodyAllowed(code) {
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.decided {
+		if w.encoder != nil {
+			return w.encoder.Write(b)
+		}
+		return w.ResponseWriter.Write(b)
+	}
+
+	w.buf = append(w.buf, b...)
+	if len(w.buf) >= w.compressor.MinSize() {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush implements http.Flusher: a flushed (streaming) response is compressed regardless of its size.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if err := w.decide(true); err != nil {
+			return
+		}
+	}
+	if w.encoder != nil {
+		if err := w.encoder.Flush(); err != nil {
+			return
+		}
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker (websockets).
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		w.decided = true
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("server doesn't support hijacking")
+}
+
+// decide writes the header, compressed or not, followed by the buffered body.
+// sizeReached is false when the response ended (or has no body) before MinSize.
+func (w *compressWriter) decide(sizeReached bool) error {
+	w.decided = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	h := w.Header()
+	compressible := w.compressible()
+	if compressible {
+		h.Add("Vary", "Accept-Encoding")
+	}
+
+	if compressible && sizeReached && w.encoding != "" {
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		w.ResponseWriter.WriteHeader(w.status)
+		w.encoder = w.compressor.Encoder(w.encoding, w.ResponseWriter)
+		_, err := w.encoder.Write(w.buf)
+		w.buf = nil
+		return err
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+// compressible reports whether the response may be compressed at all (whatever the client accepts).
+func (w *compressWriter) compressible() bool {
+	h := w.Header()
+	if !bodyAllowed(w.status) || w.status == http.StatusPartialContent ||
+		h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" ||
+		strings.Contains(strings.ToLower(h.Get("Cache-Control")), "no-transform") {
+		return false
+	}
+
+	contentType := h.Get("Content-Type")
+	if contentType == "" && len(w.buf) > 0 {
+		// Sniff now: net/http would otherwise sniff the compressed bytes.
+		contentType = http.DetectContentType(w.buf)
+		h.Set("Content-Type", contentType)
+	}
+	return w.compressor.Compressible(contentType)
+}
+
+// close finishes the response once the handler returned.
+func (w *compressWriter) close() {
+	if !w.decided {
+		if err := w.decide(len(w.buf) >= w.compressor.MinSize() && len(w.buf) > 0); err != nil {
+			logx.Errorf("write response failed: %v", err)
+		}
+	}
+	if w.encoder != nil {
+		if err := w.encoder.Close(); err != nil {
+			logx.Errorf("finish compressed response failed: %v", err)
+		}
+		w.compressor.Release(w.encoding, w.encoder)
+		w.encoder = nil
+	}
+}
+
+// bodyAllowed reports whether a response with the status may carry a body.
+func bodyAllowed(status int) bool {
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
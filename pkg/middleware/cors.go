@@ -19,7 +19,7 @@ func DefaultCorsConfig() CorsConfig {
 	return CorsConfig{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Trace-Id"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Api-Key", "Idempotency-Key", "X-CSRF-Token", "X-Trace-Id"},
 		ExposeHeaders:    []string{"Content-Length", "X-Trace-Id"},
 		AllowCredentials: false,
 		MaxAge:           86400,
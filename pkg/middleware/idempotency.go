@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/rest"
+
+	"github.com/addls/go-base/pkg/errcode"
+	"github.com/addls/go-base/pkg/idempotency"
+	"github.com/addls/go-base/pkg/pathmatch"
+	"github.com/addls/go-base/pkg/response"
+)
+
+// IdempotentReplayedHeader is set on responses replayed from the idempotency store.
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// Idempotency stores the first response of unsafe requests carrying an idempotency key
+// (scoped to the caller, see callerScope) and replays it on retries. Concurrent duplicates are
+// rejected with errcode.ErrIdempotencyInFlight, and a key reused with a different request
+// with errcode.ErrIdempotencyMismatch.
+//
+// It must run after authentication (so the caller scope is known) and outside
+// ResponseMiddleware (so the unified envelope is stored).
+func Idempotency(c idempotency.Conf, store idempotency.Store) rest.Middleware {
+	methods := map[string]bool{http.MethodPost: true, http.MethodPatch: true}
+	if len(c.Methods) > 0 {
+		methods = make(map[string]bool, len(c.Methods))
+		for _, m := range c.Methods {
+			methods[strings.ToUpper(m)] = true
+		}
+	}
+	patterns := make([]pathmatch.Pattern, 0, len(c.Paths))
+	for _, p := range c.Paths {
+		patterns = append(patterns, pathmatch.Compile(p))
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			idemKey := r.Header.Get(c.Header)
+			if idemKey == "" || !methods[r.Method] || !matchAny(patterns, r.URL.Path) {
+				next(w, r)
+				return
+			}
+			if c.MaxKeyLen > 0 && len(idemKey) > c.MaxKeyLen {
+				response.Error(w, errcode.ErrInvalidParam.WithMsg("idempotency key is too long"))
+				return
+			}
+
+			var body []byte
+			if r.Body != nil && r.Body != http.NoBody {
+				b, err := io.ReadAll(r.Body)
+				if err != nil {
+					response.Error(w, errcode.ErrInvalidParam)
+					return
+				}
+				body = b
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			ctx := r.Context()
+			scope := callerScope(r)
+			if scope == "" {
+				scope = "anonymous"
+			}
+			key := idempotency.Key(scope, idemKey)
+			fingerprint := idempotency.Fingerprint(r.Method, r.URL.Path, r.URL.RawQuery, body)
+
+			rec, err := store.Begin(ctx, key, fingerprint, c.LockTTL)
+			if err != nil {
+				// Fail open: an idempotency store outage must not block all writes.
+				logx.WithContext(ctx).Errorf("idempotency store unavailable: %v", err)
+				next(w, r)
+				return
+			}
+			if rec != nil {
+				switch {
+				case rec.Fingerprint != fingerprint:
+					response.Error(w, errcode.ErrIdempotencyMismatch)
+				case !rec.Done:
+					response.Error(w, errcode.ErrIdempotencyInFlight)
+				default:
+					replay(w, rec)
+				}
+				return
+			}
+
+			// The outcome must be stored even if the client went away: that is when it retries.
+			storeCtx := context.WithoutCancel(ctx)
+			cw := &captureWriter{ResponseWriter: w, status: http.StatusOK}
+			completed := false
+			defer func() {
+				if !completed {
+					// Panicked: free the key so that the client can retry.
+					if err := store.Release(storeCtx, key); err != nil {
+						logx.WithContext(ctx).Errorf("idempotency release failed: %v", err)
+					}
+				}
+			}()
+
+			next(cw, r)
+
+			completed = true
+			if serverFailure(cw.status, cw.body.Bytes()) {
+				// Server errors are not stored: the request may succeed on retry.
+				err = store.Release(storeCtx, key)
+			} else {
+				err = store.Complete(storeCtx, key, idempotency.Record{
+					Fingerprint: fingerprint,
+					Status:      cw.status,
+					Header:      cw.header,
+					Body:        cw.body.Bytes(),
+				}, c.TTL)
+			}
+			if err != nil {
+				logx.WithContext(ctx).Errorf("idempotency store update failed: %v", err)
+			}
+		}
+	}
+}
+
+// serverFailure reports whether a response is a server error: an HTTP 5xx, or a unified response
+// with a system error code (the gateway answers failed upstreams with HTTP 200 and e.g. ErrInternal).
+func serverFailure(status int, body []byte) bool {
+	if status >= http.StatusInternalServerError {
+		return true
+	}
+	code := unifiedCode(body)
+	return code != nil && errcode.IsSystemCode(*code)
+}
+
+// replay writes a stored response.
+func replay(w http.ResponseWriter, rec *idempotency.Record) {
+	for k, values := range rec.Header {
+		w.Header()[k] = values
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(rec.Status)
+	_, _ = w.Write(rec.Body)
+}
+
+// captureWriter writes through to the client while recording the response.
+type captureWriter struct {
+	http.ResponseWriter
+	status      int
+	header      http.Header
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (w *captureWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.status = code
+		w.header = w.Header().Clone()
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
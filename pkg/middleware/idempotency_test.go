@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/idempotency"
+	"github.com/addls/go-base/pkg/tenant"
+)
+
+// cancelAwareStore fails updates with a cancelled context, like the Redis store does.
+type cancelAwareStore struct {
+	*idempotency.MemoryStore
+}
+
+func (s cancelAwareStore) Complete(ctx context.Context, key string, rec idempotency.Record, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.MemoryStore.Complete(ctx, key, rec, ttl)
+}
+
+func (s cancelAwareStore) Release(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.MemoryStore.Release(ctx, key)
+}
+
+func newIdempotency(handler http.HandlerFunc) http.HandlerFunc {
+	c := idempotency.Conf{Header: "Idempotency-Key", TTL: time.Hour, LockTTL: time.Minute, MaxKeyLen: 255}
+	return Idempotency(c, cancelAwareStore{idempotency.NewMemoryStore()})(handler)
+}
+
+func idempotentRequest(ctx context.Context) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"sku":"a"}`))
+	r.Header.Set("Idempotency-Key", "k1")
+	return r.WithContext(ctx)
+}
+
+func withIdentity(ctx context.Context, fields map[string]string) context.Context {
+	claims := jwt.MapClaims{}
+	for k, v := range fields {
+		claims[k] = v
+	}
+	return auth.WithClaims(ctx, claims)
+}
+
+func TestIdempotencyOutcome(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		disconnect bool
+		replayed   bool
+	}{
+		{name: "success", status: http.StatusOK, body: `{"code":0,"msg":"success"}`, replayed: true},
+		{name: "business error", status: http.StatusOK, body: `{"code":20001,"msg":"invalid"}`, replayed: true},
+		{name: "server error", status: http.StatusInternalServerError, body: `{"code":10001,"msg":"internal"}`},
+		{name: "system code", status: http.StatusOK, body: `{"code":10002,"msg":"unavailable"}`},
+		{name: "client disconnects", status: http.StatusOK, body: `{"code":0,"msg":"success"}`, disconnect: true, replayed: true},
+		{name: "client disconnects on server error", status: http.StatusBadGateway, body: `{"code":10001}`, disconnect: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			calls := 0
+			h := newIdempotency(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if tt.disconnect {
+					// The client goes away while the request is processed.
+					cancel()
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			})
+			h(httptest.NewRecorder(), idempotentRequest(ctx))
+
+			w := httptest.NewRecorder()
+			h(w, idempotentRequest(context.Background()))
+			replayed := w.Header().Get(IdempotentReplayedHeader) == "true"
+			if replayed != tt.replayed {
+				t.Errorf("retry replayed = %v, want %v (handler calls %d)", replayed, tt.replayed, calls)
+			}
+			if wantCalls := map[bool]int{true: 1, false: 2}[tt.replayed]; calls != wantCalls {
+				t.Errorf("handler calls = %d, want %d", calls, wantCalls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyCallerScope(t *testing.T) {
+	alice := map[string]string{auth.JwtUserIdHeader: "alice", tenant.MetadataKey: "acme"}
+	tests := []struct {
+		name     string
+		retry    map[string]string
+		replayed bool
+	}{
+		{name: "same caller", retry: alice, replayed: true},
+		{name: "same user id in another tenant", retry: map[string]string{auth.JwtUserIdHeader: "alice", tenant.MetadataKey: "beta"}},
+		{name: "API key of the same owner", retry: map[string]string{
+			auth.JwtUserIdHeader:   "alice",
+			tenant.MetadataKey:     "acme",
+			auth.JwtAuthTypeHeader: auth.AuthTypeAPIKey,
+			auth.JwtKeyIdHeader:    "key-1",
+		}},
+		{name: "anonymous", retry: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newIdempotency(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"code":0,"msg":"success"}`))
+			})
+			h(httptest.NewRecorder(), idempotentRequest(withIdentity(context.Background(), alice)))
+
+			w := httptest.NewRecorder()
+			h(w, idempotentRequest(withIdentity(context.Background(), tt.retry)))
+			if replayed := w.Header().Get(IdempotentReplayedHeader) == "true"; replayed != tt.replayed {
+				t.Errorf("retry replayed = %v, want %v", replayed, tt.replayed)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/rest"
+
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/errcode"
+	"github.com/addls/go-base/pkg/response"
+)
+
+// StripIdentityHeaders removes client-supplied identity headers (x-jwt-*, with or without the
+// Grpc-Metadata- prefix), so that only the identity set by the gateway itself reaches backends.
+// RunGateway installs it in front of the JWT middleware.
+func StripIdentityHeaders() rest.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			for key := range r.Header {
+				if auth.IsIdentityKey(identityKey(key)) {
+					logx.WithContext(r.Context()).Infof("stripped client-supplied identity header: %s", key)
+					r.Header.Del(key)
+				}
+			}
+			next(w, r)
+		}
+	}
+}
+
+// IdentitySignature verifies the gateway-signed identity headers in HTTP services and rejects
+// requests carrying unsigned, tampered or stale identities. Requests without identity pass through.
+// Verified identity headers are trusted by AuthContext.
+func IdentitySignature(signer *auth.IdentitySigner) rest.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			fields, err := identityFields(r.Header)
+			if err == nil {
+				err = signer.VerifyFields(fields)
+			}
+			if err != nil {
+				logx.WithContext(r.Context()).Errorf("identity signature verification failed: %v", err)
+				response.Error(w, errcode.FromError(err))
+				return
+			}
+			next(w, r.WithContext(context.WithValue(r.Context(), trustedIdentityKey{}, true)))
+		}
+	}
+}
+
+// TrustIdentityHeaders makes AuthContext trust unsigned identity headers. Use it only when clients
+// cannot reach the service but through the gateway (which strips client-supplied identity headers);
+// prefer IdentitySignature otherwise.
+func TrustIdentityHeaders() rest.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			next(w, r.WithContext(context.WithValue(r.Context(), trustedIdentityKey{}, true)))
+		}
+	}
+}
+
+type trustedIdentityKey struct{}
+
+// identityTrusted reports whether the identity headers were verified or are trusted.
+func identityTrusted(r *http.Request) bool {
+	trusted, _ := r.Context().Value(trustedIdentityKey{}).(bool)
+	return trusted
+}
+
+// identityKey normalizes a header name into an identity key (lowercase, without the Grpc-Metadata- prefix).
+func identityKey(header string) string {
+	return strings.TrimPrefix(strings.ToLower(header), grpcMetadataPrefix)
+}
+
+// identityFields collects the identity fields (including timestamp and signature) from request headers.
+func identityFields(h http.Header) (map[string]string, error) {
+	fields := make(map[string]string)
+	for key, values := range h {
+		if len(values) == 0 {
+			continue
+		}
+		if err := auth.CollectIdentityField(fields, identityKey(key), values[0]); err != nil {
+			return nil, err
+		}
+	}
+	return fields, nil
+}
@@ -1,21 +1,154 @@
 package middleware
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/golang-jwt/jwt/v4/request"
 	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/rest"
-	"github.com/zeromicro/go-zero/rest/handler"
+	"github.com/zeromicro/go-zero/rest/token"
 
 	"github.com/addls/go-base/pkg/auth"
 	"github.com/addls/go-base/pkg/errcode"
+	"github.com/addls/go-base/pkg/pathmatch"
 	"github.com/addls/go-base/pkg/response"
+	"github.com/addls/go-base/pkg/tenant"
+)
+
+// Standard JWT claims.
+const (
+	jwtAudience  = "aud"
+	jwtExpire    = "exp"
+	jwtId        = "jti"
+	jwtIssueAt   = "iat"
+	jwtIssuer    = "iss"
+	jwtNotBefore = "nbf"
+	jwtSubject   = "sub"
+)
+
+var errInvalidToken = errors.New("invalid auth token")
+
+// JWT rule modes.
+const (
+	// JwtModeRequired requires a valid token (default behavior for paths without a rule).
+	JwtModeRequired = "required"
+	// JwtModeSkip skips JWT verification entirely.
+	JwtModeSkip = "skip"
+	// JwtModeOptional allows requests without a token; if a token is present it must be valid,
+	// and its claims are decoded and passed through as usual.
+	JwtModeOptional = "optional"
 )
 
 // JwtConfig JWT configuration.
 type JwtConfig struct {
-	Secret    string   // JWT secret
-	SkipPaths []string // Paths that skip JWT verification
+	Secret       string                    // JWT secret (ignored when Issuers is set)
+	SkipPaths    []string                  // Paths that skip JWT verification (path patterns, all methods)
+	Rules        []JwtRule                 // Per-route rules; the first matching rule wins and takes precedence over SkipPaths
+	Revocation   auth.RevocationStore      // Optional revocation denylist checked by jti and user cutoff
+	FailClosed   bool                      // Reject requests while the revocation store is unavailable (default: let them through)
+	Signer       *auth.IdentitySigner      // Optional signer for the forwarded identity headers
+	APIKeys      *auth.APIKeyAuthenticator // Optional API key authentication for machine clients
+	Issuers      *auth.JwtVerifier         // Optional named issuers; the issuer name is forwarded as x-jwt-issuer
+	IssuerRoutes []JwtIssuerRoute          // Accepted issuers per route, used when the matching rule lists none
+	Tenants      *tenant.Resolver          // Optional tenant resolution; the credential tenant is forwarded as x-jwt-tenant-id
+}
+
+// JwtRule describes how JWT verification applies to matching requests.
+type JwtRule struct {
+	Path    string   // Path pattern, see pathmatch.Pattern (e.g. /public/*, /users/:id/avatar)
+	Methods []string `json:",optional"`                                    // HTTP methods; empty means all methods
+	Mode    string   `json:",default=skip,options=skip|optional|required"` // skip, optional or required
+	Issuers []string `json:",optional"`                                    // Accepted issuer names; empty means all issuers
+}
+
+// JwtIssuerRoute restricts the issuers accepted on matching requests (e.g. all routes of an upstream).
+type JwtIssuerRoute struct {
+	Path    string   // Path pattern, see pathmatch.Pattern
+	Methods []string // HTTP methods; empty means all methods
+	Issuers []string // Accepted issuer names
+}
+
+type jwtRuleMatcher struct {
+	pattern pathmatch.Pattern
+	methods map[string]bool
+	mode    string
+	issuers []string
+}
+
+func newJwtRuleMatcher(path string, methods []string, mode string, issuers []string) jwtRuleMatcher {
+	m := jwtRuleMatcher{
+		pattern: pathmatch.Compile(path),
+		mode:    mode,
+		issuers: issuers,
+	}
+	if len(methods) > 0 {
+		m.methods = make(map[string]bool, len(methods))
+		for _, method := range methods {
+			m.methods[strings.ToUpper(method)] = true
+		}
+	}
+	return m
+}
+
+func newJwtRuleMatchers(cfg JwtConfig) []jwtRuleMatcher {
+	matchers := make([]jwtRuleMatcher, 0, len(cfg.Rules)+len(cfg.SkipPaths))
+	for _, rule := range cfg.Rules {
+		mode := rule.Mode
+		if mode == "" {
+			mode = JwtModeSkip
+		}
+		matchers = append(matchers, newJwtRuleMatcher(rule.Path, rule.Methods, mode, rule.Issuers))
+	}
+	for _, p := range cfg.SkipPaths {
+		matchers = append(matchers, newJwtRuleMatcher(p, nil, JwtModeSkip, nil))
+	}
+	return matchers
+}
+
+func newJwtIssuerMatchers(routes []JwtIssuerRoute) []jwtRuleMatcher {
+	matchers := make([]jwtRuleMatcher, 0, len(routes))
+	for _, route := range routes {
+		matchers = append(matchers, newJwtRuleMatcher(route.Path, route.Methods, JwtModeRequired, route.Issuers))
+	}
+	return matchers
+}
+
+// matchRule returns the first matcher matching the request, or nil.
+func matchRule(matchers []jwtRuleMatcher, r *http.Request) *jwtRuleMatcher {
+	for i := range matchers {
+		m := &matchers[i]
+		if m.methods != nil && !m.methods[r.Method] {
+			continue
+		}
+		if m.pattern.Match(r.URL.Path) {
+			return m
+		}
+	}
+	return nil
+}
+
+// ruleFor returns the JWT mode (JwtModeRequired if no rule matches) and the accepted issuers
+// (empty means all) for the request.
+func ruleFor(matchers, issuerMatchers []jwtRuleMatcher, r *http.Request) (string, []string) {
+	mode := JwtModeRequired
+	var issuers []string
+	if m := matchRule(matchers, r); m != nil {
+		mode, issuers = m.mode, m.issuers
+	}
+	if len(issuers) == 0 {
+		if m := matchRule(issuerMatchers, r); m != nil {
+			issuers = m.issuers
+		}
+	}
+	return mode, issuers
 }
 
 // responseWriter wraps http.ResponseWriter to track whether a response has been written.
@@ -42,58 +175,306 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 }
 
 // RegisterJwtMiddleware registers a JWT middleware for the Gateway.
-// It uses go-zero's handler.Authorize to verify JWT.
+// It uses go-zero's token parser to verify JWT.
 // After successful verification, JWT claims are passed through to backend services via HTTP headers.
 func RegisterJwtMiddleware(secret string, skipPaths []string) rest.Middleware {
-	skipMap := make(map[string]bool)
-	for _, path := range skipPaths {
-		skipMap[path] = true
+	return JwtWithConfig(JwtConfig{
+		Secret:    secret,
+		SkipPaths: skipPaths,
+	})
+}
+
+// JwtWithConfig is a configurable JWT middleware for the Gateway.
+// Rules select per route (path pattern + HTTP methods) whether JWT is skipped, optional or required.
+// Bearer tokens are only accepted if Secret or Issuers is set (with API keys only, they are treated
+// as missing); it panics if none of Secret, Issuers and APIKeys is set.
+func JwtWithConfig(cfg JwtConfig) rest.Middleware {
+	if cfg.Secret == "" && cfg.Issuers == nil && cfg.APIKeys == nil {
+		logx.Must(errors.New("jwt middleware: Secret, Issuers or APIKeys is required"))
 	}
+	// Never verify bearer tokens with an empty HMAC key: golang-jwt accepts it.
+	bearer := cfg.Secret != "" || cfg.Issuers != nil
+	matchers := newJwtRuleMatchers(cfg)
+	issuerMatchers := newJwtIssuerMatchers(cfg.IssuerRoutes)
+	parser := token.NewTokenParser()
+
+	unauthorized := func(w http.ResponseWriter, r *http.Request, err error) {
+		// Classify the failure so clients can tell a missing, expired (refresh) or invalid token apart.
+		reason := auth.ClassifyTokenError(err)
+		auth.ReportTokenFailure(reason)
+		logx.WithContext(r.Context()).Errorf("JWT authorization failed (%s): %v", reason, err)
 
-	// Use go-zero's Authorize handler (returns a middleware function).
-	authorizeMiddleware := handler.Authorize(secret, handler.WithUnauthorizedCallback(func(w http.ResponseWriter, r *http.Request, err error) {
-		logx.WithContext(r.Context()).Errorf("JWT authorization failed: %v", err)
 		// Use the unified error response format.
-		response.ErrorWithCode(w, errcode.ErrUnauthorized.Code, errcode.ErrUnauthorized.Msg)
-	}))
+		e := auth.TokenErrorCode(reason)
+		w.Header().Set("WWW-Authenticate", auth.WWWAuthenticate(reason))
+		response.ErrorWithCode(w, e.Code, e.Msg)
+	}
 
 	return func(next http.HandlerFunc) http.HandlerFunc {
-		// Wrap next with authorizeMiddleware to create a new handler.
-		authorizedHandler := authorizeMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Wrap ResponseWriter to track response status.
-			rw := &responseWriter{ResponseWriter: w}
+		// anonymous continues without caller identity, forwarding only the tenant requested by the client, if any.
+		anonymous := func(w http.ResponseWriter, r *http.Request) {
+			if cfg.Tenants == nil {
+				next(w, r)
+				return
+			}
+			f := newIdentityForwarder(r, 1)
+			if !forwardTenant(cfg, f, w, r, "", false) {
+				return
+			}
+			if len(f.identity) == 0 {
+				next(w, r)
+				return
+			}
+			f.next(r.Context(), cfg.Signer, w, next)
+		}
+
+		return func(w http.ResponseWriter, r *http.Request) {
+			mode, accepted := ruleFor(matchers, issuerMatchers, r)
+			if mode == JwtModeSkip {
+				anonymous(w, r)
+				return
+			}
 
-			// Extract JWT claims from context (non-standard fields).
-			// go-zero's handler.Authorize stores each non-standard field into context using the field name as the key.
+			// API key authentication (machine clients), when enabled and the key header is present.
+			if cfg.APIKeys != nil {
+				if key := r.Header.Get(cfg.APIKeys.Header()); key != "" {
+					authenticateAPIKey(cfg, w, r, key, accepted, next)
+					return
+				}
+			}
+
+			// No token (or no bearer verifier) on an optional route: let the request through anonymously.
+			if mode == JwtModeOptional {
+				if _, err := request.AuthorizationHeaderExtractor.ExtractToken(r); err != nil || !bearer {
+					anonymous(w, r)
+					return
+				}
+			}
+			if !bearer {
+				unauthorized(w, r, request.ErrNoTokenInRequest)
+				return
+			}
+
+			var (
+				issuer string
+				claims jwt.MapClaims
+			)
+			if cfg.Issuers != nil {
+				raw, err := request.AuthorizationHeaderExtractor.ExtractToken(r)
+				if err != nil {
+					unauthorized(w, r, err)
+					return
+				}
+				if issuer, claims, err = cfg.Issuers.Verify(raw, accepted); err != nil {
+					unauthorized(w, r, err)
+					return
+				}
+			} else {
+				tok, err := parser.ParseToken(r, cfg.Secret, "")
+				if err != nil {
+					unauthorized(w, r, err)
+					return
+				}
+				var ok bool
+				claims, ok = tok.Claims.(jwt.MapClaims)
+				if !tok.Valid || !ok {
+					unauthorized(w, r, errInvalidToken)
+					return
+				}
+			}
+
+			uid, _ := claims["uid"].(string)
+			jti, _ := claims[jwtId].(string)
+			iat := claimTime(claims[jwtIssueAt])
+
+			// Check the revocation denylist (by jti and by user-wide cutoff).
+			if cfg.Revocation != nil {
+				revoked, err := cfg.Revocation.IsRevoked(r.Context(), jti, uid, iat)
+				if err != nil {
+					// Fail open unless configured otherwise: a denylist outage must not take down
+					// all authenticated traffic.
+					logx.WithContext(r.Context()).Errorf("JWT revocation check failed: %v", err)
+					if cfg.FailClosed {
+						response.ErrorWithCode(w, errcode.ErrServiceUnavailable.Code, errcode.ErrServiceUnavailable.Msg)
+						return
+					}
+				} else if revoked {
+					unauthorized(w, r, errcode.ErrTokenRevoked)
+					return
+				}
+			}
+
+			// Store non-standard claims into context, using the field name as the key
+			// (same behavior as go-zero's handler.Authorize).
 			// Standard fields (sub, exp, iat, iss, aud, nbf, jti) are ignored.
 			ctx := r.Context()
-			
-			// Extract user info (using non-standard fields uid and name),
-			// and pass through to backend services via HTTP headers.
-			if uid, ok := ctx.Value("uid").(string); ok && uid != "" {
-				// grpc-gateway forwarding into gRPC metadata requires the "Grpc-Metadata-" prefix.
-				r.Header.Set("Grpc-Metadata-"+auth.JwtUserIdHeader, uid)
+			f := newIdentityForwarder(r, len(claims)+8)
+			for k, v := range claims {
+				f.identity[k] = v
+				switch k {
+				case jwtAudience, jwtExpire, jwtId, jwtIssueAt, jwtIssuer, jwtNotBefore, jwtSubject:
+				default:
+					ctx = context.WithValue(ctx, k, v)
+				}
+			}
+
+			// Pass through user info to backend services via HTTP headers.
+			f.forward(auth.JwtAuthTypeHeader, auth.AuthTypeJwt)
+			f.forward(auth.JwtIssuerHeader, issuer)
+			f.forward(auth.JwtUserIdHeader, uid)
+			if name, ok := claims["name"].(string); ok {
+				f.forward(auth.JwtUserNameHeader, name)
 			}
-			if name, ok := ctx.Value("name").(string); ok && name != "" {
-				// grpc-gateway forwarding into gRPC metadata requires the "Grpc-Metadata-" prefix.
-				r.Header.Set("Grpc-Metadata-"+auth.JwtUserNameHeader, name)
+			f.forward(auth.JwtTokenIdHeader, jti)
+			if !iat.IsZero() {
+				f.forward(auth.JwtIssuedAtHeader, strconv.FormatInt(iat.Unix(), 10))
+			}
+			f.forward(auth.JwtRolesHeader, auth.JoinList(auth.RolesFromClaims(claims)))
+			f.forward(auth.JwtScopesHeader, auth.JoinList(auth.ScopesFromClaims(claims)))
+			if cfg.Tenants != nil {
+				if !forwardTenant(cfg, f, w, r, cfg.Tenants.FromClaims(claims), true) {
+					return
+				}
 			}
 
-			// Continue to the next handler.
-			next(rw, r)
-		}))
+			f.next(ctx, cfg.Signer, w, next)
+		}
+	}
+}
 
-		return func(w http.ResponseWriter, r *http.Request) {
-			// Skip paths.
-			if skipMap[r.URL.Path] {
-				next(w, r)
-				return
-			}
+// authenticateAPIKey authenticates a machine client by API key and forwards the key owner
+// as the caller identity, the same way as JWT identities.
+// Routes restricting issuers only accept API keys if they list auth.APIKeyIssuerName.
+func authenticateAPIKey(cfg JwtConfig, w http.ResponseWriter, r *http.Request, key string, accepted []string,
+	next http.HandlerFunc) {
+	if len(accepted) > 0 && !slices.Contains(accepted, auth.APIKeyIssuerName) {
+		logx.WithContext(r.Context()).Errorf("API key authorization failed: issuer %q not accepted", auth.APIKeyIssuerName)
+		response.ErrorWithCode(w, errcode.ErrAPIKeyInvalid.Code, errcode.ErrAPIKeyInvalid.Msg)
+		return
+	}
+
+	k, err := cfg.APIKeys.Authenticate(r.Context(), key)
+	if err != nil {
+		logx.WithContext(r.Context()).Errorf("API key authorization failed: %v", err)
+		e := errcode.FromError(err)
+		if e.HTTPCode != http.StatusUnauthorized {
+			// Store failure: do not leak details to the client.
+			e = errcode.ErrServiceUnavailable
+		}
+		response.ErrorWithCode(w, e.Code, e.Msg)
+		return
+	}
+
+	// The key is a credential: never forward it to backends.
+	r.Header.Del(cfg.APIKeys.Header())
 
-			// Run authorization and the subsequent handler chain.
-			authorizedHandler.ServeHTTP(w, r)
+	f := newIdentityForwarder(r, 8)
+	f.forward(auth.JwtAuthTypeHeader, auth.AuthTypeAPIKey)
+	f.forward(auth.JwtKeyIdHeader, k.ID)
+	f.forward(auth.JwtUserIdHeader, k.Owner)
+	f.forward(auth.JwtUserNameHeader, k.Name)
+	f.forward(auth.JwtRolesHeader, auth.JoinList(k.Roles))
+	f.forward(auth.JwtScopesHeader, auth.JoinList(k.Scopes))
+	if cfg.Tenants != nil {
+		if !forwardTenant(cfg, f, w, r, k.Tenant, true) {
+			return
 		}
 	}
+
+	// Same context keys as go-zero's handler.Authorize would set for a token with uid/name claims.
+	ctx := context.WithValue(r.Context(), auth.ClaimUserID, k.Owner)
+	if k.Name != "" {
+		ctx = context.WithValue(ctx, auth.ClaimUserName, k.Name)
+	}
+	f.next(ctx, cfg.Signer, w, next)
 }
 
+// forwardTenant resolves the tenant of the request and forwards it, writing the error response on failure:
+// the tenant of the credential as tenant.MetadataKey, or else the tenant chosen by the client (header or
+// subdomain) as tenant.RequestedKey. Authenticated requests whose credential carries no tenant are
+// rejected if tenants are required.
+func forwardTenant(cfg JwtConfig, f *identityForwarder, w http.ResponseWriter, r *http.Request,
+	credentialTenant string, authenticated bool) bool {
+	tid, err := cfg.Tenants.Resolve(r, credentialTenant)
+	if err == nil && credentialTenant == "" && authenticated && cfg.Tenants.Required() {
+		err = errcode.ErrTenantMissing
+	}
+	if err != nil {
+		logx.WithContext(r.Context()).Errorf("tenant resolution failed: %v", err)
+		e := errcode.FromError(err)
+		response.ErrorWithCode(w, e.Code, e.Msg)
+		return false
+	}
+	if credentialTenant != "" {
+		f.forward(tenant.MetadataKey, tid)
+	} else {
+		f.forward(tenant.RequestedKey, tid)
+	}
+	return true
+}
 
+// identityForwarder passes the caller identity through to backend services via HTTP headers
+// and records it for gateway-local handlers.
+type identityForwarder struct {
+	r        *http.Request
+	identity jwt.MapClaims
+}
+
+func newIdentityForwarder(r *http.Request, size int) *identityForwarder {
+	return &identityForwarder{
+		r:        r,
+		identity: make(jwt.MapClaims, size),
+	}
+}
+
+// forward sets an identity header (empty values are skipped).
+// grpc-gateway forwarding into gRPC metadata requires the "Grpc-Metadata-" prefix.
+func (f *identityForwarder) forward(key, value string) {
+	if value == "" {
+		return
+	}
+	f.r.Header.Set("Grpc-Metadata-"+key, value)
+	f.identity[key] = value
+}
+
+// next signs the forwarded identity (if a signer is set) and continues to the next handler.
+func (f *identityForwarder) next(ctx context.Context, signer *auth.IdentitySigner, w http.ResponseWriter, next http.HandlerFunc) {
+	// Sign the forwarded identity so backends can reject spoofed x-jwt-* metadata.
+	if signer != nil {
+		fields := make(map[string]string)
+		for k, v := range f.identity {
+			if s, ok := v.(string); ok && auth.IsIdentityKey(k) {
+				fields[k] = s
+			}
+		}
+		ts, sig, err := signer.Sign(fields, time.Now())
+		if err != nil {
+			logx.WithContext(ctx).Errorf("sign forwarded identity failed: %v", err)
+			response.Error(w, errcode.ErrInternal)
+			return
+		}
+		f.forward(auth.JwtTimestampHeader, ts)
+		f.forward(auth.JwtSignatureHeader, sig)
+	}
+	// Make the identity available to gateway-local handlers through the auth accessors as well.
+	ctx = auth.WithClaims(ctx, f.identity)
+
+	// Wrap ResponseWriter to track response status, then continue to the next handler.
+	next(&responseWriter{ResponseWriter: w}, f.r.WithContext(ctx))
+}
+
+// claimTime converts a numeric date claim (json.Number or float64) into time.
+func claimTime(v interface{}) time.Time {
+	switch t := v.(type) {
+	case json.Number:
+		if n, err := t.Int64(); err == nil {
+			return time.Unix(n, 0)
+		}
+		if f, err := t.Float64(); err == nil {
+			return time.Unix(int64(f), 0)
+		}
+	case float64:
+		return time.Unix(int64(t), 0)
+	}
+	return time.Time{}
+}
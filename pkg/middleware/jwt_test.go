@@ -0,0 +1,230 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/errcode"
+)
+
+const testJwtSecret = "test-secret-at-least-256-bits-long"
+
+func signToken(t *testing.T, key string, claims jwt.MapClaims) string {
+	t.Helper()
+	if _, ok := claims["exp"]; !ok {
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+	}
+	if _, ok := claims["iat"]; !ok {
+		claims["iat"] = time.Now().Unix()
+	}
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// serveJwt runs a request through the JWT middleware and returns the response code
+// (0 if the request reached the handler) and the request seen by the handler.
+func serveJwt(cfg JwtConfig, r *http.Request) (int, *http.Request) {
+	var got *http.Request
+	h := JwtWithConfig(cfg)(func(w http.ResponseWriter, r *http.Request) { got = r })
+	w := httptest.NewRecorder()
+	h(w, r)
+	if got != nil {
+		return 0, got
+	}
+	return responseCode(w), nil
+}
+
+// responseCode returns the business code of a unified response (0 for an empty body).
+func responseCode(w *httptest.ResponseRecorder) int {
+	var body struct {
+		Code int `json:"code"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+	return body.Code
+}
+
+// newAPIKeys returns an API key authenticator reading X-Api-Key with the given keys.
+func newAPIKeys(t *testing.T, entries ...auth.APIKeyEntry) *auth.APIKeyAuthenticator {
+	t.Helper()
+	store, err := auth.NewConfigAPIKeyStore(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return auth.NewAPIKeyAuthenticator("X-Api-Key", store)
+}
+
+func bearerRequest(path, token string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+// failingRevocationStore is a revocation store whose backend is down.
+type failingRevocationStore struct {
+	auth.RevocationStore
+}
+
+func (failingRevocationStore) IsRevoked(context.Context, string, string, time.Time) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func TestJwtRevocation(t *testing.T) {
+	revoked := auth.NewMemoryRevocationStore()
+	ctx := context.Background()
+	if err := revoked.RevokeToken(ctx, "jti-revoked", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	cutoff := time.Now()
+	if err := revoked.RevokeUser(ctx, "mallory", cutoff, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		store      auth.RevocationStore
+		failClosed bool
+		claims     jwt.MapClaims
+		code       int
+	}{
+		{name: "valid token", store: revoked, claims: jwt.MapClaims{"uid": "alice", "jti": "jti-ok"}},
+		{name: "revoked jti", store: revoked, claims: jwt.MapClaims{"uid": "alice", "jti": "jti-revoked"}, code: errcode.ErrTokenRevoked.Code},
+		{name: "issued in the second of the user cutoff", store: revoked, claims: jwt.MapClaims{"uid": "mallory", "jti": "jti-new", "iat": cutoff.Unix()},
+			code: errcode.ErrTokenRevoked.Code},
+		{name: "store down, fail open", store: failingRevocationStore{}, claims: jwt.MapClaims{"uid": "alice"}},
+		{name: "store down, fail closed", store: failingRevocationStore{}, failClosed: true, claims: jwt.MapClaims{"uid": "alice"},
+			code: errcode.ErrServiceUnavailable.Code},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := JwtConfig{Secret: testJwtSecret, Revocation: tt.store, FailClosed: tt.failClosed}
+			code, _ := serveJwt(cfg, bearerRequest("/orders", signToken(t, testJwtSecret, tt.claims)))
+			if code != tt.code {
+				t.Errorf("code = %d, want %d", code, tt.code)
+			}
+		})
+	}
+}
+
+func TestJwtSkipPaths(t *testing.T) {
+	cfg := JwtConfig{Secret: testJwtSecret, SkipPaths: []string{"/public/*"}}
+	tests := []struct {
+		name string
+		path string
+		code int
+	}{
+		{name: "skipped", path: "/public/plans"},
+		{name: "protected", path: "/admin/users", code: errcode.ErrTokenMissing.Code},
+		{name: "dot segments", path: "/public/../admin/users", code: errcode.ErrTokenMissing.Code},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.URL.Path = tt.path
+			if code, _ := serveJwt(cfg, r); code != tt.code {
+				t.Errorf("code = %d, want %d", code, tt.code)
+			}
+		})
+	}
+}
+
+func TestJwtAPIKeysOnly(t *testing.T) {
+	cfg := JwtConfig{
+		Rules:   []JwtRule{{Path: "/catalog", Mode: JwtModeOptional}},
+		APIKeys: newAPIKeys(t, auth.APIKeyEntry{ID: "k1", Hash: auth.HashAPIKey("key-1"), Owner: "svc"}),
+	}
+	// Without a secret, a token signed with the empty HMAC key must not be accepted.
+	forged := signToken(t, "", jwt.MapClaims{"uid": "admin"})
+
+	tests := []struct {
+		name   string
+		path   string
+		token  string
+		apiKey string
+		code   int
+		uid    string
+	}{
+		{name: "API key", path: "/orders", apiKey: "key-1", uid: "svc"},
+		{name: "unknown API key", path: "/orders", apiKey: "key-2", code: errcode.ErrAPIKeyInvalid.Code},
+		{name: "bearer token", path: "/orders", token: forged, code: errcode.ErrTokenMissing.Code},
+		{name: "bearer token on an optional route", path: "/catalog", token: forged},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bearerRequest(tt.path, tt.token)
+			if tt.apiKey != "" {
+				r.Header.Set("X-Api-Key", tt.apiKey)
+			}
+			code, got := serveJwt(cfg, r)
+			if code != tt.code {
+				t.Fatalf("code = %d, want %d", code, tt.code)
+			}
+			if got != nil && auth.GetUserID(got.Context()) != tt.uid {
+				t.Errorf("user id = %q, want %q", auth.GetUserID(got.Context()), tt.uid)
+			}
+		})
+	}
+}
+
+func TestJwtIssuerRoutes(t *testing.T) {
+	issuers, err := auth.NewJwtVerifier([]auth.JwtIssuerConf{
+		{Name: "web", Secret: testJwtSecret},
+		{Name: "partner", Secret: "partner-secret-at-least-256-bits"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := JwtConfig{
+		Issuers: issuers,
+		IssuerRoutes: []JwtIssuerRoute{
+			{Path: "/partner/*", Issuers: []string{"partner"}},
+			{Path: "/machine/*", Issuers: []string{"partner", auth.APIKeyIssuerName}},
+		},
+		APIKeys: newAPIKeys(t, auth.APIKeyEntry{ID: "k1", Hash: auth.HashAPIKey("key-1"), Owner: "svc"}),
+	}
+	web := signToken(t, testJwtSecret, jwt.MapClaims{"uid": "alice"})
+	partner := signToken(t, "partner-secret-at-least-256-bits", jwt.MapClaims{"uid": "p1"})
+
+	tests := []struct {
+		name   string
+		path   string
+		token  string
+		apiKey string
+		code   int
+		issuer string
+	}{
+		{name: "any issuer", path: "/orders", token: web, issuer: "web"},
+		{name: "accepted issuer", path: "/partner/orders", token: partner, issuer: "partner"},
+		{name: "issuer not accepted", path: "/partner/orders", token: web, code: errcode.ErrTokenInvalid.Code},
+		{name: "API key on an unrestricted route", path: "/orders", apiKey: "key-1", issuer: ""},
+		{name: "API key on a restricted route", path: "/partner/orders", apiKey: "key-1", code: errcode.ErrAPIKeyInvalid.Code},
+		{name: "API key on a route admitting API keys", path: "/machine/jobs", apiKey: "key-1", issuer: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bearerRequest(tt.path, tt.token)
+			if tt.apiKey != "" {
+				r.Header.Set("X-Api-Key", tt.apiKey)
+			}
+			code, got := serveJwt(cfg, r)
+			if code != tt.code {
+				t.Fatalf("code = %d, want %d", code, tt.code)
+			}
+			if got != nil && auth.GetIssuerName(got.Context()) != tt.issuer {
+				t.Errorf("issuer = %q, want %q", auth.GetIssuerName(got.Context()), tt.issuer)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ func DefaultMiddlewares() []rest.Middleware {
 	return []rest.Middleware{
 		RecoverMiddleware,
 		CorsMiddleware,
+		AuthContextMiddleware,
 	}
 }
 
@@ -4,23 +4,42 @@ import (
 	"net/http"
 	"runtime/debug"
 
-	"github.com/zeromicro/go-zero/core/logx"
-
+	"github.com/addls/go-base/pkg/accesslog"
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/clientip"
 	"github.com/addls/go-base/pkg/errcode"
+	"github.com/addls/go-base/pkg/recovery"
 	"github.com/addls/go-base/pkg/response"
 )
 
-// Recover is a panic recovery middleware.
+// Recover is a panic recovery middleware. Recovered panics are logged with their stack, passed to
+// the recovery Reporter (see recovery.SetReporter) and answered with ErrInternal and the trace id.
 func Recover() func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
+			ctx, identity := auth.TrackIdentity(r.Context())
 			defer func() {
-				if err := recover(); err != nil {
-					logx.WithContext(r.Context()).Errorf("panic recovered: %v\n%s", err, debug.Stack())
-					response.Error(w, errcode.ErrInternal)
+				if v := recover(); v != nil {
+					if v == http.ErrAbortHandler {
+						// Deliberate abort of the response: let net/http handle it.
+						panic(v)
+					}
+					traceID := recovery.TraceID(r.Context())
+					recovery.Handle(r.Context(), recovery.Panic{
+						Value:     v,
+						Stack:     string(debug.Stack()),
+						Protocol:  recovery.ProtocolHTTP,
+						Method:    r.Method,
+						Path:      r.URL.Path,
+						UserID:    accessUserID(r, identity()),
+						RequestID: r.Header.Get(accesslog.RequestIdHeader),
+						TraceID:   traceID,
+						ClientIP:  clientip.FromRequest(r),
+					})
+					response.ErrorWithTrace(w, errcode.ErrInternal, traceID)
 				}
 			}()
-			next(w, r)
+			next(w, r.WithContext(ctx))
 		}
 	}
 }
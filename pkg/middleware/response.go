@@ -57,6 +57,14 @@ func ResponseMiddleware() rest.Middleware {
 			rawBody := rw.body.Bytes()
 			status := rw.statusCode
 
+			// Redirects (e.g. the OIDC login) are not API responses: pass them through unchanged
+			// so that browsers follow the Location header.
+			if status >= http.StatusMultipleChoices && status < http.StatusBadRequest {
+				w.WriteHeader(status)
+				_, _ = w.Write(rawBody)
+				return
+			}
+
 			// If the original response is empty, return a unified empty success response.
 			if len(rawBody) == 0 {
 				response.Ok(w)
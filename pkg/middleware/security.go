@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/zeromicro/go-zero/rest"
+
+	"github.com/addls/go-base/pkg/pathmatch"
+)
+
+// Default security header values, used when the corresponding SecurityHeadersConf field is empty.
+// The CSP suits JSON APIs; routes serving HTML need a CSPRoutes override.
+const (
+	DefaultHSTS               = "max-age=31536000; includeSubDomains"
+	DefaultCSP                = "default-src 'none'; frame-ancestors 'none'"
+	DefaultFrameOptions       = "DENY"
+	DefaultReferrerPolicy     = "no-referrer"
+	DefaultPermissionsPolicy  = "accelerometer=(), camera=(), geolocation=(), gyroscope=(), microphone=(), payment=(), usb=()"
+	defaultContentTypeOptions = "nosniff"
+)
+
+// SecurityHeaderOmit as a SecurityHeadersConf value omits the header.
+const SecurityHeaderOmit = "-"
+
+// SecurityHeadersConf security headers configuration. Empty values use the defaults above.
+type SecurityHeadersConf struct {
+	Enabled           bool       `json:",optional"` // Apply in every environment (applied by default when App.Env is prod)
+	Disabled          bool       `json:",optional"` // Do not apply, even in prod
+	HSTS              string     `json:",optional"` // Strict-Transport-Security (default DefaultHSTS)
+	CSP               string     `json:",optional"` // Content-Security-Policy (default DefaultCSP)
+	CSPReportOnly     bool       `json:",optional"` // Send CSPs as Content-Security-Policy-Report-Only
+	CSPReportURI      string     `json:",optional"` // Appended to CSPs as report-uri
+	CSPRoutes         []CSPRoute `json:",optional"` // Per-route CSPs; the first matching route wins
+	FrameOptions      string     `json:",optional"` // X-Frame-Options (default DENY)
+	ReferrerPolicy    string     `json:",optional"` // Referrer-Policy (default no-referrer)
+	PermissionsPolicy string     `json:",optional"` // Permissions-Policy (default DefaultPermissionsPolicy)
+}
+
+// CSPRoute overrides the CSP of matching requests.
+type CSPRoute struct {
+	Path       string   // Path pattern, see pathmatch.Pattern
+	Methods    []string `json:",optional"` // HTTP methods; empty means all methods
+	Policy     string   // Content-Security-Policy of the route ("-" omits it)
+	ReportOnly bool     `json:",optional"` // Report-only for this route (e.g. while rolling out a new policy)
+}
+
+type cspRoute struct {
+	pattern pathmatch.Pattern
+	methods map[string]bool
+	header  string
+	policy  string
+}
+
+func (c *cspRoute) match(r *http.Request) bool {
+	if c.methods != nil && !c.methods[r.Method] {
+		return false
+	}
+	return c.pattern.Match(r.URL.Path)
+}
+
+// SecurityHeaders sets HSTS, CSP, X-Content-Type-Options, X-Frame-Options, Referrer-Policy and
+// Permissions-Policy on every response. Headers are set before the handler runs, so handlers may
+// still override them. CSP violations are reported to CSPReportURI if set.
+func SecurityHeaders(c SecurityHeadersConf) rest.Middleware {
+	static := map[string]string{
+		"Strict-Transport-Security": orDefault(c.HSTS, DefaultHSTS),
+		"X-Content-Type-Options":    defaultContentTypeOptions,
+		"X-Frame-Options":           orDefault(c.FrameOptions, DefaultFrameOptions),
+		"Referrer-Policy":           orDefault(c.ReferrerPolicy, DefaultReferrerPolicy),
+		"Permissions-Policy":        orDefault(c.PermissionsPolicy, DefaultPermissionsPolicy),
+	}
+	for k, v := range static {
+		if v == SecurityHeaderOmit {
+			delete(static, k)
+		}
+	}
+
+	csp := cspHeader(c.CSPReportOnly)
+	policy := withReportURI(orDefault(c.CSP, DefaultCSP), c.CSPReportURI)
+	routes := make([]cspRoute, 0, len(c.CSPRoutes))
+	for _, route := range c.CSPRoutes {
+		cr := cspRoute{
+			pattern: pathmatch.Compile(route.Path),
+			header:  cspHeader(c.CSPReportOnly || route.ReportOnly),
+			policy:  withReportURI(route.Policy, c.CSPReportURI),
+		}
+		if len(route.Methods) > 0 {
+			cr.methods = make(map[string]bool, len(route.Methods))
+			for _, method := range route.Methods {
+				cr.methods[strings.ToUpper(method)] = true
+			}
+		}
+		routes = append(routes, cr)
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			for k, v := range static {
+				h.Set(k, v)
+			}
+
+			header, value := csp, policy
+			for i := range routes {
+				if routes[i].match(r) {
+					header, value = routes[i].header, routes[i].policy
+					break
+				}
+			}
+			if value != SecurityHeaderOmit {
+				h.Set(header, value)
+			}
+
+			next(w, r)
+		}
+	}
+}
+
+func cspHeader(reportOnly bool) string {
+	if reportOnly {
+		return "Content-Security-Policy-Report-Only"
+	}
+	return "Content-Security-Policy"
+}
+
+func withReportURI(policy, uri string) string {
+	if uri == "" || policy == SecurityHeaderOmit || policy == "" {
+		return policy
+	}
+	return strings.TrimSuffix(strings.TrimSpace(policy), ";") + "; report-uri " + uri
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/rest"
+
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/errcode"
+	"github.com/addls/go-base/pkg/pathmatch"
+	"github.com/addls/go-base/pkg/response"
+	"github.com/addls/go-base/pkg/session"
+)
+
+// Session loads the session cookie and exposes the session identity through the auth accessors
+// (auth.GetUserID, auth.GetRoles, ...) and session.FromContext.
+// Requests already carrying an identity (bearer token or gateway headers) are left untouched,
+// so it must run after AuthContext.
+func Session(m *session.Manager) rest.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if auth.GetUserID(r.Context()) != "" {
+				next(w, r)
+				return
+			}
+
+			s, err := m.Get(r)
+			if err != nil {
+				// Treat store outages as anonymous: protected routes reject the request anyway.
+				logx.WithContext(r.Context()).Errorf("load session failed: %v", err)
+			}
+			if s == nil {
+				next(w, r)
+				return
+			}
+
+			claims := make(jwt.MapClaims, len(s.Identity.Claims)+5)
+			for k, v := range s.Identity.Claims {
+				claims[k] = v
+			}
+			claims[auth.JwtAuthTypeHeader] = auth.AuthTypeSession
+			claims[auth.JwtUserIdHeader] = s.Identity.UserID
+			if s.Identity.UserName != "" {
+				claims[auth.JwtUserNameHeader] = s.Identity.UserName
+			}
+			if len(s.Identity.Roles) > 0 {
+				claims[auth.JwtRolesHeader] = auth.JoinList(s.Identity.Roles)
+			}
+			if len(s.Identity.Scopes) > 0 {
+				claims[auth.JwtScopesHeader] = auth.JoinList(s.Identity.Scopes)
+			}
+
+			ctx := session.WithSession(auth.WithClaims(r.Context(), claims), s)
+			next(w, r.WithContext(ctx))
+		}
+	}
+}
+
+// CSRF rejects unsafe requests (POST, PUT, PATCH, DELETE, ...) authenticated by a session cookie
+// unless they carry a valid CSRF token header. Bearer and anonymous requests are not affected.
+// It must run after Session.
+func CSRF(m *session.Manager) rest.Middleware {
+	c := m.Conf().CSRF
+	skip := make([]pathmatch.Pattern, 0, len(c.SkipPaths))
+	for _, p := range c.SkipPaths {
+		skip = append(skip, pathmatch.Compile(p))
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			s := session.FromContext(r.Context())
+			if s == nil || isSafeMethod(r.Method) || (len(skip) > 0 && matchAny(skip, r.URL.Path)) {
+				next(w, r)
+				return
+			}
+
+			if r.Header.Get(c.Header) == "" {
+				response.Error(w, errcode.ErrCSRFTokenMissing)
+				return
+			}
+			if !m.VerifyCSRF(r, s) {
+				logx.WithContext(r.Context()).Errorf("CSRF token mismatch: uid=%s", s.Identity.UserID)
+				response.Error(w, errcode.ErrCSRFTokenInvalid)
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+// isSafeMethod reports whether the method is safe (RFC 9110), i.e. exempt from CSRF checks.
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/rest"
+
+	"github.com/addls/go-base/pkg/errcode"
+	"github.com/addls/go-base/pkg/pathmatch"
+	"github.com/addls/go-base/pkg/response"
+	"github.com/addls/go-base/pkg/signature"
+)
+
+// RequestSignature verifies HMAC request signatures (see package signature) on the given
+// path patterns; with no patterns every request must be signed.
+func RequestSignature(verifier *signature.Verifier, paths ...string) rest.Middleware {
+	patterns := make([]pathmatch.Pattern, 0, len(paths))
+	for _, p := range paths {
+		patterns = append(patterns, pathmatch.Compile(p))
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if !matchAny(patterns, r.URL.Path) {
+				next(w, r)
+				return
+			}
+			if err := verifier.Verify(r); err != nil {
+				logx.WithContext(r.Context()).Errorf("request signature verification failed: %v", err)
+				e := errcode.FromError(err)
+				if e.HTTPCode != http.StatusUnauthorized {
+					// Nonce store failure: do not leak details to the client.
+					e = errcode.ErrServiceUnavailable
+				}
+				response.Error(w, e)
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+// matchAny reports whether the path matches any pattern; an empty list matches every path.
+func matchAny(patterns []pathmatch.Pattern, path string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if p.Match(path) {
+			return true
+		}
+	}
+	return false
+}
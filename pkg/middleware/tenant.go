@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/rest"
+
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/errcode"
+	"github.com/addls/go-base/pkg/response"
+	"github.com/addls/go-base/pkg/tenant"
+)
+
+// Tenant resolves the tenant in HTTP services and makes it available through tenant.FromContext.
+// The tenant of the caller identity (forwarded by the gateway, or the tenant claim of a go-zero JWT
+// verified on this route) wins; a header or subdomain naming another tenant is rejected. Without a
+// caller tenant, the header or subdomain tenant is only available through tenant.RequestedFromContext.
+// It must run after the identity is in context (AuthContext). The gateway resolves tenants in the
+// JWT middleware instead (JwtConfig.Tenants).
+func Tenant(resolver *tenant.Resolver) rest.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			tokenTenant := tenant.FromContext(ctx)
+			if tokenTenant == "" {
+				tokenTenant, _ = ctx.Value(resolver.Claim()).(string)
+			}
+
+			tid, err := resolver.Resolve(r, tokenTenant)
+			if err == nil && tokenTenant == "" && resolver.Required() && auth.GetUserID(ctx) != "" {
+				err = errcode.ErrTenantMissing
+			}
+			if err != nil {
+				logx.WithContext(ctx).Errorf("tenant resolution failed: %v", err)
+				response.Error(w, errcode.FromError(err))
+				return
+			}
+			if tid == "" {
+				next(w, r)
+				return
+			}
+			if tokenTenant == "" {
+				next(w, r.WithContext(tenant.NewRequestedContext(ctx, tid)))
+				return
+			}
+			next(w, r.WithContext(tenant.NewContext(ctx, tid)))
+		}
+	}
+}
+
+// TenantRateLimit limits the request rate of each tenant (tenant.FromContext); requests without
+// tenant, including anonymous requests naming a tenant themselves, are not limited. It must run
+// after the tenant is resolved (JWT middleware or Tenant).
+func TenantRateLimit(limiter tenant.Limiter) rest.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if tid := tenant.FromContext(r.Context()); tid != "" && !limiter.Allow(r.Context(), tid) {
+				logx.WithContext(r.Context()).Infof("tenant %s exceeded its rate limit", tid)
+				response.Error(w, errcode.ErrTooManyRequests)
+				return
+			}
+			next(w, r)
+		}
+	}
+}
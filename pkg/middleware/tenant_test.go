@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/errcode"
+	"github.com/addls/go-base/pkg/tenant"
+)
+
+func TestJwtTenant(t *testing.T) {
+	cfg := JwtConfig{
+		Secret:  testJwtSecret,
+		Rules:   []JwtRule{{Path: "/public/*", Mode: JwtModeSkip}},
+		APIKeys: newAPIKeys(t, auth.APIKeyEntry{ID: "k1", Hash: auth.HashAPIKey("key-acme"), Owner: "svc", Tenant: "acme"}),
+		Tenants: tenant.NewResolver(tenant.Conf{Enabled: true}),
+	}
+
+	tests := []struct {
+		name      string
+		path      string
+		claims    jwt.MapClaims
+		apiKey    string
+		header    string
+		code      int
+		tenant    string // Forwarded as tenant.MetadataKey
+		requested string // Forwarded as tenant.RequestedKey
+	}{
+		{name: "token tenant", path: "/orders", claims: jwt.MapClaims{"uid": "alice", "tid": "acme"}, tenant: "acme"},
+		{name: "token tenant and matching header", path: "/orders", claims: jwt.MapClaims{"uid": "alice", "tid": "acme"}, header: "acme",
+			tenant: "acme"},
+		{name: "token tenant and other header", path: "/orders", claims: jwt.MapClaims{"uid": "alice", "tid": "acme"}, header: "beta",
+			code: errcode.ErrTenantMismatch.Code},
+		{name: "token without tenant names one", path: "/orders", claims: jwt.MapClaims{"uid": "alice"}, header: "acme", requested: "acme"},
+		{name: "anonymous names a tenant", path: "/public/plans", header: "acme", requested: "acme"},
+		{name: "API key tenant", path: "/orders", apiKey: "key-acme", tenant: "acme"},
+		{name: "API key and other header", path: "/orders", apiKey: "key-acme", header: "beta", code: errcode.ErrTenantMismatch.Code},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.claims != nil {
+				r = bearerRequest(tt.path, signToken(t, testJwtSecret, tt.claims))
+			}
+			if tt.apiKey != "" {
+				r.Header.Set("X-Api-Key", tt.apiKey)
+			}
+			if tt.header != "" {
+				r.Header.Set("X-Tenant-Id", tt.header)
+			}
+			code, got := serveJwt(cfg, r)
+			if code != tt.code {
+				t.Fatalf("code = %d, want %d", code, tt.code)
+			}
+			if got == nil {
+				return
+			}
+			if v := got.Header.Get("Grpc-Metadata-" + tenant.MetadataKey); v != tt.tenant {
+				t.Errorf("forwarded tenant = %q, want %q", v, tt.tenant)
+			}
+			if v := got.Header.Get("Grpc-Metadata-" + tenant.RequestedKey); v != tt.requested {
+				t.Errorf("forwarded requested tenant = %q, want %q", v, tt.requested)
+			}
+			if v := tenant.FromContext(got.Context()); v != tt.tenant {
+				t.Errorf("tenant.FromContext = %q, want %q", v, tt.tenant)
+			}
+		})
+	}
+}
+
+func TestTenant(t *testing.T) {
+	tests := []struct {
+		name      string
+		conf      tenant.Conf
+		identity  map[string]string
+		header    string
+		code      int
+		tenant    string
+		requested string
+	}{
+		{name: "caller tenant", identity: map[string]string{auth.JwtUserIdHeader: "alice", tenant.MetadataKey: "acme"}, tenant: "acme"},
+		{name: "caller tenant and other header", identity: map[string]string{auth.JwtUserIdHeader: "alice", tenant.MetadataKey: "acme"},
+			header: "beta", code: errcode.ErrTenantMismatch.Code},
+		{name: "anonymous names a tenant", header: "acme", requested: "acme"},
+		{name: "required tenant missing", conf: tenant.Conf{Required: true}, identity: map[string]string{auth.JwtUserIdHeader: "alice"},
+			header: "acme", code: errcode.ErrTenantMissing.Code},
+		{name: "required tenant, anonymous", conf: tenant.Conf{Required: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+			h := Tenant(tenant.NewResolver(tt.conf))(func(w http.ResponseWriter, r *http.Request) { got = r })
+			r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			if tt.header != "" {
+				r.Header.Set("X-Tenant-Id", tt.header)
+			}
+			r = r.WithContext(withIdentity(context.Background(), tt.identity))
+			w := httptest.NewRecorder()
+			h(w, r)
+
+			if got == nil {
+				if code := responseCode(w); code != tt.code {
+					t.Fatalf("code = %d, want %d", code, tt.code)
+				}
+				return
+			}
+			if tt.code != 0 {
+				t.Fatalf("request passed, want code %d", tt.code)
+			}
+			if v := tenant.FromContext(got.Context()); v != tt.tenant {
+				t.Errorf("tenant.FromContext = %q, want %q", v, tt.tenant)
+			}
+			if v := tenant.RequestedFromContext(got.Context()); v != tt.requested {
+				t.Errorf("tenant.RequestedFromContext = %q, want %q", v, tt.requested)
+			}
+		})
+	}
+}
+
+func TestTenantRateLimit(t *testing.T) {
+	limiter := tenant.NewMemoryLimiter(tenant.RateLimitConf{Rate: 1, Burst: 1})
+	h := TenantRateLimit(limiter)(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		code int
+	}{
+		{name: "first request of the tenant", ctx: tenant.NewContext(context.Background(), "acme")},
+		{name: "tenant over its limit", ctx: tenant.NewContext(context.Background(), "acme"), code: errcode.ErrTooManyRequests.Code},
+		{name: "other tenant", ctx: tenant.NewContext(context.Background(), "beta")},
+		{name: "requested tenant is not limited", ctx: tenant.NewRequestedContext(context.Background(), "acme")},
+		{name: "no tenant", ctx: context.Background()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h(w, httptest.NewRequest(http.MethodGet, "/orders", nil).WithContext(tt.ctx))
+			if code := responseCode(w); code != tt.code {
+				t.Errorf("code = %d, want %d", code, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,381 @@
+// Package oidc implements an OpenID Connect relying party (authorization code flow with PKCE).
+//
+// The gateway redirects users to the provider, validates the returned ID token against the
+// provider's discovery document and JWKS, and converts the identity into a go-base access token,
+// so that subsequent requests are verified and forwarded (x-jwt-*) like any other token.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+
+	"github.com/addls/go-base/pkg/auth"
+)
+
+// discoveryPath is the well-known discovery document path (OpenID Connect Discovery 1.0).
+const discoveryPath = "/.well-known/openid-configuration"
+
+// jwksRefreshInterval limits JWKS refetches triggered by unknown key ids.
+const jwksRefreshInterval = time.Minute
+
+// Conf OpenID Connect relying party configuration.
+type Conf struct {
+	Enabled      bool            `json:",optional"`
+	Issuer       string          // Provider issuer URL (discovery is fetched from Issuer + /.well-known/openid-configuration)
+	ClientID     string          // OAuth 2.0 client id registered at the provider
+	ClientSecret string          `json:",optional"` // Empty for public clients (PKCE only)
+	RedirectURL  string          // Absolute callback URL registered at the provider
+	Scopes       []string        `json:",optional"`                            // Default: openid profile email
+	LoginPath    string          `json:",default=/auth/oidc/login"`            // Starts the login redirect
+	CallbackPath string          `json:",default=/auth/oidc/callback"`         // Handles the provider callback
+	UserClaim    string          `json:",default=sub"`                         // ID token claim used as the user id
+	NameClaim    string          `json:",default=name"`                        // ID token claim used as the user name
+	RolesClaim   string          `json:",default=roles"`                       // ID token claim holding the roles (optional in tokens)
+	StateTTL     time.Duration   `json:",default=10m"`                         // How long a login may take
+	StateCookie  string          `json:",default=gobase_oidc"`                 // Cookie binding the login to the browser that started it
+	Timeout      time.Duration   `json:",default=10s"`                         // Provider HTTP timeout
+	Store        string          `json:",default=memory,options=memory|redis"` // Login state store: memory (single instance) or redis (cluster)
+	Redis        redis.RedisConf `json:",optional"`                            // Required when Store is redis
+	KeyPrefix    string          `json:",default=gobase:oidc:"`                // Redis key prefix
+}
+
+// Discovery is the subset of the provider metadata used by the relying party.
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+// loginState is stored between the login redirect and the callback.
+type loginState struct {
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+	Redirect string `json:"redirect,omitempty"`
+	Binding  string `json:"binding"` // Hash of the browser binding (state cookie)
+}
+
+// tokenResponse is the provider token endpoint response.
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// RelyingParty talks to one OpenID provider.
+type RelyingParty struct {
+	conf   Conf
+	client *http.Client
+	states StateStore
+
+	mu        sync.Mutex
+	discovery *Discovery
+	keys      map[string]interface{}
+	keysAt    time.Time
+}
+
+// NewRelyingParty creates a RelyingParty. Provider metadata is discovered lazily on first use,
+// so the gateway starts even while the provider is unreachable.
+func NewRelyingParty(c Conf, states StateStore) (*RelyingParty, error) {
+	if c.Issuer == "" || c.ClientID == "" || c.RedirectURL == "" {
+		return nil, fmt.Errorf("oidc: Issuer, ClientID and RedirectURL are required")
+	}
+	if len(c.Scopes) == 0 {
+		c.Scopes = []string{"openid", "profile", "email"}
+	}
+	if c.StateCookie == "" {
+		c.StateCookie = "gobase_oidc"
+	}
+	return &RelyingParty{
+		conf:   c,
+		client: &http.Client{Timeout: c.Timeout},
+		states: states,
+	}, nil
+}
+
+// MustNewRelyingParty creates a RelyingParty with the state store from config, panics on error.
+func MustNewRelyingParty(c Conf) *RelyingParty {
+	rp, err := NewRelyingParty(c, MustNewStateStore(c))
+	logx.Must(err)
+	return rp
+}
+
+// Conf returns the relying party configuration.
+func (rp *RelyingParty) Conf() Conf {
+	return rp.conf
+}
+
+// NewBinding returns a random browser binding for a login, to be set in the state cookie
+// (see authhandler.OIDCLoginHandler) and passed to AuthCodeURL and Exchange.
+func NewBinding() string {
+	return randomString()
+}
+
+// AuthCodeURL starts a login: it stores a new state (PKCE verifier, nonce, the optional
+// post-login redirect and the hash of the browser binding) and returns the provider authorization URL.
+func (rp *RelyingParty) AuthCodeURL(ctx context.Context, redirect, binding string) (string, error) {
+	if binding == "" {
+		return "", errors.New("oidc: browser binding is required")
+	}
+	d, err := rp.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	state := randomString()
+	ls := loginState{
+		Verifier: randomString(),
+		Nonce:    randomString(),
+		Redirect: redirect,
+		Binding:  hashBinding(binding),
+	}
+	data, err := json.Marshal(ls)
+	if err != nil {
+		return "", err
+	}
+	if err := rp.states.Save(ctx, state, string(data), rp.conf.StateTTL); err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(ls.Verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {rp.conf.ClientID},
+		"redirect_uri":          {rp.conf.RedirectURL},
+		"scope":                 {strings.Join(rp.conf.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {ls.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange completes a login: it consumes the state, checks that the callback comes from the browser
+// that started the login (binding, RFC 6749 section 10.12), exchanges the code and validates the ID token.
+// It returns the go-base identity, the ID token claims and the post-login redirect.
+func (rp *RelyingParty) Exchange(ctx context.Context, state, code, binding string) (auth.Identity, jwt.MapClaims, string, error) {
+	var ls loginState
+	data, err := rp.states.Take(ctx, state)
+	if err != nil {
+		return auth.Identity{}, nil, "", err
+	}
+	if data == "" || json.Unmarshal([]byte(data), &ls) != nil {
+		return auth.Identity{}, nil, "", ErrStateInvalid
+	}
+	if binding == "" || subtle.ConstantTimeCompare([]byte(hashBinding(binding)), []byte(ls.Binding)) != 1 {
+		return auth.Identity{}, nil, "", ErrStateInvalid
+	}
+
+	d, err := rp.Discover(ctx)
+	if err != nil {
+		return auth.Identity{}, nil, "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {rp.conf.RedirectURL},
+		"client_id":     {rp.conf.ClientID},
+		"code_verifier": {ls.Verifier},
+	}
+	if rp.conf.ClientSecret != "" {
+		form.Set("client_secret", rp.conf.ClientSecret)
+	}
+	var tr tokenResponse
+	if err := rp.postForm(ctx, d.TokenEndpoint, form, &tr); err != nil {
+		return auth.Identity{}, nil, "", err
+	}
+	if tr.Error != "" || tr.IDToken == "" {
+		return auth.Identity{}, nil, "", fmt.Errorf("oidc: token exchange failed: %s %s", tr.Error, tr.ErrorDescription)
+	}
+
+	claims, err := rp.VerifyIDToken(ctx, tr.IDToken, ls.Nonce)
+	if err != nil {
+		return auth.Identity{}, nil, "", err
+	}
+	id, err := rp.identity(claims)
+	return id, claims, ls.Redirect, err
+}
+
+// VerifyIDToken validates an ID token: signature (JWKS), issuer, audience, expiry (exp and iat are
+// required) and nonce.
+func (rp *RelyingParty) VerifyIDToken(ctx context.Context, idToken, nonce string) (jwt.MapClaims, error) {
+	d, err := rp.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}))
+	tok, err := parser.Parse(idToken, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return rp.key(ctx, d, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("oidc: invalid id token: %w", err)
+	}
+	claims, ok := tok.Claims.(jwt.MapClaims)
+	if !ok || !tok.Valid {
+		return nil, fmt.Errorf("oidc: invalid id token")
+	}
+	now := time.Now().Unix()
+	if !claims.VerifyExpiresAt(now, true) || !claims.VerifyIssuedAt(now, true) {
+		return nil, fmt.Errorf("oidc: id token exp or iat is missing or invalid")
+	}
+	if !claims.VerifyIssuer(d.Issuer, true) {
+		return nil, fmt.Errorf("oidc: id token issuer mismatch")
+	}
+	if !claims.VerifyAudience(rp.conf.ClientID, true) {
+		return nil, fmt.Errorf("oidc: id token audience mismatch")
+	}
+	if aud, ok := claims["aud"].([]interface{}); ok && len(aud) > 1 && claims["azp"] != rp.conf.ClientID {
+		return nil, fmt.Errorf("oidc: id token authorized party mismatch")
+	}
+	if claims["nonce"] != nonce {
+		return nil, fmt.Errorf("oidc: id token nonce mismatch")
+	}
+	return claims, nil
+}
+
+// Discover returns the provider metadata (fetched once and cached).
+func (rp *RelyingParty) Discover(ctx context.Context) (*Discovery, error) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	if rp.discovery != nil {
+		return rp.discovery, nil
+	}
+
+	var d Discovery
+	if err := rp.getJSON(ctx, strings.TrimSuffix(rp.conf.Issuer, "/")+discoveryPath, &d); err != nil {
+		return nil, err
+	}
+	if d.Issuer != rp.conf.Issuer {
+		return nil, fmt.Errorf("oidc: discovery issuer %q does not match %q", d.Issuer, rp.conf.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JwksURI == "" {
+		return nil, fmt.Errorf("oidc: incomplete discovery document")
+	}
+	rp.discovery = &d
+	return rp.discovery, nil
+}
+
+// key returns the JWKS key with the given id, refetching the key set for unknown ids.
+func (rp *RelyingParty) key(ctx context.Context, d *Discovery, kid string) (interface{}, error) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	if k, ok := rp.lookupKey(kid); ok {
+		return k, nil
+	}
+	if time.Since(rp.keysAt) < jwksRefreshInterval && rp.keys != nil {
+		return nil, fmt.Errorf("oidc: unknown key id %q", kid)
+	}
+
+	var set jsonWebKeySet
+	if err := rp.getJSON(ctx, d.JwksURI, &set); err != nil {
+		return nil, err
+	}
+	rp.keys = set.publicKeys()
+	rp.keysAt = time.Now()
+
+	if k, ok := rp.lookupKey(kid); ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("oidc: unknown key id %q", kid)
+}
+
+// lookupKey finds a key by id; tokens without kid match a key set holding a single key.
+func (rp *RelyingParty) lookupKey(kid string) (interface{}, bool) {
+	if kid == "" && len(rp.keys) == 1 {
+		for _, k := range rp.keys {
+			return k, true
+		}
+	}
+	k, ok := rp.keys[kid]
+	return k, ok
+}
+
+// identity converts ID token claims into a go-base identity.
+func (rp *RelyingParty) identity(claims jwt.MapClaims) (auth.Identity, error) {
+	uid, _ := claims[rp.conf.UserClaim].(string)
+	if uid == "" {
+		return auth.Identity{}, fmt.Errorf("oidc: id token has no %q claim", rp.conf.UserClaim)
+	}
+	name, _ := claims[rp.conf.NameClaim].(string)
+	return auth.Identity{
+		UserID:   uid,
+		UserName: name,
+		Roles:    auth.RolesFromClaims(jwt.MapClaims{auth.ClaimRoles: claims[rp.conf.RolesClaim]}),
+	}, nil
+}
+
+func (rp *RelyingParty) getJSON(ctx context.Context, u string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	return rp.do(req, v)
+}
+
+func (rp *RelyingParty) postForm(ctx context.Context, u string, form url.Values, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return rp.do(req, v)
+}
+
+func (rp *RelyingParty) do(req *http.Request, v interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := rp.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("oidc: %s %s: %w", req.Method, req.URL, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	// Token endpoint errors come back as 400 with an error body (RFC 6749 5.2).
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusBadRequest {
+		return fmt.Errorf("oidc: %s %s: unexpected status %d", req.Method, req.URL, resp.StatusCode)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// hashBinding hashes a browser binding, so that the state store never holds the cookie value.
+func hashBinding(binding string) string {
+	sum := sha256.Sum256([]byte(binding))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// randomString returns a random 256-bit URL-safe string (state, nonce and PKCE verifier).
+func randomString() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
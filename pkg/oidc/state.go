@@ -0,0 +1,120 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/redis"
+
+	"github.com/addls/go-base/pkg/internal/redisx"
+)
+
+// Store types.
+const (
+	StoreMemory = "memory" // In-process, single instance only
+	StoreRedis  = "redis"  // Shared by all instances of a cluster
+)
+
+// ErrStateInvalid is returned for unknown, expired or already used login states.
+var ErrStateInvalid = errors.New("oidc: login state is invalid or expired")
+
+// StateStore keeps login states between the redirect and the callback.
+type StateStore interface {
+	Save(ctx context.Context, state, data string, ttl time.Duration) error
+	// Take returns and deletes the state data ("" if absent), so that each state is used once.
+	Take(ctx context.Context, state string) (string, error)
+}
+
+// MustNewStateStore creates a state store from config, panics on error.
+func MustNewStateStore(c Conf) StateStore {
+	if c.Store == StoreRedis {
+		return NewRedisStateStore(redis.MustNewRedis(c.Redis), c.KeyPrefix)
+	}
+	return NewMemoryStateStore()
+}
+
+// ----- In-memory store -----
+
+// sweepInterval controls how often expired states are purged from the in-memory store.
+const sweepInterval = time.Minute
+
+type memoryState struct {
+	data      string
+	expiresAt time.Time
+}
+
+// MemoryStateStore is an in-memory state store (single instance only).
+type MemoryStateStore struct {
+	mu        sync.Mutex
+	states    map[string]memoryState
+	lastSweep time.Time
+}
+
+// NewMemoryStateStore creates an in-memory state store.
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{
+		states:    make(map[string]memoryState),
+		lastSweep: time.Now(),
+	}
+}
+
+// Save implements StateStore.
+func (s *MemoryStateStore) Save(_ context.Context, state, data string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.lastSweep = now
+		for k, st := range s.states {
+			if !now.Before(st.expiresAt) {
+				delete(s.states, k)
+			}
+		}
+	}
+	s.states[state] = memoryState{
+		data:      data,
+		expiresAt: now.Add(ttl),
+	}
+	return nil
+}
+
+// Take implements StateStore.
+func (s *MemoryStateStore) Take(_ context.Context, state string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.states[state]
+	delete(s.states, state)
+	if !ok || !time.Now().Before(st.expiresAt) {
+		return "", nil
+	}
+	return st.data, nil
+}
+
+// ----- Redis store -----
+
+// RedisStateStore is a Redis-backed state store shared by all instances of a cluster.
+type RedisStateStore struct {
+	rds    *redis.Redis
+	prefix string
+}
+
+// NewRedisStateStore creates a Redis-backed state store.
+func NewRedisStateStore(rds *redis.Redis, keyPrefix string) *RedisStateStore {
+	return &RedisStateStore{
+		rds:    rds,
+		prefix: keyPrefix,
+	}
+}
+
+// Save implements StateStore.
+func (s *RedisStateStore) Save(ctx context.Context, state, data string, ttl time.Duration) error {
+	return s.rds.SetexCtx(ctx, s.prefix+state, data, redisx.TTL(ttl))
+}
+
+// Take implements StateStore.
+func (s *RedisStateStore) Take(ctx context.Context, state string) (string, error) {
+	return s.rds.GetDelCtx(ctx, s.prefix+state)
+}
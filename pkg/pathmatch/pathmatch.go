@@ -0,0 +1,99 @@
+// Package pathmatch provides route pattern matching shared by go-base middlewares, interceptors and policies.
+package pathmatch
+
+import (
+	"path"
+	"strings"
+)
+
+// Pattern is a compiled route pattern used by components that apply per-route rules.
+//
+// Supported syntax (matched segment by segment against the cleaned r.URL.Path):
+//   - Exact segments:      /ping, /api/v1/users
+//   - Path parameters:     /users/:id/avatar, /users/{id}/avatar (match exactly one segment)
+//   - Segment globs:       /files/*.png, /v?/users (path.Match syntax within one segment)
+//   - Single wildcard:     /users/*/avatar (matches exactly one segment)
+//   - Trailing wildcard:   /public/* (matches everything below /public/)
+//   - Multi-segment:       /static/**/index.html (** matches zero or more segments)
+//
+// gRPC full method names are matched the same way: /user.UserService/*, /user.*/Get*.
+type Pattern struct {
+	raw      string
+	segments []string
+}
+
+// Compile compiles a path pattern.
+func Compile(pattern string) Pattern {
+	return Pattern{
+		raw:      pattern,
+		segments: splitPath(pattern),
+	}
+}
+
+// String returns the original pattern.
+func (p Pattern) String() string {
+	return p.raw
+}
+
+// Match reports whether the request path matches the pattern. The path is cleaned first (like
+// the go-zero router does), so that dot segments cannot make a request match another rule than
+// the route it is dispatched to, e.g. /public/../admin/users is matched as /admin/users.
+func (p Pattern) Match(urlPath string) bool {
+	return matchSegments(p.segments, splitPath(path.Clean("/"+urlPath)))
+}
+
+func matchSegments(pattern, segs []string) bool {
+	for i, ps := range pattern {
+		switch {
+		case ps == "**":
+			rest := pattern[i+1:]
+			// ** matches zero or more segments: try every possible split.
+			for j := 0; j <= len(segs); j++ {
+				if matchSegments(rest, segs[j:]) {
+					return true
+				}
+			}
+			return false
+		case ps == "*" && i == len(pattern)-1:
+			// Trailing wildcard matches the remaining path (at least one segment).
+			return len(segs) > 0
+		}
+
+		if len(segs) == 0 {
+			return false
+		}
+		if !matchSegment(ps, segs[0]) {
+			return false
+		}
+		segs = segs[1:]
+	}
+	return len(segs) == 0
+}
+
+func matchSegment(pattern, seg string) bool {
+	switch {
+	case pattern == "*":
+		return true
+	case strings.HasPrefix(pattern, ":"):
+		return seg != ""
+	case strings.HasPrefix(pattern, "{") && strings.HasSuffix(pattern, "}"):
+		return seg != ""
+	case strings.ContainsAny(pattern, "*?["):
+		ok, err := path.Match(pattern, seg)
+		return err == nil && ok
+	default:
+		return pattern == seg
+	}
+}
+
+// splitPath splits a path into segments, ignoring empty segments (so "/ping" and "/ping/" are equivalent).
+func splitPath(p string) []string {
+	parts := strings.Split(p, "/")
+	segs := parts[:0]
+	for _, s := range parts {
+		if s != "" {
+			segs = append(segs, s)
+		}
+	}
+	return segs
+}
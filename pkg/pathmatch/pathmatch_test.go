@@ -0,0 +1,43 @@
+package pathmatch
+
+import "testing"
+
+func TestPatternMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{pattern: "/ping", path: "/ping", want: true},
+		{pattern: "/ping", path: "/ping/", want: true},
+		{pattern: "/ping", path: "/pong"},
+		{pattern: "/users/:id/avatar", path: "/users/42/avatar", want: true},
+		{pattern: "/users/{id}/avatar", path: "/users/42/avatar", want: true},
+		{pattern: "/users/:id/avatar", path: "/users/42/7/avatar"},
+		{pattern: "/files/*.png", path: "/files/a.png", want: true},
+		{pattern: "/files/*.png", path: "/files/a.jpg"},
+		{pattern: "/v?/users", path: "/v1/users", want: true},
+		{pattern: "/users/*/avatar", path: "/users/42/avatar", want: true},
+		{pattern: "/public/*", path: "/public/a/b", want: true},
+		{pattern: "/public/*", path: "/public"},
+		{pattern: "/static/**/index.html", path: "/static/index.html", want: true},
+		{pattern: "/static/**/index.html", path: "/static/a/b/index.html", want: true},
+		{pattern: "/user.UserService/*", path: "/user.UserService/GetUser", want: true},
+		{pattern: "/user.*/Get*", path: "/user.UserService/DeleteUser"},
+
+		// Dot segments are resolved before matching, like the router does.
+		{pattern: "/public/*", path: "/public/../admin/users"},
+		{pattern: "/admin/*", path: "/public/../admin/users", want: true},
+		{pattern: "/public/*", path: "/public/./a", want: true},
+		{pattern: "/public/*", path: "/public/..", want: false},
+		{pattern: "/admin/*", path: "//admin//users", want: true},
+		{pattern: "/admin/*", path: "admin/users", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			if got := Compile(tt.pattern).Match(tt.path); got != tt.want {
+				t.Errorf("Compile(%q).Match(%q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+			}
+		})
+	}
+}
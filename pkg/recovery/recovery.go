@@ -0,0 +1,110 @@
+// Package recovery reports panics recovered by middleware.Recover (HTTP) and the
+// interceptor.Recover interceptors (gRPC) to a pluggable Reporter, e.g. incident tooling.
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/metric"
+	"github.com/zeromicro/go-zero/core/threading"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// Protocols reported in Panic.Protocol.
+const (
+	ProtocolHTTP = "http"
+	ProtocolGRPC = "grpc"
+)
+
+var metricPanics = metric.NewCounterVec(&metric.CounterVecOpts{
+	Namespace: "gobase",
+	Subsystem: "recovery",
+	Name:      "panics_total",
+	Help:      "Recovered panics by protocol.",
+	Labels:    []string{"protocol"},
+})
+
+// Panic is a recovered panic with a summary of the request that caused it.
+type Panic struct {
+	Value     interface{} // Value passed to panic
+	Stack     string      // Stack trace of the panicking goroutine
+	Time      time.Time
+	Protocol  string // ProtocolHTTP or ProtocolGRPC
+	Method    string // HTTP method (empty for gRPC)
+	Path      string // HTTP path or gRPC full method
+	UserID    string
+	RequestID string
+	TraceID   string
+	ClientIP  string
+}
+
+// Reporter ships recovered panics, e.g. to an error tracker. Report runs asynchronously and
+// must not block indefinitely.
+type Reporter interface {
+	Report(ctx context.Context, p Panic)
+}
+
+// ReporterFunc adapts a function to Reporter.
+type ReporterFunc func(ctx context.Context, p Panic)
+
+// Report implements Reporter.
+func (f ReporterFunc) Report(ctx context.Context, p Panic) {
+	f(ctx, p)
+}
+
+var (
+	reporterMu sync.RWMutex
+	reporter   Reporter
+)
+
+// SetReporter sets the Reporter receiving the panics recovered by the HTTP and RPC recovery.
+func SetReporter(r Reporter) {
+	reporterMu.Lock()
+	reporter = r
+	reporterMu.Unlock()
+}
+
+func getReporter() Reporter {
+	reporterMu.RLock()
+	defer reporterMu.RUnlock()
+	return reporter
+}
+
+// Handle logs a recovered panic with its stack and passes it to the Reporter, if any.
+func Handle(ctx context.Context, p Panic) {
+	if p.Time.IsZero() {
+		p.Time = time.Now()
+	}
+	metricPanics.Inc(p.Protocol)
+	logx.WithContext(ctx).Errorw("panic recovered",
+		logx.Field("panic", fmt.Sprint(p.Value)),
+		logx.Field("protocol", p.Protocol),
+		logx.Field("method", p.Method),
+		logx.Field("path", p.Path),
+		logx.Field("user", p.UserID),
+		logx.Field("request_id", p.RequestID),
+		logx.Field("ip", p.ClientIP),
+		logx.Field("stack", p.Stack),
+	)
+
+	if r := getReporter(); r != nil {
+		// Report off the request path; the request context may be canceled once the response is written.
+		reportCtx := context.WithoutCancel(ctx)
+		threading.GoSafe(func() {
+			r.Report(reportCtx, p)
+		})
+	}
+}
+
+// TraceID returns the trace id of ctx (empty when tracing is disabled).
+func TraceID(ctx context.Context) string {
+	sc := oteltrace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
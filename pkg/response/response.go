@@ -2,11 +2,18 @@
 package response
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
+	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/rest/httpx"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
 
+	"github.com/addls/go-base/pkg/auth"
 	"github.com/addls/go-base/pkg/errcode"
+	"github.com/addls/go-base/pkg/validation"
 )
 
 // Response is the unified response structure.
@@ -90,9 +97,15 @@ func ErrorWithCode(w http.ResponseWriter, code int, msg string) {
 }
 
 // UnauthorizedCallback is for rest.WithUnauthorizedCallback (e.g. HTTP service JWT via rest.WithJwt).
-// It responds with HTTP 401 and the unified response format { code, msg }.
-func UnauthorizedCallback(w http.ResponseWriter, _ *http.Request, _ error) {
-	Error(w, errcode.ErrUnauthorized)
+// It responds with HTTP 401 and the unified response format { code, msg },
+// using ErrTokenMissing, ErrTokenExpired or ErrTokenInvalid depending on the failure reason.
+func UnauthorizedCallback(w http.ResponseWriter, r *http.Request, err error) {
+	reason := auth.ClassifyTokenError(err)
+	auth.ReportTokenFailure(reason)
+	logx.WithContext(r.Context()).Errorf("JWT authorization failed (%s): %v", reason, err)
+
+	w.Header().Set("WWW-Authenticate", auth.WWWAuthenticate(reason))
+	Error(w, auth.TokenErrorCode(reason))
 }
 
 // ErrorInvalidParam returns an invalid-parameter error response (used for parameter parsing failures).
@@ -108,6 +121,76 @@ func ErrorInvalidParam(w http.ResponseWriter, err error) {
 	})
 }
 
+// ErrorValidation returns a validation error response (used for validation.ValidateRequest failures).
+// Field errors are returned in data as {"fields": [{"field", "rule", "param", "message"}]};
+// other errors are returned as usual.
+func ErrorValidation(w http.ResponseWriter, err error) {
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		Error(w, err)
+		return
+	}
+	e := errs.Code()
+	httpx.WriteJson(w, e.GetHTTPCode(), &Response{
+		Code: e.Code,
+		Msg:  e.Msg,
+		Data: map[string]interface{}{"fields": errs},
+	})
+}
+
+// ErrorHandler converts errors written with httpx.Error (e.g. gRPC errors returned through the gateway)
+// into the unified response format; use it with httpx.SetErrorHandlerCtx.
+// gRPC statuses keep the business code of their ErrorInfo detail, and BadRequest field violations
+// are returned in data as {"fields": [...]} like ErrorValidation. The request id of a RequestInfo
+// detail (the trace id of a recovered panic) is returned as traceId.
+func ErrorHandler(_ context.Context, err error) (int, any) {
+	var e *errcode.Error
+	var data interface{}
+	var traceID string
+	if st, ok := status.FromError(err); ok {
+		e = errcode.FromGrpcStatus(st)
+		if fields := fieldViolations(st); len(fields) > 0 {
+			data = map[string]interface{}{"fields": fields}
+		}
+		traceID = requestID(st)
+	} else if ce, ok := err.(*errcode.Error); ok {
+		e = ce
+	} else {
+		// Other errors come from request parsing.
+		e = errcode.ErrInvalidParam.WithMsg(err.Error())
+	}
+	return e.GetHTTPCode(), &Response{
+		Code:    e.Code,
+		Msg:     e.Msg,
+		Data:    data,
+		TraceID: traceID,
+	}
+}
+
+func requestID(st *status.Status) string {
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.RequestInfo); ok {
+			return info.GetRequestId()
+		}
+	}
+	return ""
+}
+
+func fieldViolations(st *status.Status) validation.Errors {
+	var fields validation.Errors
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				fields = append(fields, validation.FieldError{
+					Field:   v.GetField(),
+					Message: v.GetDescription(),
+				})
+			}
+		}
+	}
+	return fields
+}
+
 // ----- TraceID variants -----
 
 // OkWithTrace returns a success response with TraceID.
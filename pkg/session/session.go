@@ -0,0 +1,368 @@
+// Package session provides cookie based sessions for browser clients (e.g. admin consoles).
+//
+// Sessions are either kept entirely in an encrypted cookie (Store: cookie) or in a server-side
+// store referenced by a random session id (Store: memory or redis). Each session carries a CSRF
+// token, see middleware.CSRF.
+package session
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+
+	"github.com/addls/go-base/pkg/auth"
+)
+
+// Store types.
+const (
+	StoreCookie = "cookie" // Encrypted cookie, no server state (logout only clears the cookie)
+	StoreMemory = "memory" // In-process, single instance only
+	StoreRedis  = "redis"  // Shared by all instances of a cluster
+)
+
+// CSRF protection modes.
+const (
+	CSRFSynchronizer = "synchronizer"  // Header must match the token stored in the session
+	CSRFDoubleSubmit = "double-submit" // Header must match the signed CSRF cookie
+)
+
+// maxCookieSize is the largest cookie value browsers reliably accept.
+const maxCookieSize = 4096
+
+// ErrCookieTooLarge is returned when an encrypted cookie session exceeds the browser limit.
+var ErrCookieTooLarge = errors.New("session: cookie session is too large, use a server-side store")
+
+// Conf session configuration.
+type Conf struct {
+	Enabled    bool            `json:",optional"`
+	Secret     string          // Encrypts cookie sessions and signs CSRF cookies
+	Store      string          `json:",default=cookie,options=cookie|memory|redis"`
+	CookieName string          `json:",default=gobase_session"`
+	Domain     string          `json:",optional"`
+	Path       string          `json:",default=/"`
+	Secure     bool            `json:",default=true"`                        // Send cookies over HTTPS only
+	SameSite   string          `json:",default=lax,options=lax|strict|none"` // Cookie SameSite attribute
+	MaxAge     time.Duration   `json:",default=24h"`                         // Absolute session lifetime
+	Redis      redis.RedisConf `json:",optional"`                            // Required when Store is redis
+	KeyPrefix  string          `json:",default=gobase:session:"`             // Redis key prefix
+	CSRF       CSRFConf        // CSRF protection for session requests
+}
+
+// CSRFConf CSRF protection configuration (applies to session-authenticated requests).
+type CSRFConf struct {
+	Enabled    bool     `json:",default=true"`
+	Mode       string   `json:",default=synchronizer,options=synchronizer|double-submit"`
+	Header     string   `json:",default=X-CSRF-Token"`
+	CookieName string   `json:",default=gobase_csrf"` // Readable by scripts, so SPAs can echo the token
+	SkipPaths  []string `json:",optional"`            // Path patterns exempt from CSRF checks
+}
+
+// Session is an authenticated browser session.
+type Session struct {
+	ID        string
+	Identity  auth.Identity
+	CSRFToken string
+	ExpiresAt time.Time
+}
+
+// Store keeps server-side sessions.
+type Store interface {
+	Save(ctx context.Context, id string, data []byte, ttl time.Duration) error
+	Load(ctx context.Context, id string) ([]byte, error) // nil if absent
+	Delete(ctx context.Context, id string) error
+}
+
+// Manager creates, loads and destroys sessions.
+type Manager struct {
+	conf  Conf
+	store Store // nil for cookie sessions
+	aead  cipher.AEAD
+	key   []byte
+}
+
+// NewManager creates a Manager. A nil store keeps sessions in encrypted cookies.
+func NewManager(c Conf, store Store) (*Manager, error) {
+	if c.Secret == "" {
+		return nil, fmt.Errorf("session: Secret is required")
+	}
+	key := sha256.Sum256([]byte(c.Secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Manager{
+		conf:  c,
+		store: store,
+		aead:  aead,
+		key:   key[:],
+	}, nil
+}
+
+// MustNewManager creates a Manager with the store from config, panics on error.
+func MustNewManager(c Conf) *Manager {
+	var store Store
+	switch c.Store {
+	case StoreRedis:
+		store = NewRedisStore(redis.MustNewRedis(c.Redis), c.KeyPrefix)
+	case StoreMemory:
+		store = NewMemoryStore()
+	}
+	m, err := NewManager(c, store)
+	logx.Must(err)
+	return m
+}
+
+// Conf returns the session configuration.
+func (m *Manager) Conf() Conf {
+	return m.conf
+}
+
+// Login starts a new session for the identity and sets the session and CSRF cookies.
+// Any previous session of the request is destroyed first (prevents session fixation).
+func (m *Manager) Login(w http.ResponseWriter, r *http.Request, id auth.Identity) (*Session, error) {
+	if err := m.destroy(r); err != nil {
+		return nil, err
+	}
+
+	s := &Session{
+		ID:        randomToken(),
+		Identity:  id,
+		CSRFToken: randomToken(),
+		ExpiresAt: time.Now().Add(m.conf.MaxAge),
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	value := s.ID
+	if m.store != nil {
+		if err := m.store.Save(r.Context(), s.ID, data, m.conf.MaxAge); err != nil {
+			return nil, err
+		}
+	} else {
+		value = m.seal(data)
+		if len(value) > maxCookieSize {
+			return nil, ErrCookieTooLarge
+		}
+	}
+
+	http.SetCookie(w, m.cookie(m.conf.CookieName, value, s.ExpiresAt, true))
+	http.SetCookie(w, m.cookie(m.conf.CSRF.CookieName, m.csrfCookieValue(s.ID, s.CSRFToken), s.ExpiresAt, false))
+	return s, nil
+}
+
+// Logout destroys the session of the request and clears the cookies.
+func (m *Manager) Logout(w http.ResponseWriter, r *http.Request) error {
+	err := m.destroy(r)
+	http.SetCookie(w, m.cookie(m.conf.CookieName, "", time.Unix(0, 0), true))
+	http.SetCookie(w, m.cookie(m.conf.CSRF.CookieName, "", time.Unix(0, 0), false))
+	return err
+}
+
+// Get loads the session of the request. It returns nil for missing, invalid or expired sessions.
+func (m *Manager) Get(r *http.Request) (*Session, error) {
+	c, err := r.Cookie(m.conf.CookieName)
+	if err != nil || c.Value == "" {
+		return nil, nil
+	}
+
+	var data []byte
+	if m.store != nil {
+		if data, err = m.store.Load(r.Context(), c.Value); err != nil || data == nil {
+			return nil, err
+		}
+	} else if data, err = m.open(c.Value); err != nil {
+		return nil, nil
+	}
+
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil || time.Now().After(s.ExpiresAt) {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+// VerifyCSRF checks the CSRF token sent in the request header against the session
+// (synchronizer mode) or the signed CSRF cookie (double-submit mode).
+func (m *Manager) VerifyCSRF(r *http.Request, s *Session) bool {
+	token := r.Header.Get(m.conf.CSRF.Header)
+	if token == "" {
+		return false
+	}
+	if m.conf.CSRF.Mode == CSRFDoubleSubmit {
+		c, err := r.Cookie(m.conf.CSRF.CookieName)
+		if err != nil {
+			return false
+		}
+		expected, ok := m.csrfFromCookie(s.ID, c.Value)
+		return ok && hmac.Equal([]byte(token), []byte(expected))
+	}
+	return hmac.Equal([]byte(token), []byte(s.CSRFToken))
+}
+
+func (m *Manager) destroy(r *http.Request) error {
+	if m.store == nil {
+		return nil
+	}
+	c, err := r.Cookie(m.conf.CookieName)
+	if err != nil || c.Value == "" {
+		return nil
+	}
+	return m.store.Delete(r.Context(), c.Value)
+}
+
+func (m *Manager) cookie(name, value string, expires time.Time, httpOnly bool) *http.Cookie {
+	c := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     m.conf.Path,
+		Domain:   m.conf.Domain,
+		Expires:  expires,
+		Secure:   m.conf.Secure,
+		HttpOnly: httpOnly,
+	}
+	switch m.conf.SameSite {
+	case "strict":
+		c.SameSite = http.SameSiteStrictMode
+	case "none":
+		c.SameSite = http.SameSiteNoneMode
+	default:
+		c.SameSite = http.SameSiteLaxMode
+	}
+	if value == "" {
+		c.MaxAge = -1
+	}
+	return c
+}
+
+// csrfCookieValue signs the CSRF token bound to the session id, so that cookies injected by sibling
+// subdomains (forged, or copied from another session) are rejected.
+func (m *Manager) csrfCookieValue(sessionID, token string) string {
+	return token + "." + base64.RawURLEncoding.EncodeToString(m.mac(sessionID, token))
+}
+
+// csrfFromCookie returns the CSRF token of a CSRF cookie value signed for the session.
+func (m *Manager) csrfFromCookie(sessionID, value string) (string, bool) {
+	i := strings.LastIndexByte(value, '.')
+	if i < 0 {
+		return "", false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(value[i+1:])
+	if err != nil || !hmac.Equal(sig, m.mac(sessionID, value[:i])) {
+		return "", false
+	}
+	return value[:i], true
+}
+
+func (m *Manager) mac(sessionID, token string) []byte {
+	h := hmac.New(sha256.New, m.key)
+	h.Write([]byte("csrf\n" + sessionID + "\n" + token))
+	return h.Sum(nil)
+}
+
+// seal encrypts cookie session data (AES-256-GCM).
+func (m *Manager) seal(data []byte) string {
+	nonce := make([]byte, m.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(m.aead.Seal(nonce, nonce, data, []byte(m.conf.CookieName)))
+}
+
+// open decrypts cookie session data.
+func (m *Manager) open(value string) ([]byte, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < m.aead.NonceSize() {
+		return nil, errors.New("session: malformed cookie")
+	}
+	nonce, ciphertext := b[:m.aead.NonceSize()], b[m.aead.NonceSize():]
+	return m.aead.Open(nil, nonce, ciphertext, []byte(m.conf.CookieName))
+}
+
+// randomToken returns a random 256-bit URL-safe token.
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// ----- Default manager and context -----
+
+var (
+	manager   *Manager
+	managerMu sync.RWMutex
+)
+
+// SetManager sets the default manager used by Login and Logout.
+// bootstrap sets it automatically when sessions are enabled in config.
+func SetManager(m *Manager) {
+	managerMu.Lock()
+	defer managerMu.Unlock()
+	manager = m
+}
+
+// GetManager returns the default manager (nil if sessions are disabled).
+func GetManager() *Manager {
+	managerMu.RLock()
+	defer managerMu.RUnlock()
+	return manager
+}
+
+// ErrSessionDisabled is returned by Login and Logout when no default manager is set.
+var ErrSessionDisabled = errors.New("sessions are not enabled")
+
+// Login starts a session for the identity using the default manager (call it from the login handler).
+func Login(w http.ResponseWriter, r *http.Request, id auth.Identity) (*Session, error) {
+	m := GetManager()
+	if m == nil {
+		return nil, ErrSessionDisabled
+	}
+	return m.Login(w, r, id)
+}
+
+// Logout destroys the session of the request using the default manager.
+func Logout(w http.ResponseWriter, r *http.Request) error {
+	m := GetManager()
+	if m == nil {
+		return ErrSessionDisabled
+	}
+	return m.Logout(w, r)
+}
+
+type sessionKey struct{}
+
+// WithSession returns a copy of ctx carrying the session.
+func WithSession(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// FromContext returns the session of the request (nil if the caller has no session).
+func FromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(sessionKey{}).(*Session)
+	return s
+}
@@ -0,0 +1,106 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/addls/go-base/pkg/auth"
+)
+
+func newTestManager(t *testing.T, mode string, store Store) *Manager {
+	t.Helper()
+	m, err := NewManager(Conf{
+		Secret:     "session-secret",
+		CookieName: "gobase_session",
+		Path:       "/",
+		MaxAge:     time.Hour,
+		CSRF:       CSRFConf{Enabled: true, Mode: mode, Header: "X-CSRF-Token", CookieName: "gobase_csrf"},
+	}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// login starts a session and returns it with the cookies set for it.
+func login(t *testing.T, m *Manager, uid string) (*Session, map[string]*http.Cookie) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	s, err := m.Login(w, httptest.NewRequest(http.MethodPost, "/login", nil), auth.Identity{UserID: uid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cookies := make(map[string]*http.Cookie)
+	for _, c := range w.Result().Cookies() {
+		cookies[c.Name] = c
+	}
+	return s, cookies
+}
+
+func TestVerifyCSRF(t *testing.T) {
+	for _, mode := range []string{CSRFSynchronizer, CSRFDoubleSubmit} {
+		m := newTestManager(t, mode, NewMemoryStore())
+		alice, aliceCookies := login(t, m, "alice")
+		mallory, malloryCookies := login(t, m, "mallory")
+
+		tests := []struct {
+			name   string
+			cookie *http.Cookie
+			header string
+			want   bool
+		}{
+			{name: "own token", cookie: aliceCookies["gobase_csrf"], header: alice.CSRFToken, want: true},
+			{name: "missing header", cookie: aliceCookies["gobase_csrf"]},
+			{name: "wrong token", cookie: aliceCookies["gobase_csrf"], header: mallory.CSRFToken},
+			// A sibling subdomain plants the attacker's own CSRF cookie in the victim's browser.
+			{name: "cookie of another session", cookie: malloryCookies["gobase_csrf"], header: mallory.CSRFToken},
+			{name: "unsigned cookie", cookie: &http.Cookie{Name: "gobase_csrf", Value: "forged.c2ln"}, header: "forged"},
+		}
+		for _, tt := range tests {
+			t.Run(mode+"/"+tt.name, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodPost, "/orders", nil)
+				r.AddCookie(aliceCookies["gobase_session"])
+				if tt.cookie != nil {
+					r.AddCookie(tt.cookie)
+				}
+				if tt.header != "" {
+					r.Header.Set("X-CSRF-Token", tt.header)
+				}
+				if got := m.VerifyCSRF(r, alice); got != tt.want {
+					t.Errorf("VerifyCSRF = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestManagerGet(t *testing.T) {
+	stores := map[string]Store{StoreCookie: nil, StoreMemory: NewMemoryStore()}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			m := newTestManager(t, CSRFSynchronizer, store)
+			s, cookies := login(t, m, "alice")
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.AddCookie(cookies["gobase_session"])
+			got, err := m.Get(r)
+			if err != nil || got == nil || got.ID != s.ID || got.Identity.UserID != "alice" {
+				t.Fatalf("Get = %+v, %v; want the session of alice", got, err)
+			}
+
+			tampered := *cookies["gobase_session"]
+			if tampered.Value[0] == 'A' {
+				tampered.Value = "B" + tampered.Value[1:]
+			} else {
+				tampered.Value = "A" + tampered.Value[1:]
+			}
+			r = httptest.NewRequest(http.MethodGet, "/", nil)
+			r.AddCookie(&tampered)
+			if got, _ := m.Get(r); got != nil {
+				t.Errorf("Get with a tampered cookie = %+v, want nil", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/redis"
+
+	"github.com/addls/go-base/pkg/internal/redisx"
+)
+
+// ----- In-memory store -----
+
+// sweepInterval controls how often expired sessions are purged from the in-memory store.
+const sweepInterval = time.Minute
+
+type memoryEntry struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+// MemoryStore is an in-memory session store (single instance only).
+type MemoryStore struct {
+	mu        sync.Mutex
+	sessions  map[string]memoryEntry
+	lastSweep time.Time
+}
+
+// NewMemoryStore creates an in-memory session store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		sessions:  make(map[string]memoryEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+// Save implements Store.
+func (s *MemoryStore) Save(_ context.Context, id string, data []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.lastSweep = now
+		for k, e := range s.sessions {
+			if !now.Before(e.expiresAt) {
+				delete(s.sessions, k)
+			}
+		}
+	}
+	s.sessions[id] = memoryEntry{
+		data:      data,
+		expiresAt: now.Add(ttl),
+	}
+	return nil
+}
+
+// Load implements Store.
+func (s *MemoryStore) Load(_ context.Context, id string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.sessions[id]; ok && time.Now().Before(e.expiresAt) {
+		return e.data, nil
+	}
+	return nil, nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+	return nil
+}
+
+// ----- Redis store -----
+
+// RedisStore is a Redis-backed session store shared by all instances of a cluster.
+type RedisStore struct {
+	rds    *redis.Redis
+	prefix string
+}
+
+// NewRedisStore creates a Redis-backed session store.
+func NewRedisStore(rds *redis.Redis, keyPrefix string) *RedisStore {
+	return &RedisStore{
+		rds:    rds,
+		prefix: keyPrefix,
+	}
+}
+
+// Save implements Store.
+func (s *RedisStore) Save(ctx context.Context, id string, data []byte, ttl time.Duration) error {
+	return s.rds.SetexCtx(ctx, s.prefix+id, string(data), redisx.TTL(ttl))
+}
+
+// Load implements Store.
+func (s *RedisStore) Load(ctx context.Context, id string) ([]byte, error) {
+	v, err := s.rds.GetCtx(ctx, s.prefix+id)
+	if err != nil || v == "" {
+		return nil, err
+	}
+	return []byte(v), nil
+}
+
+// Delete implements Store.
+func (s *RedisStore) Delete(ctx context.Context, id string) error {
+	_, err := s.rds.DelCtx(ctx, s.prefix+id)
+	return err
+}
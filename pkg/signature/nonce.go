@@ -0,0 +1,70 @@
+package signature
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/redis"
+
+	"github.com/addls/go-base/pkg/internal/redisx"
+)
+
+// sweepInterval controls how often expired nonces are purged from the in-memory store.
+const sweepInterval = time.Minute
+
+// MemoryNonceStore is an in-memory nonce store (single instance only).
+type MemoryNonceStore struct {
+	mu        sync.Mutex
+	nonces    map[string]time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryNonceStore creates an in-memory nonce store.
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{
+		nonces:    make(map[string]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+// Use implements NonceStore.
+func (s *MemoryNonceStore) Use(_ context.Context, nonce string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.lastSweep = now
+		for n, exp := range s.nonces {
+			if !now.Before(exp) {
+				delete(s.nonces, n)
+			}
+		}
+	}
+
+	if exp, ok := s.nonces[nonce]; ok && now.Before(exp) {
+		return false, nil
+	}
+	s.nonces[nonce] = now.Add(ttl)
+	return true, nil
+}
+
+// RedisNonceStore is a Redis-backed nonce store shared by all instances of a cluster.
+type RedisNonceStore struct {
+	rds    *redis.Redis
+	prefix string
+}
+
+// NewRedisNonceStore creates a Redis-backed nonce store.
+func NewRedisNonceStore(rds *redis.Redis, keyPrefix string) *RedisNonceStore {
+	return &RedisNonceStore{
+		rds:    rds,
+		prefix: keyPrefix,
+	}
+}
+
+// Use implements NonceStore.
+func (s *RedisNonceStore) Use(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	return s.rds.SetnxExCtx(ctx, s.prefix+nonce, "1", redisx.TTL(ttl))
+}
@@ -0,0 +1,123 @@
+package signature
+
+import (
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/addls/go-base/pkg/errcode"
+)
+
+// signedRequest returns a POST request signed with the key at the given time.
+func signedRequest(t *testing.T, keyID, secret string, at time.Time) *http.Request {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/partner/orders?b=2&a=1", strings.NewReader(`{"sku":"a"}`))
+	if err := Sign(r, keyID, secret); err != nil {
+		t.Fatal(err)
+	}
+	if !at.IsZero() {
+		ts := strconv.FormatInt(at.Unix(), 10)
+		body, _ := readBody(r)
+		r.Header.Set(TimestampHeader, ts)
+		r.Header.Set(SignatureHeader, hex.EncodeToString(mac([]byte(secret), canonicalRequest(r, body, ts, r.Header.Get(NonceHeader)))))
+	}
+	return r
+}
+
+func TestVerifierVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		request func(t *testing.T) *http.Request
+		want    error
+	}{
+		{name: "valid", request: func(t *testing.T) *http.Request {
+			return signedRequest(t, "k1", "secret-1", time.Time{})
+		}},
+		{name: "rotated key", request: func(t *testing.T) *http.Request {
+			return signedRequest(t, "k2", "secret-2", time.Time{})
+		}},
+		{name: "unknown key", request: func(t *testing.T) *http.Request {
+			return signedRequest(t, "k3", "secret-1", time.Time{})
+		}, want: errcode.ErrSignatureInvalid},
+		{name: "secret of another key", request: func(t *testing.T) *http.Request {
+			return signedRequest(t, "k1", "secret-2", time.Time{})
+		}, want: errcode.ErrSignatureInvalid},
+		{name: "tampered body", request: func(t *testing.T) *http.Request {
+			r := signedRequest(t, "k1", "secret-1", time.Time{})
+			r.Body = io.NopCloser(strings.NewReader(`{"sku":"b"}`))
+			return r
+		}, want: errcode.ErrSignatureInvalid},
+		{name: "tampered query", request: func(t *testing.T) *http.Request {
+			r := signedRequest(t, "k1", "secret-1", time.Time{})
+			r.URL.RawQuery = "a=1&b=3"
+			return r
+		}, want: errcode.ErrSignatureInvalid},
+		{name: "reordered query", request: func(t *testing.T) *http.Request {
+			r := signedRequest(t, "k1", "secret-1", time.Time{})
+			r.URL.RawQuery = "a=1&b=2"
+			return r
+		}},
+		{name: "missing nonce", request: func(t *testing.T) *http.Request {
+			r := signedRequest(t, "k1", "secret-1", time.Time{})
+			r.Header.Del(NonceHeader)
+			return r
+		}, want: errcode.ErrSignatureInvalid},
+		{name: "stale", request: func(t *testing.T) *http.Request {
+			return signedRequest(t, "k1", "secret-1", time.Now().Add(-10*time.Minute))
+		}, want: errcode.ErrSignatureExpired},
+		{name: "from the future", request: func(t *testing.T) *http.Request {
+			return signedRequest(t, "k1", "secret-1", time.Now().Add(10*time.Minute))
+		}, want: errcode.ErrSignatureExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewVerifier(Conf{
+				Keys:   []Key{{ID: "k1", Secret: "secret-1"}, {ID: "k2", Secret: "secret-2"}},
+				Window: 5 * time.Minute,
+			}, NewMemoryNonceStore())
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := tt.request(t)
+			if err := v.Verify(r); !errors.Is(err, tt.want) {
+				t.Fatalf("Verify error = %v, want %v", err, tt.want)
+			}
+			if body, _ := io.ReadAll(r.Body); len(body) == 0 {
+				t.Error("request body was not restored")
+			}
+		})
+	}
+}
+
+func TestVerifierReplay(t *testing.T) {
+	v, err := NewVerifier(Conf{
+		Keys:   []Key{{ID: "k1", Secret: "secret-1"}, {ID: "k2", Secret: "secret-2"}},
+		Window: 5 * time.Minute,
+	}, NewMemoryNonceStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := signedRequest(t, "k1", "secret-1", time.Time{})
+	if err := v.Verify(r); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Verify(r); !errors.Is(err, errcode.ErrSignatureReplayed) {
+		t.Errorf("replayed request error = %v, want %v", err, errcode.ErrSignatureReplayed)
+	}
+
+	// Nonces are scoped by key: another partner may pick the same nonce.
+	other := signedRequest(t, "k2", "secret-2", time.Time{})
+	body, _ := readBody(other)
+	ts := other.Header.Get(TimestampHeader)
+	other.Header.Set(NonceHeader, r.Header.Get(NonceHeader))
+	other.Header.Set(SignatureHeader, hex.EncodeToString(mac([]byte("secret-2"), canonicalRequest(other, body, ts, r.Header.Get(NonceHeader)))))
+	if err := v.Verify(other); err != nil {
+		t.Errorf("same nonce of another key error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,153 @@
+package tenant
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/limit"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+	"golang.org/x/time/rate"
+)
+
+// Store types.
+const (
+	StoreMemory = "memory" // In-process, single instance only
+	StoreRedis  = "redis"  // Shared by all instances of a cluster
+)
+
+// RateLimitConf per-tenant rate limit configuration (a token bucket per tenant).
+type RateLimitConf struct {
+	Enabled   bool                `json:",optional"`
+	Rate      int                 `json:",default=100,range=[1:]"`              // Requests per second per tenant
+	Burst     int                 `json:",default=200,range=[1:]"`              // Bucket size per tenant
+	Overrides []RateLimitOverride `json:",optional"`                            // Per-tenant limits (e.g. paid plans)
+	Store     string              `json:",default=memory,options=memory|redis"` // memory (single instance) or redis (cluster)
+	Redis     redis.RedisConf     `json:",optional"`                            // Required when Store is redis
+	KeyPrefix string              `json:",default=gobase:tenant:rate:"`         // Redis key prefix
+}
+
+// RateLimitOverride overrides the rate limit of a tenant.
+type RateLimitOverride struct {
+	Tenant string
+	Rate   int `json:",range=[1:]"`
+	Burst  int `json:",optional"` // Default: the configured Burst scaled by Rate
+}
+
+// Limiter limits the request rate of each tenant.
+type Limiter interface {
+	// Allow reports whether a request of the tenant may proceed now.
+	Allow(ctx context.Context, tid string) bool
+}
+
+// MustNewLimiter creates a limiter from config, panics on error.
+func MustNewLimiter(c RateLimitConf) Limiter {
+	if c.Store == StoreRedis {
+		return NewRedisLimiter(c, redis.MustNewRedis(c.Redis))
+	}
+	return NewMemoryLimiter(c)
+}
+
+// NewMemoryLimiter creates an in-process limiter (single instance only).
+func NewMemoryLimiter(c RateLimitConf) Limiter {
+	return newBuckets(c, func(_ string, r, b int) func(context.Context) bool {
+		lim := rate.NewLimiter(rate.Limit(r), b)
+		return func(context.Context) bool {
+			return lim.Allow()
+		}
+	})
+}
+
+// NewRedisLimiter creates a limiter shared by all instances through redis. While redis is
+// unavailable each instance falls back to an in-process bucket (see go-zero limit.TokenLimiter).
+func NewRedisLimiter(c RateLimitConf, store *redis.Redis) Limiter {
+	return newBuckets(c, func(tid string, r, b int) func(context.Context) bool {
+		return limit.NewTokenLimiter(r, b, store, c.KeyPrefix+tid).AllowCtx
+	})
+}
+
+// idleTimeout is how long an unused bucket is kept; a bucket idle that long is full again anyway
+// (as long as Burst/Rate is shorter), so dropping it does not change the limit.
+const idleTimeout = time.Minute
+
+type bucket struct {
+	allow    func(context.Context) bool
+	lastSeen time.Time
+}
+
+// buckets keeps a bucket per tenant, created on first use and dropped when idle.
+type buckets struct {
+	conf      RateLimitConf
+	overrides map[string]RateLimitOverride
+	create    func(tid string, rate, burst int) func(context.Context) bool
+
+	mu        sync.Mutex
+	entries   map[string]*bucket
+	lastSweep time.Time
+}
+
+func newBuckets(c RateLimitConf, create func(string, int, int) func(context.Context) bool) *buckets {
+	if c.Rate <= 0 {
+		c.Rate = 100
+	}
+	if c.Burst <= 0 {
+		c.Burst = 2 * c.Rate
+	}
+	b := &buckets{
+		conf:      c,
+		overrides: make(map[string]RateLimitOverride, len(c.Overrides)),
+		create:    create,
+		entries:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+	for _, o := range c.Overrides {
+		b.overrides[o.Tenant] = o
+	}
+	return b
+}
+
+// Allow implements Limiter.
+func (b *buckets) Allow(ctx context.Context, tid string) bool {
+	now := time.Now()
+
+	b.mu.Lock()
+	b.sweepLocked(now)
+	e, ok := b.entries[tid]
+	if !ok {
+		r, burst := b.limits(tid)
+		e = &bucket{allow: b.create(tid, r, burst)}
+		b.entries[tid] = e
+	}
+	e.lastSeen = now
+	b.mu.Unlock()
+
+	return e.allow(ctx)
+}
+
+// limits returns the rate and burst of a tenant.
+func (b *buckets) limits(tid string) (int, int) {
+	o, ok := b.overrides[tid]
+	if !ok {
+		return b.conf.Rate, b.conf.Burst
+	}
+	if o.Burst > 0 {
+		return o.Rate, o.Burst
+	}
+	burst := o.Rate * b.conf.Burst / b.conf.Rate
+	if burst < 1 {
+		burst = 1
+	}
+	return o.Rate, burst
+}
+
+func (b *buckets) sweepLocked(now time.Time) {
+	if now.Sub(b.lastSweep) < idleTimeout {
+		return
+	}
+	b.lastSweep = now
+	for tid, e := range b.entries {
+		if now.Sub(e.lastSeen) >= idleTimeout {
+			delete(b.entries, tid)
+		}
+	}
+}
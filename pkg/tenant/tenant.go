@@ -0,0 +1,157 @@
+// Package tenant resolves the tenant of a request (token claim, header or subdomain) in the gateway
+// and HTTP services, and exposes it to HTTP and RPC services through FromContext.
+//
+// The tenant id is forwarded like the caller identity (x-jwt-tenant-id): clients cannot set it
+// directly, it is covered by the identity signature and propagated to downstream calls. Only the
+// tenant of the credential (token claim or API key) is forwarded as x-jwt-tenant-id; a tenant the
+// client chose itself (header or subdomain) is forwarded as x-jwt-requested-tenant-id and read
+// with RequestedFromContext.
+package tenant
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/addls/go-base/pkg/auth"
+	"github.com/addls/go-base/pkg/errcode"
+)
+
+// MetadataKey is the identity key (HTTP header / gRPC metadata) carrying the tenant id of the credential.
+const MetadataKey = auth.IdentityPrefix + "tenant-id"
+
+// RequestedKey is the identity key carrying the tenant chosen by the request (header or subdomain)
+// when the credential carries none, e.g. on anonymous routes. It is not verified against the caller.
+const RequestedKey = auth.IdentityPrefix + "requested-tenant-id"
+
+// validID restricts tenant ids to values safe in headers, metadata and storage keys.
+var validID = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// Conf tenant configuration.
+type Conf struct {
+	Enabled   bool          `json:",optional"`
+	Claim     string        `json:",default=tid"`         // JWT claim carrying the tenant id
+	Header    string        `json:",default=X-Tenant-Id"` // Request header carrying the tenant id
+	Domain    string        `json:",optional"`            // Base domain for subdomain resolution, e.g. example.com (acme.example.com -> acme)
+	Required  bool          `json:",optional"`            // Reject authenticated requests whose credential carries no tenant
+	RateLimit RateLimitConf `json:",optional"`            // Per-tenant rate limit
+}
+
+// Resolver resolves the tenant of HTTP requests.
+type Resolver struct {
+	conf   Conf
+	domain string
+}
+
+// NewResolver creates a Resolver from config.
+func NewResolver(c Conf) *Resolver {
+	if c.Claim == "" {
+		c.Claim = "tid"
+	}
+	if c.Header == "" {
+		c.Header = "X-Tenant-Id"
+	}
+	r := &Resolver{conf: c}
+	if c.Domain != "" {
+		r.domain = "." + strings.ToLower(strings.TrimPrefix(c.Domain, "."))
+	}
+	return r
+}
+
+// Claim returns the JWT claim carrying the tenant id.
+func (r *Resolver) Claim() string {
+	return r.conf.Claim
+}
+
+// Required reports whether the credential of authenticated requests must carry a tenant.
+func (r *Resolver) Required() bool {
+	return r.conf.Required
+}
+
+// FromClaims returns the tenant id of verified token claims (empty if absent).
+func (r *Resolver) FromClaims(claims map[string]interface{}) string {
+	tid, _ := claims[r.conf.Claim].(string)
+	return tid
+}
+
+// Resolve returns the tenant of a request: the tenant of the verified token (tokenTenant, if any),
+// else the header, else the subdomain. A header or subdomain naming another tenant than the token
+// is rejected with errcode.ErrTenantMismatch, a malformed tenant id with errcode.ErrInvalidParam.
+func (r *Resolver) Resolve(req *http.Request, tokenTenant string) (string, error) {
+	header := req.Header.Get(r.conf.Header)
+	subdomain := r.subdomain(req.Host)
+
+	for _, tid := range []string{tokenTenant, header, subdomain} {
+		if tid != "" && !validID.MatchString(tid) {
+			return "", errcode.ErrInvalidParam.WithMsg("invalid tenant id")
+		}
+	}
+	if tokenTenant != "" {
+		if (header != "" && header != tokenTenant) || (subdomain != "" && subdomain != tokenTenant) {
+			return "", errcode.ErrTenantMismatch
+		}
+		return tokenTenant, nil
+	}
+	if header != "" {
+		if subdomain != "" && subdomain != header {
+			return "", errcode.ErrTenantMismatch
+		}
+		return header, nil
+	}
+	return subdomain, nil
+}
+
+// subdomain returns the label directly below the base domain (acme.example.com -> acme).
+func (r *Resolver) subdomain(host string) string {
+	if r.domain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	if !strings.HasSuffix(host, r.domain) {
+		return ""
+	}
+	label := strings.TrimSuffix(host, r.domain)
+	if strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
+
+type (
+	tenantKey          struct{}
+	requestedTenantKey struct{}
+)
+
+// NewContext returns a copy of ctx carrying the tenant id (e.g. for background jobs).
+func NewContext(ctx context.Context, tid string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tid)
+}
+
+// FromContext returns the tenant id of the caller (unified API, works for HTTP or gRPC):
+// the tenant set by NewContext, else the forwarded x-jwt-tenant-id.
+func FromContext(ctx context.Context) string {
+	if tid, ok := ctx.Value(tenantKey{}).(string); ok && tid != "" {
+		return tid
+	}
+	return auth.GetValue(ctx, MetadataKey)
+}
+
+// NewRequestedContext returns a copy of ctx carrying the tenant chosen by the request.
+func NewRequestedContext(ctx context.Context, tid string) context.Context {
+	return context.WithValue(ctx, requestedTenantKey{}, tid)
+}
+
+// RequestedFromContext returns the tenant chosen by the request (header or subdomain) when the caller
+// has no tenant: the tenant set by NewRequestedContext, else the forwarded x-jwt-requested-tenant-id.
+// The caller is not known to belong to it; do not use it for authorization or quotas.
+func RequestedFromContext(ctx context.Context) string {
+	if tid, ok := ctx.Value(requestedTenantKey{}).(string); ok && tid != "" {
+		return tid
+	}
+	return auth.GetValue(ctx, RequestedKey)
+}
@@ -0,0 +1,53 @@
+package tenant
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/addls/go-base/pkg/errcode"
+)
+
+func TestResolverResolve(t *testing.T) {
+	r := NewResolver(Conf{Enabled: true, Domain: "example.com"})
+
+	tests := []struct {
+		name   string
+		host   string
+		header string
+		token  string
+		want   string
+		err    error
+	}{
+		{name: "token", host: "api.test", token: "acme", want: "acme"},
+		{name: "token and matching header", host: "api.test", header: "acme", token: "acme", want: "acme"},
+		{name: "token and other header", host: "api.test", header: "beta", token: "acme", err: errcode.ErrTenantMismatch},
+		{name: "token and other subdomain", host: "beta.example.com", token: "acme", err: errcode.ErrTenantMismatch},
+		{name: "header", host: "api.test", header: "acme", want: "acme"},
+		{name: "header and other subdomain", host: "beta.example.com", header: "acme", err: errcode.ErrTenantMismatch},
+		{name: "subdomain with port", host: "Acme.Example.com:8443", want: "acme"},
+		{name: "nested subdomain", host: "a.acme.example.com"},
+		{name: "other domain", host: "acme.example.org"},
+		{name: "malformed header", host: "api.test", header: "../acme", err: errcode.ErrInvalidParam},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Host = tt.host
+			if tt.header != "" {
+				req.Header.Set("X-Tenant-Id", tt.header)
+			}
+			got, err := r.Resolve(req, tt.token)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) && errcode.FromError(err).Code != errcode.FromError(tt.err).Code {
+					t.Fatalf("Resolve error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Resolve = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package validation
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Built-in locales.
+const (
+	LocaleEn = "en"
+	LocaleZh = "zh"
+)
+
+var (
+	messagesMu    sync.RWMutex
+	defaultLocale = LocaleEn
+
+	// messages maps locale -> rule (optionally suffixed with the value kind, e.g. "min.string") -> message.
+	messages = map[string]map[string]string{
+		LocaleEn: {
+			"required":      "{field} is required",
+			"min.string":    "{field} must be at least {param} characters",
+			"min.list":      "{field} must contain at least {param} items",
+			"min":           "{field} must be at least {param}",
+			"max.string":    "{field} must be at most {param} characters",
+			"max.list":      "{field} must contain at most {param} items",
+			"max":           "{field} must be at most {param}",
+			"len.string":    "{field} must be exactly {param} characters",
+			"len.list":      "{field} must contain exactly {param} items",
+			"len":           "{field} must be {param}",
+			"gt":            "{field} must be greater than {param}",
+			"lt":            "{field} must be less than {param}",
+			"oneof":         "{field} must be one of [{param}]",
+			"regex":         "{field} has an invalid format",
+			"email":         "{field} must be a valid email address",
+			"url":           "{field} must be a valid URL",
+			"uuid":          "{field} must be a valid UUID",
+			"alpha":         "{field} must contain only letters",
+			"alphanum":      "{field} must contain only letters and digits",
+			"numeric":       "{field} must be numeric",
+			"eqfield":       "{field} must be equal to {param}",
+			"nefield":       "{field} must not be equal to {param}",
+			"gtfield":       "{field} must be greater than {param}",
+			"gtefield":      "{field} must be greater than or equal to {param}",
+			"ltfield":       "{field} must be less than {param}",
+			"ltefield":      "{field} must be less than or equal to {param}",
+			"required_with": "{field} is required when {param} is present",
+			"required_if":   "{field} is required when {param}",
+			"":              "{field} is invalid",
+		},
+		LocaleZh: {
+			"required":      "{field} 不能为空",
+			"min.string":    "{field} 长度不能少于 {param} 个字符",
+			"min.list":      "{field} 至少包含 {param} 项",
+			"min":           "{field} 不能小于 {param}",
+			"max.string":    "{field} 长度不能超过 {param} 个字符",
+			"max.list":      "{field} 最多包含 {param} 项",
+			"max":           "{field} 不能大于 {param}",
+			"len.string":    "{field} 长度必须为 {param} 个字符",
+			"len.list":      "{field} 必须包含 {param} 项",
+			"len":           "{field} 必须等于 {param}",
+			"gt":            "{field} 必须大于 {param}",
+			"lt":            "{field} 必须小于 {param}",
+			"oneof":         "{field} 必须是 [{param}] 之一",
+			"regex":         "{field} 格式不正确",
+			"email":         "{field} 必须是有效的邮箱地址",
+			"url":           "{field} 必须是有效的 URL",
+			"uuid":          "{field} 必须是有效的 UUID",
+			"alpha":         "{field} 只能包含字母",
+			"alphanum":      "{field} 只能包含字母和数字",
+			"numeric":       "{field} 必须是数字",
+			"eqfield":       "{field} 必须与 {param} 相同",
+			"nefield":       "{field} 不能与 {param} 相同",
+			"gtfield":       "{field} 必须大于 {param}",
+			"gtefield":      "{field} 必须大于或等于 {param}",
+			"ltfield":       "{field} 必须小于 {param}",
+			"ltefield":      "{field} 必须小于或等于 {param}",
+			"required_with": "{param} 存在时 {field} 不能为空",
+			"required_if":   "{param} 时 {field} 不能为空",
+			"":              "{field} 不合法",
+		},
+	}
+)
+
+// RegisterMessage sets the message of a rule in a locale (a new locale is added if needed).
+// The rule may be suffixed with a value kind (".string", ".number" or ".list") to override
+// the message for that kind only.
+func RegisterMessage(locale, rule, msg string) {
+	messagesMu.Lock()
+	defer messagesMu.Unlock()
+
+	locale = strings.ToLower(locale)
+	if messages[locale] == nil {
+		messages[locale] = make(map[string]string)
+	}
+	messages[locale][rule] = msg
+}
+
+// SetDefaultLocale sets the locale used by Validate and when no Accept-Language matches.
+func SetDefaultLocale(locale string) {
+	messagesMu.Lock()
+	defaultLocale = strings.ToLower(locale)
+	messagesMu.Unlock()
+}
+
+// DefaultLocale returns the default locale.
+func DefaultLocale() string {
+	messagesMu.RLock()
+	defer messagesMu.RUnlock()
+	return defaultLocale
+}
+
+// LocaleFromRequest returns the first locale in the request Accept-Language that has messages
+// (matching the full tag first, then the base language, e.g. zh-CN -> zh), or the default locale.
+func LocaleFromRequest(r *http.Request) string {
+	messagesMu.RLock()
+	defer messagesMu.RUnlock()
+
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, _, _ := strings.Cut(part, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		if _, ok := messages[tag]; ok {
+			return tag
+		}
+		base, _, _ := strings.Cut(tag, "-")
+		if _, ok := messages[base]; ok {
+			return base
+		}
+	}
+	return defaultLocale
+}
+
+// message returns the localized message of a failed rule, falling back to the default locale.
+func message(locale, rule, kind, field, param string) string {
+	messagesMu.RLock()
+	defer messagesMu.RUnlock()
+
+	msg := ""
+	for _, l := range []string{locale, defaultLocale, LocaleEn} {
+		m := messages[l]
+		if m == nil {
+			continue
+		}
+		if kind != "" {
+			msg = m[rule+"."+kind]
+		}
+		if msg == "" {
+			msg = m[rule]
+		}
+		if msg != "" {
+			break
+		}
+	}
+	if msg == "" {
+		msg = messages[LocaleEn][""]
+	}
+	return strings.NewReplacer("{field}", field, "{param}", param).Replace(msg)
+}
@@ -0,0 +1,265 @@
+package validation
+
+import (
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Value kinds used to select messages (e.g. "min.string" vs "min.number").
+const (
+	kindString = "string"
+	kindNumber = "number"
+	kindList   = "list"
+)
+
+var (
+	regexCache sync.Map // pattern -> *regexp.Regexp
+
+	uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+func init() {
+	for name, fn := range map[string]RuleFunc{
+		"min":           sizeRule(func(n, p float64) bool { return n >= p }),
+		"max":           sizeRule(func(n, p float64) bool { return n <= p }),
+		"len":           sizeRule(func(n, p float64) bool { return n == p }),
+		"gt":            sizeRule(func(n, p float64) bool { return n > p }),
+		"lt":            sizeRule(func(n, p float64) bool { return n < p }),
+		"oneof":         oneOf,
+		"regex":         matchRegex,
+		"email":         stringRule(isEmail),
+		"url":           stringRule(isURL),
+		"uuid":          stringRule(uuidRegex.MatchString),
+		"alpha":         stringRule(allRunes(unicode.IsLetter)),
+		"alphanum":      stringRule(allRunes(func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) })),
+		"numeric":       stringRule(isNumeric),
+		"eqfield":       compareField(func(c int) bool { return c == 0 }),
+		"nefield":       compareField(func(c int) bool { return c != 0 }),
+		"gtfield":       compareField(func(c int) bool { return c > 0 }),
+		"gtefield":      compareField(func(c int) bool { return c >= 0 }),
+		"ltfield":       compareField(func(c int) bool { return c < 0 }),
+		"ltefield":      compareField(func(c int) bool { return c <= 0 }),
+		"required_with": requiredWith,
+		"required_if":   requiredIf,
+	} {
+		rules[name] = fn
+	}
+}
+
+// kindOf returns the message kind of a value.
+func kindOf(v reflect.Value) string {
+	if !v.IsValid() {
+		return ""
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return kindString
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return kindNumber
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return kindList
+	}
+	return ""
+}
+
+// size returns the number compared by size rules: the rune count of strings, the length of lists
+// and the value of numbers.
+func size(v reflect.Value) (float64, bool) {
+	switch kindOf(v) {
+	case kindString:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case kindList:
+		return float64(v.Len()), true
+	case kindNumber:
+		return number(v), true
+	}
+	return 0, false
+}
+
+func number(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	default:
+		return v.Float()
+	}
+}
+
+// sizeRule builds min/max/len style rules (absent values pass; use required to reject them).
+func sizeRule(cmp func(n, param float64) bool) RuleFunc {
+	return func(f Field) bool {
+		if !f.Value.IsValid() {
+			return true
+		}
+		p, err := strconv.ParseFloat(f.Param, 64)
+		if err != nil {
+			return false
+		}
+		n, ok := size(f.Value)
+		return ok && cmp(n, p)
+	}
+}
+
+// stringRule builds rules on string values (absent values and non-strings pass).
+func stringRule(fn func(s string) bool) RuleFunc {
+	return func(f Field) bool {
+		if !f.Value.IsValid() || f.Value.Kind() != reflect.String {
+			return true
+		}
+		return fn(f.Value.String())
+	}
+}
+
+func oneOf(f Field) bool {
+	if !f.Value.IsValid() {
+		return true
+	}
+	var s string
+	switch kindOf(f.Value) {
+	case kindString:
+		s = f.Value.String()
+	case kindNumber:
+		s = strconv.FormatFloat(number(f.Value), 'f', -1, 64)
+	default:
+		return false
+	}
+	for _, opt := range strings.Fields(f.Param) {
+		if opt == s {
+			return true
+		}
+	}
+	return false
+}
+
+func matchRegex(f Field) bool {
+	if !f.Value.IsValid() || f.Value.Kind() != reflect.String {
+		return true
+	}
+	re, ok := regexCache.Load(f.Param)
+	if !ok {
+		compiled, err := regexp.Compile(f.Param)
+		if err != nil {
+			return false
+		}
+		re, _ = regexCache.LoadOrStore(f.Param, compiled)
+	}
+	return re.(*regexp.Regexp).MatchString(f.Value.String())
+}
+
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
+
+func isURL(s string) bool {
+	u, err := url.ParseRequestURI(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+func isNumeric(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+func allRunes(fn func(r rune) bool) func(s string) bool {
+	return func(s string) bool {
+		for _, r := range s {
+			if !fn(r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// sibling returns the field of the parent struct named by the Go field name.
+func sibling(f Field, name string) reflect.Value {
+	if !f.Parent.IsValid() || f.Parent.Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+	return indirect(f.Parent.FieldByName(name))
+}
+
+// compareField builds rules comparing the field with a sibling field (numbers, strings or time.Time).
+func compareField(ok func(c int) bool) RuleFunc {
+	return func(f Field) bool {
+		other := sibling(f, f.Param)
+		if !f.Value.IsValid() || !other.IsValid() {
+			return true
+		}
+		c, comparable := compare(f.Value, other)
+		return comparable && ok(c)
+	}
+}
+
+func compare(a, b reflect.Value) (int, bool) {
+	if ta, ok := a.Interface().(time.Time); ok {
+		tb, ok := b.Interface().(time.Time)
+		if !ok {
+			return 0, false
+		}
+		return ta.Compare(tb), true
+	}
+
+	ka, kb := kindOf(a), kindOf(b)
+	if ka != kb {
+		return 0, false
+	}
+	switch ka {
+	case kindString:
+		return strings.Compare(a.String(), b.String()), true
+	case kindNumber:
+		x, y := number(a), number(b)
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// requiredWith requires the field when the sibling field (required_with=Field) is present.
+func requiredWith(f Field) bool {
+	other := sibling(f, f.Param)
+	if !other.IsValid() || other.IsZero() {
+		return true
+	}
+	return f.Value.IsValid() && !f.Value.IsZero()
+}
+
+// requiredIf requires the field when the sibling field has the given value (required_if=Field value).
+func requiredIf(f Field) bool {
+	name, want, _ := strings.Cut(f.Param, " ")
+	other := sibling(f, name)
+	if !other.IsValid() {
+		return true
+	}
+	var got string
+	switch kindOf(other) {
+	case kindString:
+		got = other.String()
+	case kindNumber:
+		got = strconv.FormatFloat(number(other), 'f', -1, 64)
+	default:
+		got = strconv.FormatBool(other.Kind() == reflect.Bool && other.Bool())
+	}
+	if got != want {
+		return true
+	}
+	return f.Value.IsValid() && !f.Value.IsZero()
+}
@@ -0,0 +1,317 @@
+// Package validation validates request structs by `validate` struct tags.
+//
+// Rules are separated by commas and applied in order, e.g.
+//
+//	Name  string `json:"name" validate:"required,min=2,max=32"`
+//	Email string `json:"email,optional" validate:"omitempty,email"`
+//	Role  string `json:"role" validate:"oneof=admin member guest"`
+//	Code  string `json:"code" validate:"regex=^[A-Z]{3}-[0-9]+$"`
+//
+// The regex rule takes the rest of the tag as its pattern, so it must be the last rule.
+// Nested structs, pointers to structs and slices of structs are validated recursively.
+// Custom rules are added with Register; messages are localized by the request Accept-Language.
+package validation
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/addls/go-base/pkg/errcode"
+)
+
+// TagName is the struct tag read by the validator.
+const TagName = "validate"
+
+// FieldError describes a field that failed a rule.
+type FieldError struct {
+	Field   string `json:"field"`           // Field path by request name, e.g. items[0].name
+	Rule    string `json:"rule,omitempty"`  // Failed rule, e.g. min
+	Param   string `json:"param,omitempty"` // Rule parameter, e.g. 3 for min=3 (cross-field rules use request names)
+	Message string `json:"message"`         // Localized message
+}
+
+// Errors is the list of fields that failed validation.
+type Errors []FieldError
+
+// Error implements the error interface.
+func (es Errors) Error() string {
+	msgs := make([]string, 0, len(es))
+	for _, e := range es {
+		msgs = append(msgs, e.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Code returns ErrValidationFailed carrying the joined field messages.
+func (es Errors) Code() *errcode.Error {
+	return errcode.ErrValidationFailed.WithMsg(es.Error())
+}
+
+// Field is the value passed to a rule.
+type Field struct {
+	Value  reflect.Value // Field value (pointers are dereferenced; invalid for nil pointers)
+	Param  string        // Rule parameter, e.g. "3" for min=3
+	Parent reflect.Value // Struct containing the field, for cross-field rules
+}
+
+// RuleFunc reports whether the field satisfies the rule.
+type RuleFunc func(f Field) bool
+
+var (
+	rulesMu sync.RWMutex
+	rules   = map[string]RuleFunc{}
+
+	structCache sync.Map // reflect.Type -> []fieldInfo
+)
+
+// Register registers a custom rule with its messages keyed by locale (e.g. "en", "zh").
+// Messages may use {field} and {param} placeholders. Registering an existing name replaces it.
+func Register(name string, fn RuleFunc, messages map[string]string) {
+	rulesMu.Lock()
+	rules[name] = fn
+	rulesMu.Unlock()
+
+	for locale, msg := range messages {
+		RegisterMessage(locale, name, msg)
+	}
+}
+
+func lookupRule(name string) (RuleFunc, bool) {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	fn, ok := rules[name]
+	return fn, ok
+}
+
+// Validate validates v (a struct or pointer to struct) with messages in the default locale.
+// It returns Errors when a field fails, or an error when a tag refers to an unknown rule.
+func Validate(v interface{}) error {
+	return ValidateLocale(v, DefaultLocale())
+}
+
+// ValidateRequest validates v with messages in the locale preferred by the request (Accept-Language).
+func ValidateRequest(r *http.Request, v interface{}) error {
+	return ValidateLocale(v, LocaleFromRequest(r))
+}
+
+// ValidateLocale validates v with messages in the given locale.
+func ValidateLocale(v interface{}, locale string) error {
+	val := reflect.ValueOf(v)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs Errors
+	if err := validateStruct(val, "", locale, &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+type ruleSpec struct {
+	name  string
+	param string
+}
+
+type fieldInfo struct {
+	index int
+	name  string
+	rules []ruleSpec
+}
+
+// structFields returns the validated fields of a struct type (cached).
+func structFields(t reflect.Type) []fieldInfo {
+	if cached, ok := structCache.Load(t); ok {
+		return cached.([]fieldInfo)
+	}
+
+	fields := make([]fieldInfo, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		tag := sf.Tag.Get(TagName)
+		if tag == "-" {
+			continue
+		}
+		fields = append(fields, fieldInfo{
+			index: i,
+			name:  fieldName(sf),
+			rules: parseTag(tag),
+		})
+	}
+	structCache.Store(t, fields)
+	return fields
+}
+
+// fieldName returns the request name of a field (json, form, path or header tag), or the Go name.
+func fieldName(sf reflect.StructField) string {
+	for _, key := range []string{"json", "form", "path", "header"} {
+		if name, _, _ := strings.Cut(sf.Tag.Get(key), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+func parseTag(tag string) []ruleSpec {
+	var specs []ruleSpec
+	for tag != "" {
+		var part string
+		if strings.HasPrefix(tag, "regex=") {
+			part, tag = tag, ""
+		} else {
+			part, tag, _ = strings.Cut(tag, ",")
+		}
+		name, param, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name != "" {
+			specs = append(specs, ruleSpec{name: name, param: param})
+		}
+	}
+	return specs
+}
+
+func validateStruct(val reflect.Value, prefix, locale string, errs *Errors) error {
+	for _, fi := range structFields(val.Type()) {
+		fv := val.Field(fi.index)
+		path := fi.name
+		if prefix != "" {
+			path = prefix + "." + fi.name
+		}
+
+		failed, err := validateField(fv, val, fi, path, locale, errs)
+		if err != nil {
+			return err
+		}
+		if !failed {
+			if err := validateNested(fv, path, locale, errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateField applies the field rules and reports whether a rule failed (only the first failure is reported).
+func validateField(fv, parent reflect.Value, fi fieldInfo, path, locale string, errs *Errors) (bool, error) {
+	value := indirect(fv)
+	for _, spec := range fi.rules {
+		switch spec.name {
+		case "omitempty":
+			if isZero(fv) {
+				return false, nil
+			}
+			continue
+		case "required":
+			if isZero(fv) {
+				errs.add(locale, path, spec, value, parent)
+				return true, nil
+			}
+			continue
+		}
+
+		fn, ok := lookupRule(spec.name)
+		if !ok {
+			return false, fmt.Errorf("validation: unknown rule %q on field %s", spec.name, path)
+		}
+		if !fn(Field{Value: value, Param: spec.param, Parent: parent}) {
+			errs.add(locale, path, spec, value, parent)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// validateNested validates nested structs and slices (or arrays, maps) of structs.
+func validateNested(fv reflect.Value, path, locale string, errs *Errors) error {
+	v := indirect(fv)
+	if !v.IsValid() {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type().NumField() == 0 || v.Type().PkgPath() == "time" {
+			return nil
+		}
+		return validateStruct(v, path, locale, errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := validateNested(v.Index(i), path+"["+strconv.Itoa(i)+"]", locale, errs); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := validateNested(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key()), locale, errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (es *Errors) add(locale, path string, spec ruleSpec, value, parent reflect.Value) {
+	param := displayParam(spec, parent)
+	*es = append(*es, FieldError{
+		Field:   path,
+		Rule:    spec.name,
+		Param:   param,
+		Message: message(locale, spec.name, kindOf(value), path, param),
+	})
+}
+
+// displayParam returns the rule parameter shown in messages: cross-field rules name the
+// sibling field by its request name rather than its Go name.
+func displayParam(spec ruleSpec, parent reflect.Value) string {
+	if !strings.HasSuffix(spec.name, "field") && !strings.HasPrefix(spec.name, "required_") {
+		return spec.param
+	}
+	name, rest, _ := strings.Cut(spec.param, " ")
+	sf, ok := parent.Type().FieldByName(name)
+	if !ok {
+		return spec.param
+	}
+	if rest != "" {
+		return fieldName(sf) + "=" + rest
+	}
+	return fieldName(sf)
+}
+
+// indirect dereferences pointers and interfaces (returns an invalid value for nil).
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// isZero reports whether the field is absent: a nil pointer, or a zero value (empty string, slice, map).
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return !v.IsValid() || v.IsZero()
+	}
+}